@@ -0,0 +1,36 @@
+package jsonslice
+
+// arrayStart returns the first node of the node list calling an array function (see arrayFunctions)
+// which follows an aggregating path, nil if there is none
+func arrayStart(node *tNode) (prev, start *tNode) {
+	var p *tNode
+	for n := node; n != nil; p, n = n, n.Next {
+		if p == nil || n.Type&cFunction == 0 || len(n.Calls) == 0 || arrayFunctions[n.Calls[len(n.Calls)-1].name] == nil {
+			continue
+		}
+		p.Next = nil
+		agg := aggregates(node)
+		p.Next = n
+		if agg {
+			return p, n
+		}
+	}
+	return nil, nil
+}
+
+// getArrayResult evaluates an aggregating path (up to prev), applies an array function (start) to the result
+// and the rest of the path to the value returned
+func getArrayResult(input []byte, node, prev, start *tNode) ([]byte, error) {
+	prev.Next = nil
+	res, err := getRange(input, node, 0, -1)
+	prev.Next = start
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+	call := start.Calls[len(start.Calls)-1]
+	res, err = arrayFunctions[call.name](res, start, call)
+	if err != nil || len(res) == 0 || start.Next == nil {
+		return res, err
+	}
+	return getResult(res, start.Next) // $..book[*].sort(@.price)[0]
+}
@@ -0,0 +1,409 @@
+package jsonslice
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BSON values are transcoded to json as follows: ObjectIds are hex strings, datetimes are RFC 3339 strings,
+// binary data is a base64-encoded string, regular expressions are "/pattern/options" strings, JavaScript code
+// and symbols are strings, decimal128 values, int32, int64 and timestamps are numbers, undefined is null.
+
+var (
+	// BSON decodes a BSON document: GetWith(doc, "$.fullDocument.status", WithDecoder(BSON)), see also GetBSON
+	BSON Decoder = DecoderFunc(decodeBSON)
+)
+
+// GetBSON returns a part of a BSON document matching path as json. Leading key and index steps ($.a.b[2])
+// are resolved by skipping the length-prefixed elements, only the value found is transcoded.
+func GetBSON(doc []byte, path string) ([]byte, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	if err = checkFunctions(node, nil); err != nil {
+		return nil, err
+	}
+
+	d := &tBSON{tBinReader{input: doc}}
+	typ, start := byte(bsonDocument), 0
+	// root references ($ in filters), parents and member names need the whole document
+	if strings.IndexByte(path[1:], '$') < 0 && !hasNode(node, cParent|cName) {
+		for ; node != nil && indexable(node); node = node.Next {
+			var ok bool
+			if typ, start, ok, err = d.element(typ, start, node); err != nil {
+				return nil, decodeError("bson", d.pos, err)
+			}
+			if !ok {
+				return nil, nil
+			}
+		}
+	}
+	d.pos = start
+	input, err := d.value(make([]byte, 0, len(doc)), typ, 0)
+	if err != nil {
+		return nil, decodeError("bson", d.pos, err)
+	}
+	if node == nil {
+		return input, nil
+	}
+	return evaluate(input, node, nil)
+}
+
+// BSON element types
+const (
+	bsonDouble     = 0x01
+	bsonString     = 0x02
+	bsonDocument   = 0x03
+	bsonArray      = 0x04
+	bsonBinary     = 0x05
+	bsonUndefined  = 0x06
+	bsonObjectID   = 0x07
+	bsonBool       = 0x08
+	bsonDatetime   = 0x09
+	bsonNull       = 0x0a
+	bsonRegex      = 0x0b
+	bsonDBPointer  = 0x0c
+	bsonCode       = 0x0d
+	bsonSymbol     = 0x0e
+	bsonCodeScope  = 0x0f
+	bsonInt32      = 0x10
+	bsonTimestamp  = 0x11
+	bsonInt64      = 0x12
+	bsonDecimal128 = 0x13
+)
+
+// tBSON is the state of BSON decoding
+type tBSON struct {
+	tBinReader
+}
+
+// decodeBSON transcodes a BSON document into json
+func decodeBSON(input []byte) ([]byte, error) {
+	d := &tBSON{tBinReader{input: input}}
+	buf, err := d.value(make([]byte, 0, len(input)), bsonDocument, 0)
+	if err == nil && d.pos < len(input) {
+		err = errDecodeTrailingData
+	}
+	if err != nil {
+		return nil, decodeError("bson", d.pos, err)
+	}
+	return buf, nil
+}
+
+// int32 reads a little-endian int32
+func (d *tBSON) int32() (int, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int(int32(binary.LittleEndian.Uint32(b))), nil
+}
+
+// cstring reads a zero-terminated string
+func (d *tBSON) cstring() ([]byte, error) {
+	for i := d.pos; i < len(d.input); i++ {
+		if d.input[i] == 0 {
+			str := d.input[d.pos:i]
+			d.pos = i + 1
+			return str, nil
+		}
+	}
+	return nil, errUnexpectedEnd
+}
+
+// str reads a length-prefixed zero-terminated string
+func (d *tBSON) str() ([]byte, error) {
+	n, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+	str, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || str[n-1] != 0 {
+		d.pos -= n + 4
+		return nil, errDecodeUnsupported
+	}
+	return str[:n-1], nil
+}
+
+// skip skips a value of the type, faster than transcoding it: most values are length-prefixed or of a fixed size
+func (d *tBSON) skip(typ byte) error {
+	size := 0
+	switch typ {
+	case bsonDouble, bsonDatetime, bsonTimestamp, bsonInt64:
+		size = 8
+	case bsonObjectID:
+		size = 12
+	case bsonBool:
+		size = 1
+	case bsonInt32:
+		size = 4
+	case bsonDecimal128:
+		size = 16
+	case bsonUndefined, bsonNull:
+	case bsonString, bsonCode, bsonSymbol:
+		_, err := d.str()
+		return err
+	case bsonDocument, bsonArray, bsonCodeScope:
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		size = n - 4
+	case bsonBinary:
+		n, err := d.int32()
+		if err != nil {
+			return err
+		}
+		size = n + 1
+	case bsonRegex:
+		if _, err := d.cstring(); err != nil {
+			return err
+		}
+		_, err := d.cstring()
+		return err
+	case bsonDBPointer:
+		if _, err := d.str(); err != nil {
+			return err
+		}
+		size = 12
+	default:
+		return errDecodeUnsupported
+	}
+	_, err := d.next(size)
+	return err
+}
+
+// element finds the element of a document or an array (of the type, starting at start) selected by
+// a key or an index step. Returns the type and the start of the element value.
+func (d *tBSON) element(typ byte, start int, nod *tNode) (byte, int, bool, error) {
+	if typ != bsonDocument && typ != bsonArray {
+		return 0, 0, false, nil
+	}
+	d.pos = start
+	key := []byte(nod.Keys[0])
+	index := -1
+	if typ == bsonArray {
+		if nod.Slice[0] == cNAN {
+			return 0, 0, false, nil
+		}
+		index = nod.Slice[0]
+		if index < 0 {
+			n, err := d.count()
+			if err != nil {
+				return 0, 0, false, err
+			}
+			if index += n; index < 0 {
+				return 0, 0, false, nil
+			}
+			d.pos = start
+		}
+	}
+	if _, err := d.int32(); err != nil {
+		return 0, 0, false, err
+	}
+	for k := 0; ; k++ {
+		t, err := d.next(1)
+		if err != nil || t[0] == 0 {
+			return 0, 0, false, err
+		}
+		name, err := d.cstring()
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if k == index || index < 0 && string(name) == string(key) {
+			return t[0], d.pos, true, nil
+		}
+		if err = d.skip(t[0]); err != nil {
+			return 0, 0, false, err
+		}
+	}
+}
+
+// count returns the number of elements of a document or an array starting at d.pos
+func (d *tBSON) count() (int, error) {
+	if _, err := d.int32(); err != nil {
+		return 0, err
+	}
+	for n := 0; ; n++ {
+		t, err := d.next(1)
+		if err != nil || t[0] == 0 {
+			return n, err
+		}
+		if _, err = d.cstring(); err != nil {
+			return 0, err
+		}
+		if err = d.skip(t[0]); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// value appends a value of the type at d.pos to buf as json
+func (d *tBSON) value(buf []byte, typ byte, depth int) ([]byte, error) {
+	if depth > maxDecodeDepth {
+		return nil, errDecodeTooDeep
+	}
+	pos := d.pos
+	switch typ {
+	case bsonDocument, bsonArray:
+		return d.document(buf, typ == bsonArray, depth)
+	case bsonString, bsonCode, bsonSymbol:
+		str, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		return jsonQuote(buf, str), nil
+	case bsonCodeScope:
+		if _, err := d.int32(); err != nil {
+			return nil, err
+		}
+		str, err := d.str()
+		if err != nil {
+			return nil, err
+		}
+		if err = d.skip(bsonDocument); err != nil {
+			return nil, err
+		}
+		return jsonQuote(buf, str), nil
+	case bsonBinary:
+		n, err := d.int32()
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.next(n + 1) // subtype, data
+		if err != nil {
+			return nil, err
+		}
+		return appendBase64(buf, data[1:]), nil
+	case bsonRegex:
+		pattern, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		str := append(append(append([]byte{'/'}, pattern...), '/'), options...)
+		return jsonQuote(buf, str), nil
+	case bsonUndefined, bsonNull:
+		return append(buf, "null"...), nil
+	case bsonDBPointer:
+		d.pos = pos
+		return nil, errDecodeUnsupported // deprecated
+	}
+	if err := d.skip(typ); err != nil {
+		return nil, err
+	}
+	b := d.input[pos:d.pos]
+	switch typ {
+	case bsonDouble:
+		return appendFloat(buf, math.Float64frombits(binary.LittleEndian.Uint64(b)), 64), nil
+	case bsonObjectID:
+		buf = append(buf, '"')
+		buf = append(buf, hex.EncodeToString(b)...)
+		return append(buf, '"'), nil
+	case bsonBool:
+		return strconv.AppendBool(buf, b[0] != 0), nil
+	case bsonDatetime:
+		buf = append(buf, '"')
+		buf = time.UnixMilli(int64(binary.LittleEndian.Uint64(b))).UTC().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"'), nil
+	case bsonInt32:
+		return strconv.AppendInt(buf, int64(int32(binary.LittleEndian.Uint32(b))), 10), nil
+	case bsonTimestamp:
+		return strconv.AppendUint(buf, binary.LittleEndian.Uint64(b), 10), nil
+	case bsonInt64:
+		return strconv.AppendInt(buf, int64(binary.LittleEndian.Uint64(b)), 10), nil
+	}
+	return appendDecimal128(buf, binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])), nil
+}
+
+// document appends an object (or an array) of the elements of a document at d.pos
+func (d *tBSON) document(buf []byte, array bool, depth int) ([]byte, error) {
+	start := d.pos
+	n, err := d.int32()
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 || n > len(d.input)-start || d.input[start+n-1] != 0 {
+		d.pos = start
+		return nil, errUnexpectedEnd
+	}
+	opening, closing := byte('{'), byte('}')
+	if array {
+		opening, closing = '[', ']'
+	}
+	buf = append(buf, opening)
+	for k := 0; ; k++ {
+		t, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		if t[0] == 0 {
+			break
+		}
+		name, err := d.cstring()
+		if err != nil {
+			return nil, err
+		}
+		if k > 0 {
+			buf = append(buf, ',')
+		}
+		if !array {
+			buf = append(jsonQuote(buf, name), ':')
+		}
+		if buf, err = d.value(buf, t[0], depth+1); err != nil {
+			return nil, err
+		}
+	}
+	if d.pos != start+n {
+		return nil, errDecodeTrailingData
+	}
+	return append(buf, closing), nil
+}
+
+// appendDecimal128 appends an IEEE 754-2008 decimal128 (BID encoding) as a json number, NaN and infinities as null
+func appendDecimal128(buf []byte, lo, hi uint64) []byte {
+	var exp int
+	coef := new(big.Int)
+	switch {
+	case hi>>58&0x1f >= 0x1e: // infinity, NaN
+		return append(buf, "null"...)
+	case hi>>61&3 == 3: // the coefficient would exceed the maximum: non-canonical, zero
+		exp = int(hi >> 47 & 0x3fff)
+	default:
+		exp = int(hi >> 49 & 0x3fff)
+		coef.SetUint64(hi & (1<<49 - 1))
+		coef.Lsh(coef, 64).Or(coef, new(big.Int).SetUint64(lo))
+	}
+	exp -= 6176
+	if hi>>63 != 0 {
+		buf = append(buf, '-')
+	}
+	digits := coef.String()
+	switch {
+	case exp == 0:
+		buf = append(buf, digits...)
+	case exp < 0 && -exp <= len(digits):
+		if -exp == len(digits) {
+			buf = append(buf, '0')
+		}
+		buf = append(buf, digits[:len(digits)+exp]...)
+		buf = append(append(buf, '.'), digits[len(digits)+exp:]...)
+	default:
+		buf = append(append(buf, digits...), 'e')
+		buf = strconv.AppendInt(buf, int64(exp), 10)
+	}
+	return buf
+}
@@ -0,0 +1,135 @@
+package jsonslice
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// bsonDoc builds a BSON document of the elements
+func bsonDoc(elems ...[]byte) []byte {
+	doc := []byte{0, 0, 0, 0}
+	for _, el := range elems {
+		doc = append(doc, el...)
+	}
+	doc = append(doc, 0)
+	binary.LittleEndian.PutUint32(doc, uint32(len(doc)))
+	return doc
+}
+
+// bsonElem builds a BSON element
+func bsonElem(typ byte, name string, value []byte) []byte {
+	return append(append(append([]byte{typ}, name...), 0), value...)
+}
+
+// bsonStr builds a BSON string value
+func bsonStr(str string) []byte {
+	return append(append(le32(uint32(len(str)+1)), str...), 0)
+}
+
+// le32 and le64 return little-endian integers (binary.AppendUint* are not available in go 1.18)
+func le32(u uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, u)
+	return b
+}
+
+func le64(u uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, u)
+	return b
+}
+
+func Test_BSON(t *testing.T) {
+
+	f64 := le64(math.Float64bits(8.95))
+	dec := append(le64(12345), le64(uint64(6176-2)<<49)...) // 123.45
+	doc := bsonDoc(
+		bsonElem(bsonObjectID, "_id", []byte{0x65, 0x0a, 0x1b, 0x2c, 0x3d, 0x4e, 0x5f, 0x60, 0x71, 0x82, 0x93, 0xa4}),
+		bsonElem(bsonString, "operationType", bsonStr("insert")),
+		bsonElem(bsonDocument, "fullDocument", bsonDoc(
+			bsonElem(bsonString, "name", bsonStr(`say "hi"`)),
+			bsonElem(bsonArray, "items", bsonDoc(
+				bsonElem(bsonDocument, "0", bsonDoc(bsonElem(bsonDouble, "price", f64), bsonElem(bsonInt32, "qty", []byte{2, 0, 0, 0}))),
+				bsonElem(bsonDocument, "1", bsonDoc(bsonElem(bsonDecimal128, "price", dec), bsonElem(bsonInt64, "qty", le64(1<<40)))),
+			)),
+			bsonElem(bsonBool, "paid", []byte{1}),
+			bsonElem(bsonNull, "note", nil),
+			bsonElem(bsonDatetime, "created", le64(1700000000123)),
+			bsonElem(bsonBinary, "sig", append([]byte{2, 0, 0, 0, 0}, 1, 2)),
+			bsonElem(bsonRegex, "re", []byte("^a.*\x00i\x00")),
+		)),
+	)
+
+	tests := []struct {
+		Query    string
+		Expected string
+	}{
+		{`$._id`, `"650a1b2c3d4e5f60718293a4"`},
+		{`$.operationType`, `"insert"`},
+		{`$.fullDocument.name`, `"say \"hi\""`},
+		{`$.fullDocument.items[0]`, `{"price":8.95,"qty":2}`},
+		{`$.fullDocument.items[-1].price`, `123.45`},
+		{`$.fullDocument.items[-1].qty`, `1099511627776`},
+		{`$.fullDocument.items[1].qty`, `1099511627776`},
+		{`$.fullDocument.items[2]`, ``},
+		{`$.fullDocument.items[*].price`, `[8.95,123.45]`},
+		{`$.fullDocument.items[?(@.qty > 1)].price`, `[8.95,123.45]`},
+		{`$.fullDocument.items.length()`, `2`},
+		{`$.fullDocument.paid`, `true`},
+		{`$.fullDocument.note`, `null`},
+		{`$.fullDocument.created`, `"2023-11-14T22:13:20.123Z"`},
+		{`$.fullDocument.sig`, `"AQI="`},
+		{`$.fullDocument.re`, `"/^a.*/i"`},
+		{`$.fullDocument.missing.key`, ``},
+		{`$.operationType.key`, ``},
+		{`$..price`, `[8.95,123.45]`},
+		{`$.fullDocument.items[?(@.price > $.fullDocument.items[0].price)].qty`, `[1099511627776]`},
+		{`$`, `{"_id":"650a1b2c3d4e5f60718293a4","operationType":"insert","fullDocument":{"name":"say \"hi\"","items":[{"price":8.95,"qty":2},{"price":123.45,"qty":1099511627776}],"paid":true,"note":null,"created":"2023-11-14T22:13:20.123Z","sig":"AQI=","re":"/^a.*/i"}}`},
+	}
+	for _, tst := range tests {
+		res, err := GetBSON(doc, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if string(res) != tst.Expected {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+		// the same over the whole document transcoded
+		res, err = GetWith(doc, tst.Query, WithDecoder(BSON))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if string(res) != tst.Expected {
+			t.Errorf(tst.Query + " (decoder)\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// decimal128
+	for _, tst := range []struct {
+		lo, hi   uint64
+		Expected string
+	}{
+		{0, 0x3040000000000000, `0`},
+		{5, 0xb040000000000000, `-5`},
+		{1, 0x3046000000000000, `1e3`},
+		{15, 0x303c000000000000, `0.15`},
+		{1, 0x3032000000000000, `1e-7`},
+		{0, 0x7c00000000000000, `null`},
+	} {
+		if res := appendDecimal128(nil, tst.lo, tst.hi); string(res) != tst.Expected {
+			t.Errorf("decimal128 %x %x: expected `%s`, got `%s`", tst.hi, tst.lo, tst.Expected, res)
+		}
+	}
+
+	// errors
+	for k, input := range [][]byte{nil, doc[:20], bsonDoc(bsonElem(0x7f, "a", nil)), doc[:len(doc)-1], append(doc[:len(doc):len(doc)], 0)} {
+		// the elements following the one found are not read by GetBSON
+		if _, err := GetBSON(input, `$.fullDocument.paid`); err == nil && k < 3 {
+			t.Errorf("%q: error expected", input)
+		}
+		if _, err := GetWith(input, `$`, WithDecoder(BSON)); err == nil {
+			t.Errorf("%q: error expected", input)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package jsonslice
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// CBOR values are transcoded to json as follows: null and undefined are null, byte strings are base64-encoded strings,
+// bignums (tags 2 and 3) are numbers, other tags are ignored (the tagged value is used), map keys of other types
+// than text string are quoted (1 becomes "1"). Indefinite-length strings, arrays and maps are supported.
+
+// tCBOR is the state of CBOR decoding
+type tCBOR struct {
+	tBinReader
+}
+
+// decodeCBOR transcodes a CBOR data item into json
+func decodeCBOR(input []byte) ([]byte, error) {
+	d := &tCBOR{tBinReader{input: input}}
+	buf, err := d.value(make([]byte, 0, len(input)*2), 0)
+	if err == nil && d.pos < len(input) {
+		err = errDecodeTrailingData
+	}
+	if err != nil {
+		return nil, decodeError("cbor", d.pos, err)
+	}
+	return buf, nil
+}
+
+// head reads the initial byte of a data item and its argument: major type, additional information, argument
+func (d *tCBOR) head() (byte, byte, uint64, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info > 27:
+		return major, info, 0, nil // 31: indefinite length or break, 28-30: reserved
+	}
+	u, err := d.uint(1 << (info - 24))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return major, info, u, nil
+}
+
+// value appends the next data item of the input to buf as json
+func (d *tCBOR) value(buf []byte, depth int) ([]byte, error) {
+	if depth > maxDecodeDepth {
+		return nil, errDecodeTooDeep
+	}
+	pos := d.pos
+	major, info, u, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if info >= 28 && info <= 30 || info == 31 && (major < 2 || major == 6) {
+		d.pos = pos
+		return nil, errDecodeUnsupported
+	}
+	switch major {
+	case 0: // unsigned integer
+		return strconv.AppendUint(buf, u, 10), nil
+	case 1: // negative integer -1-u
+		if u < math.MaxInt64 {
+			return strconv.AppendInt(buf, -1-int64(u), 10), nil
+		}
+		n := new(big.Int).SetUint64(u)
+		return n.Neg(n.Add(n, big.NewInt(1))).Append(buf, 10), nil
+	case 2, 3: // byte string, text string
+		str, err := d.str(major, info, u)
+		if err != nil {
+			return nil, err
+		}
+		if major == 2 {
+			return appendBase64(buf, str), nil
+		}
+		return jsonQuote(buf, str), nil
+	case 4: // array
+		return d.array(buf, info, u, depth)
+	case 5: // map
+		return d.object(buf, info, u, depth)
+	case 6: // tag
+		return d.tagged(buf, u, depth)
+	}
+	// major type 7: simple values and floats
+	switch info {
+	case 20:
+		return append(buf, "false"...), nil
+	case 21:
+		return append(buf, "true"...), nil
+	case 22, 23: // null, undefined
+		return append(buf, "null"...), nil
+	case 25:
+		return appendFloat(buf, halfFloat(uint16(u)), 32), nil
+	case 26:
+		return appendFloat(buf, float64(math.Float32frombits(uint32(u))), 32), nil
+	case 27:
+		return appendFloat(buf, math.Float64frombits(u), 64), nil
+	}
+	d.pos = pos
+	if info == 31 {
+		return nil, errDecodeUnexpectedBreak
+	}
+	return nil, errDecodeUnsupported // other simple values
+}
+
+// str returns the contents of a byte or text string, chunks of an indefinite-length string concatenated
+func (d *tCBOR) str(major, info byte, u uint64) ([]byte, error) {
+	if info != 31 {
+		n, err := d.checkLength(u)
+		if err != nil {
+			return nil, err
+		}
+		return d.next(n)
+	}
+	var str []byte
+	for !d.isBreak() {
+		pos := d.pos
+		m, i, u, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || i == 31 {
+			d.pos = pos
+			return nil, errDecodeUnsupported // chunks are definite-length strings of the same type
+		}
+		chunk, err := d.str(m, i, u)
+		if err != nil {
+			return nil, err
+		}
+		str = append(str, chunk...)
+	}
+	return str, nil
+}
+
+// isBreak reports whether the next byte is the "break" stop code of an indefinite-length item and consumes it
+func (d *tCBOR) isBreak() bool {
+	if d.pos < len(d.input) && d.input[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// items calls fn for every item of a definite (u items) or an indefinite-length array or map
+func (d *tCBOR) items(info byte, u uint64, fn func(k int) error) error {
+	n := -1
+	if info != 31 {
+		var err error
+		if n, err = d.checkLength(u); err != nil {
+			return err
+		}
+	}
+	for k := 0; n < 0 && !d.isBreak() || k < n; k++ {
+		if d.pos >= len(d.input) {
+			return errUnexpectedEnd
+		}
+		if err := fn(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// array appends an array
+func (d *tCBOR) array(buf []byte, info byte, u uint64, depth int) ([]byte, error) {
+	buf = append(buf, '[')
+	err := d.items(info, u, func(k int) (err error) {
+		if k > 0 {
+			buf = append(buf, ',')
+		}
+		buf, err = d.value(buf, depth+1)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, ']'), nil
+}
+
+// object appends an object of a map
+func (d *tCBOR) object(buf []byte, info byte, u uint64, depth int) ([]byte, error) {
+	buf = append(buf, '{')
+	err := d.items(info, u, func(k int) (err error) {
+		if k > 0 {
+			buf = append(buf, ',')
+		}
+		start, pos := len(buf), d.pos
+		if buf, err = d.value(buf, depth+1); err != nil {
+			return err
+		}
+		if buf, err = appendDecodedKey(buf, start); err != nil {
+			d.pos = pos
+			return err
+		}
+		buf = append(buf, ':')
+		buf, err = d.value(buf, depth+1)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, '}'), nil
+}
+
+// tagged appends a tagged data item: a bignum (tags 2 and 3) as a number, other tags are ignored
+func (d *tCBOR) tagged(buf []byte, tag uint64, depth int) ([]byte, error) {
+	if tag != 2 && tag != 3 {
+		return d.value(buf, depth+1)
+	}
+	pos := d.pos
+	major, info, u, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != 2 {
+		d.pos = pos
+		return nil, errDecodeUnsupported // bignum is a byte string
+	}
+	data, err := d.str(major, info, u)
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetBytes(data)
+	if tag == 3 { // -1-n
+		n.Neg(n.Add(n, big.NewInt(1)))
+	}
+	return n.Append(buf, 10), nil
+}
+
+// halfFloat converts an IEEE 754 half-precision float
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
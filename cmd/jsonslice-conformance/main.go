@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bhmj/jsonslice/jsonslicetest"
+)
+
+func main() {
+	cts := flag.String("cts", "", "jsonpath-compliance-test-suite file (cts.json)")
+	comparison := flag.String("comparison", "", "json-path-comparison regression suite converted to json")
+	report := flag.String("report", "", "write machine-readable report to file")
+	flag.Parse()
+
+	if *cts == "" && *comparison == "" {
+		fmt.Fprintf(os.Stderr, "Run jsonslice over conformance suites.\nUsage:\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	r := &jsonslicetest.Report{}
+	if err := run(r, "cts", *cts, jsonslicetest.LoadCTS); err != nil {
+		fail(err)
+	}
+	if err := run(r, "comparison", *comparison, jsonslicetest.LoadComparison); err != nil {
+		fail(err)
+	}
+	if *report != "" {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		if err = os.WriteFile(*report, data, 0644); err != nil {
+			fail(err)
+		}
+	}
+	if err := r.Markdown(os.Stdout); err != nil {
+		fail(err)
+	}
+}
+
+func run(r *jsonslicetest.Report, suite, name string, load func(io.Reader) ([]jsonslicetest.Case, error)) error {
+	if name == "" {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cases, err := load(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	r.Run(suite, cases)
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
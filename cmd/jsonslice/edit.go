@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bhmj/jsonslice"
+)
+
+// verbs modify a json document, the number is the number of arguments before the input file
+var verbs = map[string]int{"set": 2, "delete": 1, "patch": 1}
+
+// tOperation is an operation of a patch
+type tOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// edit runs a verb: set <jsonpath> <value>, delete <jsonpath> or patch <operations>
+func edit(verb string, args []string) {
+	fs := flag.NewFlagSet(verb, flag.ExitOnError)
+	inPlace := fs.Bool("i", false, "edit the input file in place")
+	backup := fs.String("backup", "", "with -i, save the original file with the `suffix` added to its name")
+	fs.Usage = func() { editUsage(fs) }
+	args = parseInterspersed(fs, args)
+
+	n := verbs[verb]
+	if len(args) < n || len(args) > n+1 || *inPlace && len(args) == n || *backup != "" && !*inPlace {
+		fs.Usage()
+		os.Exit(2)
+	}
+	apply, err := editFunc(verb, args[:n])
+	if err != nil {
+		fail(err)
+	}
+
+	if *inPlace {
+		name := args[n]
+		written := false
+		err = jsonslice.EditFile(name, func(doc []byte) ([]byte, error) {
+			res, err := apply(doc)
+			if err != nil || *backup == "" {
+				return res, err
+			}
+			// the original is saved before the file is replaced: the edit fails if it can't be
+			if err = writeBackup(name, name+*backup, doc); err != nil {
+				return nil, err
+			}
+			written = true
+			return res, nil
+		})
+		if err != nil {
+			if written { // the file is intact
+				_ = os.Remove(name + *backup)
+			}
+			fail(err)
+		}
+		return
+	}
+
+	var data []byte
+	if len(args) == n {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(args[n])
+	}
+	if err != nil {
+		fail(err)
+	}
+	if data, err = apply(data); err != nil {
+		fail(err)
+	}
+	fmt.Println(strings.TrimRight(string(data), "\r\n"))
+}
+
+// editFunc returns a function applying the verb to a document
+func editFunc(verb string, args []string) (func(doc []byte) ([]byte, error), error) {
+	switch verb {
+	case "set":
+		if !json.Valid([]byte(args[1])) {
+			return nil, fmt.Errorf("set: invalid json value: %s", args[1])
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonslice.Set(doc, args[0], []byte(args[1]))
+		}, nil
+	case "delete":
+		return func(doc []byte) ([]byte, error) {
+			return jsonslice.Delete(doc, args[0])
+		}, nil
+	}
+	ops, err := readPatch(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(doc []byte) ([]byte, error) {
+		for k, op := range ops {
+			var err error
+			switch op.Op {
+			case "set":
+				doc, err = jsonslice.Set(doc, op.Path, op.Value)
+			case "delete":
+				doc, err = jsonslice.Delete(doc, op.Path)
+			case "copy":
+				doc, err = jsonslice.CopyValue(doc, op.From, op.Path)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("patch: operation %d (%s %s): %w", k, op.Op, op.Path, err)
+			}
+		}
+		return doc, nil
+	}, nil
+}
+
+// readPatch reads the operations of a patch given as json text or as a file name prefixed with '@'
+func readPatch(arg string) ([]tOperation, error) {
+	data := []byte(arg)
+	if strings.HasPrefix(arg, "@") {
+		var err error
+		if data, err = ioutil.ReadFile(arg[1:]); err != nil {
+			return nil, err
+		}
+	}
+	var ops []tOperation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("patch: %w", err)
+	}
+	for k, op := range ops {
+		var err error
+		switch {
+		case op.Op != "set" && op.Op != "delete" && op.Op != "copy":
+			err = fmt.Errorf("unknown op %q", op.Op)
+		case op.Path == "":
+			err = errors.New("path expected")
+		case op.Op == "set" && len(op.Value) == 0:
+			err = errors.New("value expected")
+		case op.Op == "copy" && op.From == "":
+			err = errors.New("from expected")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("patch: operation %d: %w", k, err)
+		}
+	}
+	return ops, nil
+}
+
+// writeBackup writes the original contents of a file to the backup file with the same permissions
+func writeBackup(name, backup string, doc []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(backup, doc, info.Mode().Perm())
+}
+
+// parseInterspersed parses the flags of a flag set found anywhere among the arguments
+// and returns the rest of the arguments. Negative numbers (set '$.a' -1) and the arguments
+// following "--" are not flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for len(args) > 0 {
+		if negativeNumber(args[0]) {
+			rest = append(rest, args[0])
+			args = args[1:]
+			continue
+		}
+		k := 1
+		for k < len(args) && !negativeNumber(args[k]) {
+			k++
+		}
+		_ = fs.Parse(args[:k]) // ExitOnError
+		tail := fs.Args()
+		if n := k - len(tail); n > 0 && args[n-1] == "--" {
+			return append(append(rest, tail...), args[k:]...)
+		}
+		if len(tail) > 0 {
+			rest = append(rest, tail[0])
+			tail = tail[1:]
+		}
+		args = append(append([]string{}, tail...), args[k:]...)
+	}
+	return rest
+}
+
+// negativeNumber returns true if the argument is a negative json number rather than a flag
+func negativeNumber(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && json.Valid([]byte(arg))
+}
+
+func editUsage(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "Modify JSON using jsonpath, the result is printed unless edited in place.\nUsage: %[1]s set <jsonpath> <value> [input_file] [-i [-backup <suffix>]]\n       %[1]s delete <jsonpath> [input_file] [-i [-backup <suffix>]]\n       %[1]s patch <operations | @operations_file> [input_file] [-i [-backup <suffix>]]\n", filepath.Base(os.Args[0]))
+	fs.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "A patch is a json array of operations applied in order:\n  [{\"op\": \"set\", \"path\": \"$.a.b\", \"value\": 42}, {\"op\": \"delete\", \"path\": \"$.c\"}, {\"op\": \"copy\", \"from\": \"$.a\", \"path\": \"$.d\"}]\nExamples:\n  %[1]s set '$.version' '\"1.2.0\"' package.json -i\n  %[1]s delete '$.items[?(@.deleted)]' data.json -i -backup .bak\n  %[1]s patch @release.json config.json -i\n", filepath.Base(os.Args[0]))
+}
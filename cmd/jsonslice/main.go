@@ -1,40 +1,178 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bhmj/jsonslice"
 )
 
+// tPaths is a repeatable -e flag
+type tPaths []string
+
+func (p *tPaths) String() string { return strings.Join(*p, " ") }
+
+func (p *tPaths) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
+
+// tFormat is the output format of a result
+type tFormat struct {
+	raw     bool
+	compact bool
+	pretty  bool
+}
+
 func main() {
+	if len(os.Args) > 1 && verbs[os.Args[1]] > 0 {
+		edit(os.Args[1], os.Args[2:])
+		return
+	}
+
+	var paths tPaths
+	flag.Var(&paths, "e", "jsonpath `expression`, repeat to get several values (one per line)")
+	stream := flag.Bool("stream", false, "input is newline-delimited json (JSON Lines), jsonpath is applied to each record\nand the result is printed on a line; input files are read one after another")
+	skipEmpty := flag.Bool("skip-empty", false, "in -stream mode, skip the records jsonpath matches nothing in")
+	lines := flag.Bool("l", false, "same as -stream -skip-empty")
+	raw := flag.Bool("r", false, "raw output: a string result is printed unquoted")
+	compact := flag.Bool("c", false, "compact output: no whitespace between json tokens")
+	pretty := flag.Bool("p", false, "pretty-print output indented by 2 spaces")
+	csv := flag.Bool("csv", false, "output the values as CSV rows: a row of every value (object), see jsonslice.GetCSV")
+	tsv := flag.Bool("tsv", false, "same as -csv, tab separated")
+	noheader := flag.Bool("noheader", false, "no header row of column names in CSV (TSV) output")
+	env := flag.Bool("env", false, "enable the env(\"NAME\") function reading environment variables in jsonpath")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(paths) == 0 && len(args) > 0 {
+		paths, args = tPaths{args[0]}, args[1:]
+	}
+	var comma rune
+	if *csv {
+		comma = ','
+	}
+	if *tsv {
+		comma = '\t'
+	}
+	if *lines {
+		*stream, *skipEmpty = true, true
+	}
+	if len(paths) == 0 || len(args) > 1 && !*stream || *compact && *pretty || comma != 0 && (*stream || len(paths) > 1) {
+		usage()
+		os.Exit(2)
+	}
+	format := tFormat{raw: *raw, compact: *compact, pretty: *pretty}
+	var opts []jsonslice.Option
+	if *env {
+		opts = append(opts, jsonslice.WithFunctions("env"))
+	}
 
-	if len(os.Args) < 2 {
-		fmt.Printf("Slice out a part of JSON using jsonpath.\nUsage: %[1]s jsonpath <expression> [input_file]\n  ex.1: %[1]s '$.store.book[0].author' sample0.json\n  ex.2: cat sample0.json | %[1]s '$.store.book[0].author'\n", filepath.Base(os.Args[0]))
+	if *stream {
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+		for _, name := range args {
+			if err := streamFile(name, paths, opts, format, *skipEmpty); err != nil {
+				fail(err)
+			}
+		}
 		return
 	}
 
 	var data []byte
 	var err error
 
-	if len(os.Args) == 2 {
+	if len(args) == 0 {
 		data, err = ioutil.ReadAll(os.Stdin)
 	} else {
-		data, err = ioutil.ReadFile(os.Args[2])
+		data, err = ioutil.ReadFile(args[0])
 	}
 	if err != nil {
-		fmt.Println(err)
+		fail(err)
+	}
+
+	if comma != 0 {
+		s, err := jsonslice.GetCSVWith(data, paths[0], comma, !*noheader, opts...)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Print(string(s))
 		return
 	}
 
-	s, err := jsonslice.Get(data, os.Args[1])
+	for _, path := range paths {
+		s, err := jsonslice.GetWith(data, path, opts...)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(format.apply(s)))
+	}
+}
 
-	if err != nil {
-		fmt.Println(err)
-		return
+// streamFile applies the paths to every record of a newline-delimited json file ("-" is stdin)
+func streamFile(name string, paths []string, opts []jsonslice.Option, format tFormat, skipEmpty bool) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	err := jsonslice.GetLines(r, "$", func(line int, rec []byte) error {
+		for _, path := range paths {
+			s, err := jsonslice.GetWith(rec, path, opts...)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if len(s) > 0 || !skipEmpty {
+				fmt.Println(string(format.apply(s)))
+			}
+		}
+		return nil
+	})
+	if err != nil && name != "-" {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return err
+}
+
+// apply formats a result: a string unquoted (raw), other values compacted or indented
+func (f tFormat) apply(val []byte) []byte {
+	var str string
+	if f.raw && json.Unmarshal(val, &str) == nil {
+		return []byte(str)
+	}
+	var res []byte
+	var err error
+	switch {
+	case f.compact:
+		res, err = jsonslice.Compact(val)
+	case f.pretty:
+		res, err = jsonslice.Indent(val, "", "  ")
 	}
+	if res == nil || err != nil {
+		return val
+	}
+	return res
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Slice out a part of JSON using jsonpath.\nUsage: %[1]s [flags] <jsonpath> [input_file]\n       %[1]s [flags] -e <jsonpath> [-e <jsonpath> ...] [input_file]\n       %[1]s -stream [flags] <jsonpath> [input_file ...]\n       %[1]s set | delete | patch ... (see %[1]s set -h)\n", filepath.Base(os.Args[0]))
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "Examples:\n  %[1]s '$.store.book[0].author' sample0.json\n  cat sample0.json | %[1]s -r '$.store.book[0].author'\n  %[1]s -p -e '$.store.bicycle' -e '$.store.book[0]' sample0.json\n  tail -f app.log | %[1]s -stream -skip-empty -r '$.msg'\n  %[1]s -stream -c '$.user' app-1.log app-2.log\n  %[1]s -csv \"$.store.book[:]['title','price']\" sample0.json\n  %[1]s -env '$.store.book[?(@.price < env(\"MAX_PRICE\"))].title' sample0.json\n", filepath.Base(os.Args[0]))
+}
 
-	fmt.Println(string(s))
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
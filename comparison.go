@@ -0,0 +1,210 @@
+package jsonslice
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/bhmj/xpression"
+)
+
+// By default filters compare values as JavaScript does: "1" == 1 is true, === and !== compare without
+// type coercion (the legacy dialect). With WithRFCComparison the comparisons follow RFC 9535:
+// every comparison of a filter is replaced with a call of a function comparing raw json values.
+
+// WithRFCComparison makes comparisons in filters (==, !=, <, <=, >, >=) follow RFC 9535: there is no type coercion,
+// arrays and objects are equal if their elements are equal, a missing value (@.nonexistent) is only equal
+// to another missing value, < and the like are only defined on two numbers or two strings and are false otherwise.
+// === and !== are the same as == and !=.
+func WithRFCComparison() Option {
+	return func(ctx *tContext) {
+		ctx.rfcCompare = true
+	}
+}
+
+// tComparison is a function replacing a comparison operator
+type tComparison struct {
+	name string
+	fn   tFilterFunc
+}
+
+// rfcOperators are the comparison functions replacing the operators
+var rfcOperators = map[xpression.Operator]tComparison{
+	opEqual:          {"==", rfcCompare(rfcEqual)},
+	opStrictEqual:    {"===", rfcCompare(rfcEqual)},
+	opNotEqual:       {"!=", rfcCompare(func(a, b []byte) bool { return !rfcEqual(a, b) })},
+	opStrictNotEqual: {"!==", rfcCompare(func(a, b []byte) bool { return !rfcEqual(a, b) })},
+	opLess:           {"<", rfcCompare(rfcLess)},
+	opLessEqual:      {"<=", rfcCompare(func(a, b []byte) bool { return rfcLess(a, b) || rfcEqual(a, b) })},
+	opGreater:        {">", rfcCompare(func(a, b []byte) bool { return rfcLess(b, a) })},
+	opGreaterEqual:   {">=", rfcCompare(func(a, b []byte) bool { return rfcLess(b, a) || rfcEqual(a, b) })},
+}
+
+// rfcCompare makes a filter function of a comparison
+func rfcCompare(cmp func(a, b []byte) bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		return jsonBool(cmp(bytes.TrimSpace(args[0]), bytes.TrimSpace(args[1]))), nil
+	}
+}
+
+// tPicker returns the function replacing a comparison of two operands, ok is false if the comparison is kept
+type tPicker func(op xpression.Operator, left, right *tArg) (cmp tComparison, ok bool)
+
+// replaceComparisons replaces the comparisons in filters of the node list with calls of ops
+func replaceComparisons(node *tNode, ops map[xpression.Operator]tComparison) error {
+	pick := func(op xpression.Operator, left, right *tArg) (tComparison, bool) {
+		cmp, ok := ops[op]
+		return cmp, ok
+	}
+	for n := node; n != nil; n = n.Next {
+		if err := replaceNodeComparisons(n, pick); err != nil {
+			return err
+		}
+		for _, part := range n.Union {
+			if err := replaceNodeComparisons(part, pick); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceNodeComparisons replaces the comparisons in the filter and the call arguments of a single node
+func replaceNodeComparisons(n *tNode, pick tPicker) error {
+	var err error
+	if n.Filter, err = replaceTokens(n.Filter, n, pick); err != nil {
+		return err
+	}
+	calls := n.Calls // new calls are made of the rewritten expressions
+	for _, call := range calls {
+		for _, arg := range call.args {
+			if arg.toks, err = replaceTokens(arg.toks, n, pick); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceTokens replaces every comparison of the expression with a placeholder of a call added to n.Calls:
+//
+//	@.a == 1   -->  jsfn__0   (call == with arguments @.a and 1)
+func replaceTokens(toks []*xpression.Token, n *tNode, pick tPicker) ([]*xpression.Token, error) {
+	// expressions are in prefix notation: inner comparisons follow the outer ones and are replaced first
+	for k := len(toks) - 1; k >= 0; k-- {
+		if _, ok := rfcOperators[toks[k].Operator]; !ok || toks[k].Category == 0 {
+			continue
+		}
+		m := subtreeEnd(toks, k+2) // skip the result placeholder
+		e := subtreeEnd(toks, m)
+		if e > len(toks) {
+			return toks, errPathInvalidExpression
+		}
+		left, right := tokenArg(toks[k+2:m]), tokenArg(toks[m:e])
+		op, ok := pick(toks[k].Operator, left, right)
+		if !ok {
+			continue
+		}
+		n.Calls = append(n.Calls, &tCall{
+			name: op.name,
+			fn:   op.fn,
+			args: []*tArg{left, right},
+		})
+		ph, err := parseExpression([]byte(callPrefix + strconv.Itoa(len(n.Calls)-1)))
+		if err != nil {
+			return toks, err
+		}
+		toks = append(toks[:k:k], append(ph, toks[e:]...)...)
+	}
+	return toks, nil
+}
+
+// subtreeEnd returns the end of the operand starting at toks[i]
+func subtreeEnd(toks []*xpression.Token, i int) int {
+	if i >= len(toks) {
+		return len(toks) + 1
+	}
+	tok := toks[i]
+	switch {
+	case tok.Type == xpression.VariableOperand:
+		return i + 2 // variable and its result
+	case tok.Operator == 0:
+		return i + 1 // literal
+	}
+	e := subtreeEnd(toks, i+2) // operator, its result, operand(s)
+	if tok.Operator != opLogicalNOT && tok.Operator != opBitwiseNOT && tok.Operator != opUnaryMinus {
+		e = subtreeEnd(toks, e)
+	}
+	return e
+}
+
+// tokenArg makes a call argument of an operand
+func tokenArg(toks []*xpression.Token) *tArg {
+	if len(toks) == 2 && toks[0].Type == xpression.VariableOperand {
+		str := toks[0].Str
+		if n, ok := placeholderIndex(str); ok {
+			return &tArg{call: n + 1}
+		}
+		if str[0] == '@' || str[0] == '$' {
+			return &tArg{ref: str}
+		}
+	}
+	return &tArg{toks: append([]*xpression.Token(nil), toks...)}
+}
+
+// rfcEqual compares two json values, nil being a missing value
+func rfcEqual(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	switch {
+	case a[0] == '[' && b[0] == '[':
+		x, okx := argArray(a)
+		y, oky := argArray(b)
+		if !okx || !oky || len(x) != len(y) {
+			return false
+		}
+		for k := range x {
+			if !rfcEqual(bytes.TrimSpace(x[k]), bytes.TrimSpace(y[k])) {
+				return false
+			}
+		}
+		return true
+	case a[0] == '{' && b[0] == '{':
+		x, errx := appendMembers(nil, a, 0)
+		y, erry := appendMembers(nil, b, 0)
+		if errx != nil || erry != nil || len(x) != len(y) {
+			return false
+		}
+		for _, mx := range x {
+			found := false
+			for _, my := range y {
+				if bytes.Equal(mx.key, my.key) {
+					found = rfcEqual(a[mx.start:mx.end], b[my.start:my.end])
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case a[0] == '[' || a[0] == '{' || b[0] == '[' || b[0] == '{':
+		return false
+	}
+	return jsonEqual(a, b)
+}
+
+// rfcLess returns true if a is less than b: both are numbers or both are strings
+func rfcLess(a, b []byte) bool {
+	var x, y xpression.Operand
+	if len(a) == 0 || len(b) == 0 || decodeValue(a, &x) != nil || decodeValue(b, &y) != nil || x.Type != y.Type {
+		return false
+	}
+	switch {
+	case x.Type == xpression.NumberOperand:
+		return x.Number < y.Number
+	case a[0] == '"' && b[0] == '"':
+		return bytes.Compare(unescape(x.Str), unescape(y.Str)) < 0
+	}
+	return false
+}
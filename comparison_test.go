@@ -0,0 +1,60 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_RFCComparison(t *testing.T) {
+
+	doc := []byte(`[{"a":1},{"a":"1"},{"a":[1,2]},{"a":{"x":1,"y":[2]}},{"b":2},{"a":null},{"a":true},{"a":"b"},{"a":2.0}]`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		// no type coercion
+		{`$[?(@.a == 1)]`, []byte(`[{"a":1}]`)},
+		{`$[?(@.a == "1")]`, []byte(`[{"a":"1"}]`)},
+		{`$[?(@.a == 2)]`, []byte(`[{"a":2.0}]`)},
+		{`$[?(@.a === 2)]`, []byte(`[{"a":2.0}]`)},
+		{`$[?(@.a == null)]`, []byte(`[{"a":null}]`)},
+		// missing values
+		{`$[?(@.a != 1)]`, []byte(`[{"a":"1"},{"a":[1,2]},{"a":{"x":1,"y":[2]}},{"b":2},{"a":null},{"a":true},{"a":"b"},{"a":2.0}]`)},
+		{`$[?(@.a == @.c)]`, []byte(`[{"b":2}]`)},
+		// structural equality
+		{`$[?(@.a == [1,2])]`, []byte(`[{"a":[1,2]}]`)},
+		{`$[?(@.a == $[3].a)]`, []byte(`[{"a":{"x":1,"y":[2]}}]`)},
+		// ordering of numbers and strings only
+		{`$[?(@.a < 2)]`, []byte(`[{"a":1}]`)},
+		{`$[?(@.a >= 1)]`, []byte(`[{"a":1},{"a":2.0}]`)},
+		{`$[?(@.a > "a")]`, []byte(`[{"a":"b"}]`)},
+		{`$[?(@.a <= "1")]`, []byte(`[{"a":"1"}]`)},
+		// nested expressions
+		{`$[?(!(@.a == 1) && @.a)]`, []byte(`[{"a":"1"},{"a":[1,2]},{"a":{"x":1,"y":[2]}},{"a":true},{"a":"b"},{"a":2.0}]`)},
+		{`$[?(@.a == 1 || @.a == true)]`, []byte(`[{"a":1},{"a":true}]`)},
+		{`$[?(@.a + 1 == 3)]`, []byte(`[{"a":2.0}]`)},
+		{`$[?(@.a == -1 * -2)]`, []byte(`[{"a":2.0}]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(doc, tst.Query, WithRFCComparison())
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// the legacy dialect is the default
+	res, _ := Get(doc, `$[?(@.a == "1")]`)
+	if string(res) != `[{"a":1},{"a":"1"},{"a":true}]` {
+		t.Errorf("legacy comparison: unexpected %s", res)
+	}
+	p, err := CompileWith(`$[?(@.a == 1)]`, WithRFCComparison())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ = p.Get(doc)
+	if string(res) != `[{"a":1}]` {
+		t.Errorf("CompileWith: unexpected %s", res)
+	}
+}
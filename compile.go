@@ -0,0 +1,169 @@
+package jsonslice
+
+import (
+	"sync"
+)
+
+// Path is a compiled jsonpath: the path is parsed once and evaluated many times.
+// A Path is safe for concurrent use: evaluation keeps its state in the nodes (see tNode), so the parsed
+// node list is never evaluated itself. Every evaluation takes a copy of it from a pool, the copy is made
+// when the pool is empty, i.e. the first time as many goroutines evaluate the path at once.
+type Path struct {
+	path       string
+	parsed     *tNode    // the parsed path, copied for evaluation
+	nodes      sync.Pool // copies of the parsed path
+	format     *tFormat  // result reformatting, see WithCompact and WithIndent
+	disabled   Extension // see WithoutExtensions
+	rfcCompare bool      // see WithRFCComparison
+	exact      bool      // see WithExactNumbers
+}
+
+// Compile parses jsonpath and returns a Path which can be evaluated against any number of inputs
+func Compile(path string) (*Path, error) {
+	return CompileWith(path)
+}
+
+// CompileWith is the same as Compile but accepts the options affecting parsing: WithoutExtensions,
+// WithRFCComparison, WithExactNumbers and WithPolicy, and the output format: WithCompact, WithIndent
+// and WithNormalizedNumbers.
+// A disabled extension or a policy violation is reported by CompileWith. Other options are ignored.
+func CompileWith(path string, opts ...Option) (*Path, error) {
+	ctx := &tContext{}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	if ctx.err != nil {
+		return nil, ctx.err
+	}
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.policy != nil {
+		nodes := 0
+		err = ctx.policy.check(node, &nodes)
+	}
+	if err == nil {
+		err = ctx.restrict(node)
+	}
+	if err != nil {
+		repool(node)
+		return nil, err
+	}
+	p := &Path{path: path, parsed: node, format: ctx.format, disabled: ctx.disabled, rfcCompare: ctx.rfcCompare,
+		exact: ctx.exact}
+	p.nodes.New = func() interface{} {
+		return cloneNodes(p.parsed)
+	}
+	return p, nil
+}
+
+// MustCompile is like Compile but panics if the path cannot be parsed
+func MustCompile(path string) *Path {
+	p, err := Compile(path)
+	if err != nil {
+		panic(`jsonslice: Compile(` + path + `): ` + err.Error())
+	}
+	return p
+}
+
+// Get returns a part of input matching the path. The result is the same as of jsonslice.Get.
+func (p *Path) Get(input []byte) ([]byte, error) {
+	return p.get(input, nil)
+}
+
+// GetWith is the same as Get but accepts evaluation options (see GetWith): WithVars, WithDocument,
+// WithFunctions, WithMaxDepth, WithContext, WithStats and the like. The options affecting parsing
+// (WithoutExtensions, WithRFCComparison, WithExactNumbers and WithPolicy) are given to CompileWith,
+// GetWith returns ErrCompileOption for them.
+func (p *Path) GetWith(input []byte, opts ...Option) ([]byte, error) {
+	ctx := &tContext{}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	if ctx.err != nil {
+		return nil, ctx.err
+	}
+	if ctx.disabled != 0 || ctx.rfcCompare || ctx.exact || ctx.policy != nil {
+		return nil, ErrCompileOption
+	}
+	ctx.disabled, ctx.rfcCompare, ctx.exact = p.disabled, p.rfcCompare, p.exact
+	return ctx.run(input, p.path, func(input []byte) ([]byte, error) {
+		return p.get(input, ctx)
+	})
+}
+
+// get evaluates a copy of the parsed path on input
+func (p *Path) get(input []byte, ctx *tContext) ([]byte, error) {
+	if len(p.path) == 1 && p.path[0] == '$' {
+		return p.reformat(input, nil)
+	}
+	node := p.take()
+	result, err := evaluate(input, node, ctx)
+	p.release(node)
+	return p.reformat(result, err)
+}
+
+// take returns a copy of the parsed path for a single evaluation
+func (p *Path) take() *tNode {
+	node, _ := p.nodes.Get().(*tNode)
+	return node
+}
+
+// release clears the state of an evaluation (see tNode) and returns the copy to the pool
+func (p *Path) release(node *tNode) {
+	for n := node; n != nil; n = n.Next {
+		if n.Next != nil && n.Next.Type == cLocate { // see locate
+			nodePool.Put(n.Next)
+			n.Next = nil
+			for _, part := range n.Union {
+				part.Next = nil
+			}
+		}
+		n.ctx, n.root, n.pos = nil, nil, -1
+		for _, part := range n.Union {
+			part.ctx, part.root, part.pos = nil, nil, -1
+		}
+	}
+	p.nodes.Put(node)
+}
+
+// cloneNodes returns a copy of a node list which can be evaluated independently of the original:
+// the filter tokens (receiving intermediate results) and the calls are copied, the rest is shared
+func cloneNodes(node *tNode) *tNode {
+	if node == nil {
+		return nil
+	}
+	c := cloneNode(node)
+	c.Next = cloneNodes(node.Next)
+	c.Union = make([]*tNode, len(node.Union))
+	for i, part := range node.Union {
+		c.Union[i] = cloneNode(part)
+		c.Union[i].Next = c.Next
+	}
+	return c
+}
+
+// cloneNode returns a copy of a single node, see cloneNodes
+func cloneNode(nod *tNode) *tNode {
+	c := *nod
+	c.Keys = append([]word(nil), nod.Keys...)
+	c.Elems = append([]int(nil), nod.Elems...)
+	c.Filter = cloneTokens(nod.Filter)
+	c.Calls = cloneCalls(nod.Calls)
+	c.ctx, c.root, c.pos = nil, nil, -1
+	return &c
+}
+
+// reformat applies the output format of the path to a result
+func (p *Path) reformat(result []byte, err error) ([]byte, error) {
+	if err != nil || p.format == nil {
+		return result, err
+	}
+	return p.format.apply(result)
+}
+
+// String returns the source text of the path
+func (p *Path) String() string {
+	return p.path
+}
@@ -0,0 +1,187 @@
+package jsonslice
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_Compile(t *testing.T) {
+
+	for _, tst := range expressionTests() {
+		p, err := Compile(tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		for k := 0; k < 2; k++ { // reuse
+			res, err := p.Get(data)
+			if err != nil {
+				t.Errorf(tst.Query + " : " + err.Error())
+			} else if compareSlices(res, tst.Expected) != 0 {
+				t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+			}
+		}
+	}
+
+	if _, err := Compile(`$.store(foo`); err == nil || err.Error() != "path: invalid character at 7" {
+		t.Errorf("invalid path: expected `path: invalid character at 7`, got %v", err)
+	}
+}
+
+func Test_CompileFormat(t *testing.T) {
+
+	input := []byte(`{"items": [{"a":1,"b":[2,3]}, { "a" : 4 , "b" : [ 5 ] },
+	{
+		"a": 6,
+		"b": []
+	}]}`)
+	tests := []struct {
+		Query    string
+		Opt      Option
+		Expected string
+	}{
+		{`$.items[:]`, nil, "[{\"a\":1,\"b\":[2,3]},{ \"a\" : 4 , \"b\" : [ 5 ] },{\n\t\t\"a\": 6,\n\t\t\"b\": []\n\t}]"},
+		{`$.items[:]`, WithCompact(), `[{"a":1,"b":[2,3]},{"a":4,"b":[5]},{"a":6,"b":[]}]`},
+		{`$.items[:].b`, WithCompact(), `[[2,3],[5],[]]`},
+		{`$.items[1]`, WithCompact(), `{"a":4,"b":[5]}`},
+		{`$.items[1]`, WithIndent("", " "), "{\n \"a\": 4,\n \"b\": [\n  5\n ]\n}"},
+		{`$`, WithCompact(), `{"items":[{"a":1,"b":[2,3]},{"a":4,"b":[5]},{"a":6,"b":[]}]}`},
+		{`$.items[?(@.a > 10)]`, WithCompact(), `[]`},
+	}
+	doc := ParseStructure(input)
+	for _, tst := range tests {
+		var opts []Option
+		if tst.Opt != nil {
+			opts = append(opts, tst.Opt)
+		}
+		p, err := CompileWith(tst.Query, opts...)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		for _, res := range [][]byte{mustGet(p.Get(input)), mustGet(doc.GetPath(p)), mustGet(GetWith(input, tst.Query, opts...))} {
+			if string(res) != tst.Expected {
+				t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+			}
+		}
+	}
+}
+
+func Test_CompileConcurrent(t *testing.T) {
+
+	p := MustCompile(`$.store.book[?(@.price > $.expensive && @.author in $.store.book[*].author)].price`)
+	expected, _ := Get(data, p.String())
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				res, err := p.Get(data)
+				if err != nil || compareSlices(res, expected) != 0 {
+					t.Errorf("expected `%s`, got `%s` (%v)", expected, res, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_CompileState(t *testing.T) {
+
+	p := MustCompile(`$.store.book[?(@.price > $.expensive)].price`)
+	expected, _ := Get(data, p.String())
+	size := 0
+	for n := p.parsed; n != nil; n = n.Next {
+		size++
+	}
+	for i := 0; i < 3; i++ {
+		var offsets [][2]int
+		res, err := p.GetWith(data, WithOffsets(&offsets), WithMaxDepth(5))
+		if err != nil || compareSlices(res, expected) != 0 || len(offsets) != 2 {
+			t.Errorf("expected `%s`, got `%s` %v (%v)", expected, res, offsets, err)
+		}
+		// the copy is returned to the pool without the state of the evaluation
+		node := p.take()
+		k := 0
+		for n := node; n != nil; n = n.Next {
+			if n.ctx != nil || n.root != nil {
+				t.Errorf("node %s keeps the state of an evaluation", n.Src)
+			}
+			k++
+		}
+		p.release(node)
+		if k != size {
+			t.Errorf("expected %d nodes, got %d", size, k)
+		}
+	}
+	for n := p.parsed; n != nil; n = n.Next {
+		if n.ctx != nil || n.root != nil {
+			t.Errorf("parsed node %s has been evaluated", n.Src)
+		}
+	}
+}
+
+func Benchmark_Jsonslice_Path_Get_Simple(b *testing.B) {
+	p := MustCompile("$.store.bicycle.price")
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Get(data)
+	}
+}
+
+func Benchmark_Jsonslice_Path_Get_Filter(b *testing.B) {
+	p := MustCompile("$.store.book[?(@.price > 10)].title")
+	for i := 0; i < b.N; i++ {
+		_, _ = p.Get(data)
+	}
+}
+
+func Benchmark_Jsonslice_Get_Filter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = Get(data, "$.store.book[?(@.price > 10)].title")
+	}
+}
+
+func Test_ConcurrentEvaluation(t *testing.T) {
+
+	type tCompiled struct {
+		path     *Path
+		expected []byte
+	}
+	var paths []tCompiled
+	for _, tst := range expressionTests() {
+		paths = append(paths, tCompiled{MustCompile(tst.Query), tst.Expected})
+	}
+	doc := ParseStructure(data)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := range paths {
+				p := paths[(k+g*7)%len(paths)] // different goroutines evaluate different paths at a time
+				for _, res := range [][]byte{
+					mustGet(p.path.Get(data)),
+					mustGet(doc.GetPath(p.path)),
+					mustGet(GetWith(data, p.path.String(), WithWorkers(2))),
+				} {
+					if compareSlices(res, p.expected) != 0 {
+						t.Errorf(p.path.String() + "\n\texpected `" + string(p.expected) + "`\n\tbut got  `" + string(res) + "`")
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func mustGet(res []byte, err error) []byte {
+	if err != nil {
+		return []byte(err.Error())
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package jsonslice
+
+import (
+	"context"
+)
+
+// GetContext is the same as GetWith but stops evaluation as soon as c is done: deepscan, filters
+// (including the references they evaluate) and array or object scans check c on every value they visit,
+// so a runaway query ($..*[?(@ =~ /.../)] on a large input) can be abandoned. Skipping over a single large value is not interrupted.
+// Returns c.Err() if c is done before the evaluation completes.
+func GetContext(c context.Context, input []byte, path string, opts ...Option) ([]byte, error) {
+	return GetWith(input, path, append([]Option{WithContext(c)}, opts...)...)
+}
+
+// WithContext makes GetWith stop evaluation as soon as c is done (see GetContext)
+func WithContext(c context.Context) Option {
+	return func(ctx *tContext) {
+		ctx.cancel = c
+		ctx.done = c.Done()
+	}
+}
+
+// cancelled returns the context error if the evaluation context is done
+func (ctx *tContext) cancelled() error {
+	if ctx == nil || ctx.done == nil {
+		return nil
+	}
+	select {
+	case <-ctx.done:
+		return ctx.cancel.Err()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package jsonslice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_GetContext(t *testing.T) {
+
+	res, err := GetContext(context.Background(), data, `$.store.book[?(@.price > 10)].title`)
+	if err != nil || compareSlices(res, []byte(`["Sword of Honour","The Lord of the Rings"]`)) != 0 {
+		t.Errorf("unexpected result `%s` %v", res, err)
+	}
+
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, path := range []string{`$..price`, `$.store.book[?(@.price > 10)]`, `$.store.book[1:3]..title`, `$.expensive`} {
+		if _, err = GetContext(c, data, path); !errors.Is(err, context.Canceled) {
+			t.Errorf(path+" : context.Canceled expected, got %v", err)
+		}
+	}
+
+	if testing.Short() {
+		return
+	}
+	book, _ := Get(data, "$.store.book[0]")
+	largeData := append([]byte(`{"store":{"book":[`), book...)
+	for i := 0; i < 20000; i++ {
+		largeData = append(append(largeData, ','), book...)
+	}
+	largeData = append(largeData, "]}}"...)
+	for _, path := range []string{
+		`$..*[?(@ =~ /Tolkien/)]`,           // deepscan
+		`$.store.book[*].author`,            // array elements
+		`$.store.book[1:].author`,           // array slice
+		`$.store[?(@..author == 'nobody')]`, // deepscan reference in a filter
+		`$[?(@.book[*].price == 12345)]`,    // array reference in a filter
+	} {
+		start := time.Now()
+		_, _ = Get(largeData, path)
+		full := time.Since(start)
+
+		c, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+		start = time.Now()
+		_, err = GetContext(c, largeData, path)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf(path+" : context.DeadlineExceeded expected, got %v", err)
+		}
+		if d := time.Since(start); d > full/2 {
+			t.Errorf(path+" : evaluation was not stopped in time: %v (%v without a deadline)", d, full)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package jsonslice
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+// GetCSV returns the values matching path as CSV rows separated by comma (',' or '\t' for TSV),
+// with a header row of the column names if header is true:
+//
+//	$.store.book[:]['title','price']  -- a row of every book, the columns are the keys
+//	$.store.book[*]                   -- a row of every object, the columns are all the keys met
+//	$..author                         -- a row of every value, a single column "value"
+//
+// A string is written as its contents, a missing value or null as an empty cell, other values as json text.
+func GetCSV(input []byte, path string, comma rune, header bool) ([]byte, error) {
+	return GetCSVWith(input, path, comma, header)
+}
+
+// GetCSVWith is the same as GetCSV but accepts the options affecting filter evaluation:
+// WithFunctions, WithVars, WithDocument
+func GetCSVWith(input []byte, path string, comma rune, header bool, opts ...Option) ([]byte, error) {
+	var ctx *tContext
+	if len(opts) > 0 {
+		ctx = &tContext{}
+		for _, opt := range opts {
+			opt(ctx)
+		}
+		if ctx.err != nil {
+			return nil, ctx.err
+		}
+	}
+	columns, rows, err := csvTable(input, path, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = comma
+	if header {
+		if err = w.Write(columns); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range rows {
+		if err = w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvTable returns the column names and the rows of the values matching path
+func csvTable(input []byte, path string, ctx *tContext) ([]string, [][]string, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer repool(node)
+
+	// $.store.book[:]['title','price']: the rows are selected by the path without the keys
+	var prev, last *tNode
+	for last = node; last != nil && last.Next != nil; prev, last = last, last.Next {
+	}
+	var keys [][]byte
+	if last != nil && last.Type&(cAgg|cDeep|cGlob|cUnion|cFunction) == cAgg && len(last.Elems) == 0 {
+		for _, key := range last.Keys {
+			keys = append(keys, []byte(key))
+		}
+		if prev == nil {
+			node = nil
+		} else {
+			prev.Next = nil
+			defer func() { prev.Next = last }()
+		}
+	}
+
+	var res []byte
+	if node == nil {
+		res = input
+	} else if res, err = evaluate(input, node, ctx); err != nil {
+		return nil, nil, err
+	}
+	values := [][]byte{res}
+	if elems, ok := argArray(res); ok && aggregates(node) {
+		values = elems
+	}
+	if len(res) == 0 {
+		values = nil
+	}
+
+	if keys == nil {
+		objects := len(values) > 0
+		for _, val := range values {
+			objects = objects && jsonType(val) == "object"
+		}
+		if !objects {
+			rows := make([][]string, len(values))
+			for k, val := range values {
+				rows[k] = []string{csvCell(val)}
+			}
+			return []string{"value"}, rows, nil
+		}
+	}
+	// objects: the columns are the keys given or all the keys met
+	all := keys == nil
+	seen := make(map[string]bool)
+	members := make([][]tMember, len(values))
+	for k, val := range values {
+		val = bytes.TrimSpace(val)
+		if jsonType(val) != "object" {
+			continue
+		}
+		if members[k], err = appendMembers(nil, val, 0); err != nil {
+			return nil, nil, err
+		}
+		for _, m := range members[k] {
+			if all && !seen[string(m.key)] {
+				seen[string(m.key)] = true
+				keys = append(keys, m.key)
+			}
+		}
+	}
+	columns := make([]string, len(keys))
+	for k, key := range keys {
+		columns[k] = string(key)
+	}
+	rows := make([][]string, len(values))
+	for k, val := range values {
+		val = bytes.TrimSpace(val)
+		rows[k] = make([]string, len(keys))
+		for i, key := range keys {
+			for _, m := range members[k] {
+				if bytes.Equal(m.key, key) {
+					rows[k][i] = csvCell(val[m.start:m.end])
+					break
+				}
+			}
+		}
+	}
+	return columns, rows, nil
+}
+
+// csvCell returns the text of a value in a CSV cell
+func csvCell(val []byte) string {
+	val = bytes.TrimSpace(val)
+	if str, ok := argString(val); ok {
+		return string(str)
+	}
+	if len(val) == 0 || jsonType(val) == "null" {
+		return ""
+	}
+	return string(val)
+}
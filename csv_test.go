@@ -0,0 +1,65 @@
+package jsonslice
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_GetCSV(t *testing.T) {
+
+	tests := []struct {
+		Query    string
+		Expected string
+	}{
+		{`$.store.book[:]['title','price']`, "title,price\nSayings of the Century,8.95\nSword of Honour,12.99\nMoby Dick,8.99\nThe Lord of the Rings,22.99\n"},
+		{`$.store.book[:2]['author','isbn']`, "author,isbn\nNigel Rees,\nEvelyn Waugh,\n"},
+		{`$.store.book[?(@.isbn)]['title','isbn']`, "title,isbn\nMoby Dick,0-553-21311-3\nThe Lord of the Rings,0-395-19395-8\n"},
+		{`$.store.bicycle['color','price']`, "color,price\nred,19.95\n"},
+		{`$.store.book[:2]`, "category,author,title,price\nreference,Nigel Rees,Sayings of the Century,8.95\nfiction,Evelyn Waugh,Sword of Honour,12.99\n"},
+		{`$.store.book[1:3]`, "category,author,title,price,isbn\nfiction,Evelyn Waugh,Sword of Honour,12.99,\nfiction,Herman Melville,Moby Dick,8.99,0-553-21311-3\n"},
+		{`$.store.book[*].author`, "value\nNigel Rees\nEvelyn Waugh\nHerman Melville\nJ. R. R. Tolkien\n"},
+		{`$.store.book[?(@.price > 20)].price`, "value\n22.99\n"},
+		{`$.store.book[?(@.price > 100)].title`, "value\n"},
+		{`$.store.book[?(@.isbn)]{name: @.title, "isbn": @.isbn}`, "name,isbn\nMoby Dick,0-553-21311-3\nThe Lord of the Rings,0-395-19395-8\n"},
+	}
+
+	for _, tst := range tests {
+		res, err := GetCSV(data, tst.Query, ',', true)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if compareSlices(res, []byte(tst.Expected)) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// TSV without a header, quoting and nested values
+	input := []byte(`[{"a": "x, \"y\"", "b": [1, 2]}, {"a": null, "b": {"c": true}}]`)
+	res, err := GetCSV(input, `$[*]['a','b']`, '\t', false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "\"x, \"\"y\"\"\"\t[1, 2]\n\t\"{\"\"c\"\": true}\"\n"
+	if string(res) != expected {
+		t.Errorf("TSV\n\texpected `" + expected + "`\n\tbut got  `" + string(res) + "`")
+	}
+
+	// opt-in functions
+	os.Setenv("JSONSLICE_TEST_MIN", "10")
+	defer os.Unsetenv("JSONSLICE_TEST_MIN")
+	path := `$.store.book[?(@.price > env("JSONSLICE_TEST_MIN"))]['title','price']`
+	res, err = GetCSVWith(data, path, ',', false, WithFunctions("env"))
+	expected = "Sword of Honour,12.99\nThe Lord of the Rings,22.99\n"
+	if err != nil || string(res) != expected {
+		t.Errorf(path + "\n\texpected `" + expected + "`\n\tbut got  `" + string(res) + "`")
+	}
+	if _, err = GetCSV(data, path, ',', false); err != errFunctionDisabled {
+		t.Errorf("expected errFunctionDisabled, got %v", err)
+	}
+
+	// errors
+	if _, err = GetCSV(data, `$.`, ',', true); err == nil {
+		t.Errorf("invalid path: error expected")
+	}
+}
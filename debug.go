@@ -0,0 +1,56 @@
+package jsonslice
+
+// DebugEventKind is a kind of debug event
+type DebugEventKind int
+
+const (
+	DebugParsed  DebugEventKind = iota // path node parsed
+	DebugSegment                       // evaluation entered a path node
+	DebugFilter                        // filter evaluated on an array element
+	DebugSkip                          // value skipped without descending into it
+)
+
+var debugEventNames = [...]string{"parsed", "segment", "filter", "skip"}
+
+func (k DebugEventKind) String() string {
+	if k < 0 || int(k) >= len(debugEventNames) {
+		return "unknown"
+	}
+	return debugEventNames[k]
+}
+
+// DebugEvent is a single event emitted during parsing or evaluation
+type DebugEvent struct {
+	Kind   DebugEventKind
+	Node   string // path node as written in the path (spaces removed), e.g. `[?(@.price>10)]`
+	Value  []byte // value the node is applied to (segment), filtered element (filter) or skipped value (skip); subslice of input, do not modify
+	Result bool   // filter result
+}
+
+// WithDebugLogger sets a function receiving debug events during parsing and evaluation.
+// The function is called synchronously so it should be cheap.
+func WithDebugLogger(fn func(event DebugEvent)) Option {
+	return func(ctx *tContext) {
+		ctx.debug = fn
+	}
+}
+
+// emit sends debug event if debug logger is set
+func (ctx *tContext) emit(kind DebugEventKind, nod *tNode, value []byte, result bool) {
+	if ctx == nil || ctx.debug == nil {
+		return
+	}
+	ctx.debug(DebugEvent{Kind: kind, Node: string(nod.Src), Value: value, Result: result})
+}
+
+// segment sends DebugSegment event with the value bounds trimmed
+func (ctx *tContext) segment(nod *tNode, input []byte) {
+	if ctx == nil || ctx.debug == nil {
+		return
+	}
+	e, err := skipValue(input, 0)
+	if err != nil {
+		e = len(input)
+	}
+	ctx.emit(DebugSegment, nod, input[:e], false)
+}
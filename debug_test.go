@@ -0,0 +1,35 @@
+package jsonslice
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_DebugLogger(t *testing.T) {
+	input := []byte(`{"skip": 1, "book": [{"price": 8}, {"price": 22}]}`)
+
+	var events []string
+	logger := func(e DebugEvent) {
+		events = append(events, e.Kind.String()+" "+e.Node+" "+string(e.Value)+" "+strconv.FormatBool(e.Result))
+	}
+	res, err := GetWith(input, `$.book[?(@.price > 10)].price`, WithDebugLogger(logger))
+	if err != nil || string(res) != `[22]` {
+		t.Fatalf("unexpected result `%s` (%v)", res, err)
+	}
+	expected := []string{
+		`parsed .book  false`,
+		`parsed [?(@.price>10)]  false`,
+		`parsed .price  false`,
+		`segment .book {"skip": 1, "book": [{"price": 8}, {"price": 22}]} false`,
+		`skip .book 1 false`,
+		`segment [?(@.price>10)] [{"price": 8}, {"price": 22}] false`,
+		`filter [?(@.price>10)] {"price": 8} false`,
+		`skip [?(@.price>10)] {"price": 8} false`,
+		`filter [?(@.price>10)] {"price": 22} true`,
+		`segment .price {"price": 22} false`,
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("\n\texpected\n%s\n\tbut got\n%s", strings.Join(expected, "\n"), strings.Join(events, "\n"))
+	}
+}
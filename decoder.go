@@ -0,0 +1,111 @@
+package jsonslice
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Decoder converts an input of another format into json, see WithDecoder
+type Decoder interface {
+	Decode(input []byte) ([]byte, error)
+}
+
+// DecoderFunc is a function used as a Decoder
+type DecoderFunc func(input []byte) ([]byte, error)
+
+// Decode calls f(input)
+func (f DecoderFunc) Decode(input []byte) ([]byte, error) {
+	return f(input)
+}
+
+var (
+	// MessagePack decodes MessagePack: GetWith(payload, "$.temp", WithDecoder(MessagePack))
+	MessagePack Decoder = DecoderFunc(decodeMsgPack)
+	// CBOR decodes CBOR (RFC 8949): GetWith(payload, "$.temp", WithDecoder(CBOR))
+	CBOR Decoder = DecoderFunc(decodeCBOR)
+)
+
+// WithDecoder evaluates the path over an input of another format (MessagePack, CBOR or a custom Decoder)
+// transcoded to json in a single pass. The result is json, offsets (see WithOffsets) refer to the transcoded input.
+func WithDecoder(d Decoder) Option {
+	return func(ctx *tContext) {
+		ctx.decoder = d
+	}
+}
+
+// maxDecodeDepth limits the nesting of the decoded values
+const maxDecodeDepth = 1000
+
+// decodeError returns an error found at pos of the input of the format
+func decodeError(format string, pos int, err error) error {
+	return fmt.Errorf("%s: %w at %d", format, err, pos)
+}
+
+// tBinReader is the input cursor of the binary format decoders (MessagePack, CBOR, BSON)
+type tBinReader struct {
+	input []byte
+	pos   int
+}
+
+// next returns the next n bytes of the input
+func (r *tBinReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.input)-r.pos {
+		return nil, errUnexpectedEnd
+	}
+	r.pos += n
+	return r.input[r.pos-n : r.pos], nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes
+func (r *tBinReader) uint(n int) (uint64, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// checkLength checks the number of bytes or elements of a string, an array or a map read from the input
+func (r *tBinReader) checkLength(u uint64) (int, error) {
+	if u > uint64(len(r.input)) {
+		return 0, errUnexpectedEnd // every element takes at least a byte
+	}
+	return int(u), nil
+}
+
+// appendFloat appends a float as a json number, NaN and infinities (not representable in json) as null
+func appendFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(buf, "null"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bits)
+}
+
+// appendBase64 appends binary data as a base64-encoded json string (as encoding/json does)
+func appendBase64(buf []byte, data []byte) []byte {
+	buf = append(buf, '"')
+	n := len(buf)
+	buf = append(buf, make([]byte, base64.StdEncoding.EncodedLen(len(data)))...)
+	base64.StdEncoding.Encode(buf[n:], data)
+	return append(buf, '"')
+}
+
+// appendDecodedKey appends a decoded map key (the json text at buf[start:]) as a string:
+// a number or a boolean key is quoted, an array or an object is not a valid key
+func appendDecodedKey(buf []byte, start int) ([]byte, error) {
+	key := buf[start:]
+	switch jsonType(key) {
+	case "string":
+		return buf, nil
+	case "number", "boolean", "null":
+		str := string(key)
+		return jsonQuote(buf[:start], []byte(str)), nil
+	}
+	return nil, errDecodeInvalidKey
+}
@@ -0,0 +1,77 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Decoders(t *testing.T) {
+
+	tests := []struct {
+		Name     string
+		Decoder  Decoder
+		Input    string
+		Query    string
+		Expected string
+	}{
+		// MessagePack
+		{"map", MessagePack, "\x83\xa1a\x93\x01\xfe\xa1x\xa1b\x82\xa1c\xc3\x01\xc0\xa1f\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00", `$`, `{"a":[1,-2,"x"],"b":{"c":true,"1":null},"f":1.5}`},
+		{"path", MessagePack, "\x83\xa1a\x93\x01\xfe\xa1x\xa1b\x82\xa1c\xc3\x01\xc0\xa1f\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00", `$.a[?(@ < 0)]`, `[-2]`},
+		{"ints", MessagePack, "\x96\xcd\x01\x00\xd0\x80\xd1\xff\x00\xd3\xff\xff\xff\xff\xff\xff\xff\xff\xcf\xff\xff\xff\xff\xff\xff\xff\xff\xe0", `$`, `[256,-128,-256,-1,18446744073709551615,-32]`},
+		{"strings", MessagePack, "\x93\xd9\x03abc\xa2q\"\xda\x00\x00", `$`, `["abc","q\"",""]`},
+		{"floats", MessagePack, "\x92\xca\x40\x20\x00\x00\xcb\x7f\xf8\x00\x00\x00\x00\x00\x01", `$`, `[2.5,null]`},
+		{"bin, ext", MessagePack, "\x93\xc4\x02\x01\x02\xd6\xff\x00\x00\x00\x01\xd4\x05\x07", `$`, `["AQI=","1970-01-01T00:00:01Z","Bw=="]`},
+		{"array16, map16", MessagePack, "\xdc\x00\x02\xde\x00\x01\xa1k\xc2\x90", `$`, `[{"k":false},[]]`},
+		// CBOR
+		{"map", CBOR, "\xa3\x61a\x83\x01\x21\x61x\x61b\xa2\x61c\xf5\x01\xf6\x61f\xf9\x3e\x00", `$`, `{"a":[1,-2,"x"],"b":{"c":true,"1":null},"f":1.5}`},
+		{"path", CBOR, "\xa3\x61a\x83\x01\x21\x61x\x61b\xa2\x61c\xf5\x01\xf6\x61f\xf9\x3e\x00", `$..c`, `[true]`},
+		{"ints", CBOR, "\x86\x19\x01\x00\x38\x63\x1b\xff\xff\xff\xff\xff\xff\xff\xff\x3b\xff\xff\xff\xff\xff\xff\xff\xff\xc2\x49\x01\x00\x00\x00\x00\x00\x00\x00\x00\xc3\x41\x00", `$`, `[256,-100,18446744073709551615,-18446744073709551616,18446744073709551616,-1]`},
+		{"indefinite", CBOR, "\x9f\x01\x7f\x62ab\x61c\xff\xbf\x61k\x9f\xff\xff\xff", `$`, `[1,"abc",{"k":[]}]`},
+		{"simple, floats", CBOR, "\x86\xf4\xf7\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00\xfa\x40\x20\x00\x00\xf9\x7c\x00\xf9\x00\x01", `$`, `[false,null,1.5,2.5,null,5.9604645e-08]`},
+		{"bytes, tags", CBOR, "\x82\x42\x01\x02\xc1\x1a\x5a\x00\x00\x00", `$`, `["AQI=",1509949440]`},
+		// custom
+		{"func", DecoderFunc(func(input []byte) ([]byte, error) { return []byte(`{"v": "` + string(input) + `"}`), nil }), "abc", `$.v`, `"abc"`},
+	}
+	for _, tst := range tests {
+		res, err := GetWith([]byte(tst.Input), tst.Query, WithDecoder(tst.Decoder))
+		if err != nil {
+			t.Errorf(tst.Name + ": " + tst.Query + " : " + err.Error())
+			continue
+		}
+		if string(res) != tst.Expected {
+			t.Errorf(tst.Name + ": " + tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// errors
+	errorTests := []struct {
+		Decoder  Decoder
+		Input    string
+		Expected string
+	}{
+		{MessagePack, "", "msgpack: unexpected end of input at 0"},
+		{MessagePack, "\x92\x01", "msgpack: unexpected end of input at 2"},
+		{MessagePack, "\x01\x02", "msgpack: unexpected data after the value at 1"},
+		{MessagePack, "\x91\xc1", "msgpack: unsupported value at 1"},
+		{MessagePack, "\x81\x90\x01", "msgpack: map key is not a string or a number at 1"},
+		{MessagePack, "\xdd\xff\xff\xff\xff", "msgpack: unexpected end of input at 5"},
+		{CBOR, "\x83\x01\x02", "cbor: unexpected end of input at 3"},
+		{CBOR, "\xff", "cbor: unexpected break at 0"},
+		{CBOR, "\x1f", "cbor: unsupported value at 0"},
+		{CBOR, "\x9f\x01", "cbor: unexpected end of input at 2"},
+		{CBOR, "\x7f\x01\xff", "cbor: unsupported value at 1"},
+	}
+	for _, tst := range errorTests {
+		_, err := GetWith([]byte(tst.Input), `$`, WithDecoder(tst.Decoder))
+		if err == nil || err.Error() != tst.Expected {
+			t.Errorf("%q: expected `%s`, got %v", tst.Input, tst.Expected, err)
+		}
+	}
+	deep := make([]byte, maxDecodeDepth+2)
+	for k := range deep {
+		deep[k] = 0x91
+	}
+	if _, err := GetWith(deep, `$`, WithDecoder(MessagePack)); !errors.Is(err, errDecodeTooDeep) {
+		t.Errorf("deep nesting: expected `%v`, got %v", errDecodeTooDeep, err)
+	}
+}
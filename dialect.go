@@ -0,0 +1,190 @@
+package jsonslice
+
+import (
+	"fmt"
+
+	"github.com/bhmj/xpression"
+)
+
+// Extension is a non-standard feature of the path syntax. All the extensions are enabled by default,
+// consumers needing stricter paths may disable them with WithoutExtensions.
+type Extension int
+
+const (
+	// ExtDotBracket is .[] notation: $.a.[0], $.['a']
+	ExtDotBracket Extension = 1 << iota
+	// ExtUnquotedKeys are unquoted keys in brackets: $[a], $[a,b]
+	ExtUnquotedKeys
+	// ExtDotIndex are dot-notated numbers: $.a.2
+	ExtDotIndex
+	// ExtAbstractEquality is type coercion in == and != ("1" == 1). Disabled, == and != compare as === and !==.
+	ExtAbstractEquality
+	// ExtStrictEquality are the === and !== operators
+	ExtStrictEquality
+	// ExtRegexp is regular expression matching: =~ /.../, !=~ /.../
+	ExtRegexp
+	// ExtExpression is a path being an expression: $.store.book[*].price.sum() * 1.2, ($.a + $.b)
+	ExtExpression
+	// ExtProjection is a projection building an object of every value: $.book[*]{"name": @.title}
+	ExtProjection
+)
+
+// extensionNames are used in error messages
+var extensionNames = []struct {
+	ext  Extension
+	name string
+}{
+	{ExtDotBracket, ".[] notation"},
+	{ExtUnquotedKeys, "unquoted key"},
+	{ExtDotIndex, "dot-notated index"},
+	{ExtStrictEquality, "strict equality operator"},
+	{ExtRegexp, "regular expression"},
+	{ExtExpression, "path expression"},
+	{ExtProjection, "projection"},
+}
+
+// xpression operator codes
+const (
+	opEqual          xpression.Operator = 'E'
+	opNotEqual       xpression.Operator = 'N'
+	opStrictEqual    xpression.Operator = 'e'
+	opStrictNotEqual xpression.Operator = 'n'
+	opRegexMatch     xpression.Operator = 'R'
+	opNotRegexMatch  xpression.Operator = 'r'
+	opLess           xpression.Operator = 'l'
+	opLessEqual      xpression.Operator = 'L'
+	opGreater        xpression.Operator = 'g'
+	opGreaterEqual   xpression.Operator = 'G'
+	opLogicalNOT     xpression.Operator = '!'
+	opBitwiseNOT     xpression.Operator = '~'
+	opUnaryMinus     xpression.Operator = '_'
+)
+
+// WithoutExtensions disables extensions of the path syntax: WithoutExtensions(ExtDotBracket | ExtRegexp).
+// A path using a disabled extension is an error matching ErrExtensionDisabled (errors.Is),
+// except for ExtAbstractEquality which makes == and != strict. Flags of several options are combined.
+func WithoutExtensions(ext Extension) Option {
+	return func(ctx *tContext) {
+		ctx.disabled |= ext
+	}
+}
+
+// restrict applies the disabled extensions and the comparison semantics of the context to the node list
+func (ctx *tContext) restrict(node *tNode) error {
+	if ctx == nil {
+		return nil
+	}
+	if ctx.disabled != 0 {
+		if err := ctx.disabled.restrict(node); err != nil {
+			return err
+		}
+	}
+	if ctx.exact {
+		return replaceComparisons(node, exactOperators[ctx.rfcCompare])
+	}
+	if ctx.rfcCompare {
+		return replaceComparisons(node, rfcOperators)
+	}
+	return nil
+}
+
+// restrict checks the node list (including references in filters) for the use of disabled extensions
+// and turns == and != into === and !== if ExtAbstractEquality is disabled
+func (ext Extension) restrict(node *tNode) error {
+	for n := node; n != nil; n = n.Next {
+		if err := ext.restrictNode(n); err != nil {
+			return err
+		}
+		for _, part := range n.Union {
+			if err := ext.restrictNode(part); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restrictNode restricts a single node
+func (ext Extension) restrictNode(n *tNode) error {
+	if used := n.Ext & ext; used != 0 {
+		return extensionError(used, n.Src)
+	}
+	var err error
+	if n.Filter, err = ext.restrictTokens(n.Filter, n.Src); err != nil {
+		return err
+	}
+	for _, call := range n.Calls {
+		if (call.name == "===" || call.name == "!==") && ext&ExtStrictEquality > 0 { // see listComparisons
+			return extensionError(ExtStrictEquality, n.Src)
+		}
+		for _, arg := range call.args {
+			if arg.toks, err = ext.restrictTokens(arg.toks, n.Src); err != nil {
+				return err
+			}
+			if err := ext.restrictRef(arg.ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restrictTokens restricts the operators and references of a filter expression.
+// Returns the expression which may be rewritten: a != b is !(a === b) unless ExtAbstractEquality is enabled.
+func (ext Extension) restrictTokens(toks []*xpression.Token, src []byte) ([]*xpression.Token, error) {
+	for k := 0; k < len(toks); k++ {
+		tok := toks[k]
+		if tok.Category == 0 {
+			continue // intermediate result
+		}
+		if tok.Type == xpression.VariableOperand {
+			if err := ext.restrictRef(tok.Str); err != nil {
+				return toks, err
+			}
+			continue
+		}
+		var used Extension
+		switch tok.Operator {
+		case opEqual:
+			if ext&ExtAbstractEquality > 0 {
+				tok.Operator = opStrictEqual
+			}
+		case opNotEqual:
+			if ext&ExtAbstractEquality > 0 {
+				// expressions are in prefix notation, every operator is followed by its result placeholder
+				tok.Operator = opStrictEqual
+				not := &xpression.Token{Category: tok.Category, Operator: opLogicalNOT}
+				toks = append(toks[:k:k], append([]*xpression.Token{not, {}}, toks[k:]...)...)
+				k += 2
+			}
+		case opStrictEqual, opStrictNotEqual:
+			used = ExtStrictEquality
+		case opRegexMatch, opNotRegexMatch:
+			used = ExtRegexp
+		}
+		if used&ext != 0 {
+			return toks, extensionError(used, src)
+		}
+	}
+	return toks, nil
+}
+
+// restrictRef checks a reference used in a filter. Filters of the reference are restricted on evaluation.
+func (ext Extension) restrictRef(ref []byte) error {
+	node := parseRef(ref)
+	if node == nil {
+		return nil
+	}
+	defer repool(node)
+	return ext.restrict(node)
+}
+
+// extensionError reports the use of a disabled extension
+func extensionError(used Extension, src []byte) error {
+	for _, e := range extensionNames {
+		if used&e.ext > 0 {
+			return fmt.Errorf("%w: %s in %s", ErrExtensionDisabled, e.name, src)
+		}
+	}
+	return ErrExtensionDisabled
+}
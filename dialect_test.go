@@ -0,0 +1,60 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_WithoutExtensions(t *testing.T) {
+
+	doc := []byte(`{"a":[{"x":"1"},{"x":1},{"x":"abc"},{"y":1}],"k":{"2":1}}`)
+	tests := []struct {
+		Query    string
+		Ext      Extension
+		Expected []byte
+		Disabled bool
+	}{
+		{`$.a.[1].x`, ExtDotBracket, nil, true},
+		{`$..[1].x`, ExtDotBracket, []byte(`[1]`), false},
+		{`$[a][1].x`, ExtUnquotedKeys, nil, true},
+		{`$['a'][1].x`, ExtUnquotedKeys, []byte(`1`), false},
+		{`$.a.1.x`, ExtDotIndex, nil, true},
+		{`$.a[1].x`, ExtDotIndex, []byte(`1`), false},
+		{`$.a[?(@.x === 1)]`, ExtStrictEquality, nil, true},
+		{`$.a[?(@.x =~ /b/)]`, ExtRegexp, nil, true},
+		{`$.a[?(@.x == $.k.2)]`, ExtDotIndex, nil, true}, // references in filters
+		{`$.a[?(@.x == 1)]`, ExtRegexp, []byte(`[{"x":"1"},{"x":1}]`), false},
+		{`$.a.length() * 2`, ExtExpression, nil, true},
+		{`$.a.length()`, ExtExpression, []byte(`4`), false},
+		{`$.a[*]{v: @.x}`, ExtProjection, nil, true},
+		// strict == and != without type coercion
+		{`$.a[?(@.x == 1)]`, ExtAbstractEquality, []byte(`[{"x":1}]`), false},
+		{`$.a[?(@.x != 1)]`, ExtAbstractEquality, []byte(`[{"x":"1"},{"x":"abc"},{"y":1}]`), false},
+		{`$.a[?(@.x != 1 && @.x != 'abc')]`, ExtAbstractEquality, []byte(`[{"x":"1"},{"y":1}]`), false},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(doc, tst.Query, WithoutExtensions(tst.Ext))
+		if tst.Disabled {
+			if !errors.Is(err, ErrExtensionDisabled) {
+				t.Errorf(tst.Query+" : ErrExtensionDisabled expected, got %v", err)
+			}
+		} else if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	if _, err := CompileWith(`$.a.[0]`, WithoutExtensions(ExtDotBracket)); !errors.Is(err, ErrExtensionDisabled) {
+		t.Errorf("CompileWith: ErrExtensionDisabled expected, got %v", err)
+	}
+	p, err := CompileWith(`$.a[?(@.x == 1)]`, WithoutExtensions(ExtAbstractEquality))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ := p.Get(doc)
+	if string(res) != `[{"x":1}]` {
+		t.Errorf("CompileWith: unexpected %s", res)
+	}
+}
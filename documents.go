@@ -0,0 +1,80 @@
+package jsonslice
+
+import (
+	"encoding/json"
+)
+
+// WithDocument makes a named document available in filter expressions as $name:
+//
+//	GetWith(input, `$.items[?(@.id in $allow.ids)]`, WithDocument("allow", allowlist))
+//
+// The name must start with a letter. A document is evaluated once per query, not per element.
+func WithDocument(name string, doc []byte) Option {
+	return func(ctx *tContext) {
+		if ctx.docs == nil {
+			ctx.docs = make(map[string][]byte)
+		}
+		ctx.docs[name] = doc
+	}
+}
+
+// WithVars makes variables available in filter expressions as $name and $vars.name:
+//
+//	GetWith(input, `$.items[?(@.price > $min && @.tag == $vars.tag)]`, WithVars(map[string]interface{}{"min": 10, "tag": "new"}))
+//
+// Values are converted to json with encoding/json; a conversion error is returned by GetWith.
+// Documents added by WithDocument take precedence over variables of the same name.
+func WithVars(vars map[string]interface{}) Option {
+	return func(ctx *tContext) {
+		all, err := json.Marshal(vars)
+		if err != nil {
+			ctx.err = err
+			return
+		}
+		if ctx.docs == nil {
+			ctx.docs = make(map[string][]byte)
+		}
+		if _, ok := ctx.docs["vars"]; !ok {
+			ctx.docs["vars"] = all
+		}
+		for name, v := range vars {
+			if _, ok := ctx.docs[name]; ok {
+				continue
+			}
+			ctx.docs[name], _ = json.Marshal(v) // already checked above
+		}
+	}
+}
+
+// rootRef evaluates a root-based reference: $.a against input, $name.a against a named document.
+// Returns nil if not found.
+func (ctx *tContext) rootRef(input []byte, ref []byte) []byte {
+	if name, path := docRef(ref); name != nil {
+		if ctx == nil {
+			return nil
+		}
+		doc, ok := ctx.docs[string(name)]
+		if !ok {
+			return nil
+		}
+		input, ref = doc, append([]byte{'$'}, path...)
+	}
+	val, err := get(input, string(ref), ctx.refContext())
+	if err != nil {
+		return nil
+	}
+	return val
+}
+
+// docRef splits a reference to a named document ($doc.a, $doc[0]) into the name and the path following it.
+// The name is nil if ref does not refer to a named document ($.a, @.a).
+func docRef(ref []byte) (name, path []byte) {
+	if len(ref) < 2 || !isLetter(ref[1]) {
+		return nil, ref[1:]
+	}
+	i := 1
+	for i < len(ref) && ref[i] != '.' && ref[i] != '[' {
+		i++
+	}
+	return ref[1:i], ref[i:]
+}
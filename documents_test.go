@@ -0,0 +1,98 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_WithDocument(t *testing.T) {
+
+	input := []byte(`{"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}]}`)
+	allow := []byte(`{"ids": [1, 3], "names": ["b"]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.items[?(@.id in $allow.ids)].name`, []byte(`["a","c"]`)},
+		{`$.items[?(@.name in $allow.names)].id`, []byte(`[2]`)},
+		{`$.items[?(@.name in $allow.names || @.id == 1)].id`, []byte(`[1,2]`)},
+		{`$.items[?(in(@.id, $allow.ids) && @.id > 1)].name`, []byte(`["c"]`)},
+		{`$.items[?(@.id > $allow.ids.length())].name`, []byte(`["c"]`)},
+		// unknown document
+		{`$.items[?(@.id in $deny.ids)].name`, []byte(`[]`)},
+		// own root
+		{`$.items[?(@.id in $.items[1:].id)].name`, []byte(`["b","c"]`)},
+		// array literal
+		{`$.items[?(@.name in ['a', "b"])].id`, []byte(`[1,2]`)},
+		// parenthesized operand
+		{`$.items[?((@.id + 1) in [3])].name`, []byte(`["b"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithDocument("allow", allow))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	for _, path := range []string{`$.items[?(@.id in [1,)]`, `$.items[?(nosuchfn(@.id))]`} {
+		if _, err := GetWith(input, path); err == nil {
+			t.Errorf(path + " : error expected")
+		}
+	}
+}
+
+func Test_WithVars(t *testing.T) {
+
+	input := []byte(`[{"price": 5, "tag": "new"}, {"price": 15, "tag": "old"}, {"price": 25, "tag": "new"}]`)
+	vars := map[string]interface{}{"min": 10, "tag": "new", "tags": []string{"old"}, "on": true}
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(@.price > $min)].price`, []byte(`[15,25]`)},
+		{`$[?(@.price > $vars.min && @.tag == $tag)].price`, []byte(`[25]`)},
+		{`$[?(@.tag in $tags)].price`, []byte(`[15]`)},
+		{`$[?($on)].price`, []byte(`[5,15,25]`)},
+		{`$[?($undefined)].price`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithVars(vars))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// same path, different thresholds
+	res, _ := GetWith(input, `$[?(@.price > $min)].price`, WithVars(map[string]interface{}{"min": 20}))
+	if string(res) != `[25]` {
+		t.Errorf("unexpected result: %s", res)
+	}
+
+	if _, err := GetWith(input, `$[0]`, WithVars(map[string]interface{}{"ch": make(chan int)})); err == nil {
+		t.Errorf("error expected")
+	}
+
+	// one compiled path, different thresholds
+	p := MustCompile(`$[?(@.price > $min)].price`)
+	for min, expected := range map[int]string{10: `[15,25]`, 20: `[25]`, 30: `[]`} {
+		res, err := p.GetWith(input, WithVars(map[string]interface{}{"min": min}))
+		if err != nil || string(res) != expected {
+			t.Errorf("compiled, min %d: expected %s, got %s (%v)", min, expected, res, err)
+		}
+	}
+	p = MustCompile(`$[?(@.tag == $tag && @.price < $doc.max)].price`)
+	res, err := p.GetWith(input, WithVars(vars), WithDocument("doc", []byte(`{"max": 20}`)))
+	if err != nil || string(res) != `[5]` {
+		t.Errorf("compiled with a document: unexpected result %s (%v)", res, err)
+	}
+	if _, err := p.GetWith(input, WithRFCComparison()); err != ErrCompileOption {
+		t.Errorf("expected ErrCompileOption, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package jsonslice
+
+// DuplicateKeys selects the members of an object with duplicate keys addressed by a single key
+// ($.a, $['a']), see WithDuplicateKeys. Keys matching the same path key (see WithKeyMatch) are duplicates too.
+type DuplicateKeys int
+
+const (
+	// DuplicateFirst selects the first member having a value for the rest of the path (the default)
+	DuplicateFirst DuplicateKeys = iota
+	// DuplicateLast selects the last member, as most json parsers do: {"a":1,"a":2} is {"a":2}
+	DuplicateLast
+	// DuplicateAll selects every member: the path aggregates, $.a of {"a":1,"a":2} is [1,2], $.a of {"a":1} is [1]
+	DuplicateAll
+)
+
+// WithDuplicateKeys sets the policy of duplicate keys: which of the members with the same key a single key selects.
+// The policy applies to the offsets of the result (WithOffsets) and key/value pairs (WithKeyValues) as well.
+// References in filters (@.a) are single values: with DuplicateAll they select the first member.
+func WithDuplicateKeys(d DuplicateKeys) Option {
+	return func(ctx *tContext) {
+		ctx.duplicates = d
+	}
+}
+
+// duplicateKeys returns the policy of duplicate keys of the context
+func (ctx *tContext) duplicateKeys() DuplicateKeys {
+	if ctx == nil {
+		return DuplicateFirst
+	}
+	return ctx.duplicates
+}
+
+// objectValueByDuplicateKey returns the value of the last member (DuplicateLast) or the values of all the members
+// (DuplicateAll) matching the single key of nod
+func objectValueByDuplicateKey(input []byte, nod *tNode, inside bool, dup DuplicateKeys) ([]byte, error) {
+	var (
+		err  error
+		key  []byte
+		s, e int
+		res  []byte
+	)
+	ls, le := -1, -1 // the last matching member
+	all := dup == DuplicateAll
+	i := 1 // skip '{'
+	l := len(input)
+	for i < l && input[i] != '}' {
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if !nod.ctx.keysEqual(key, nod.Keys[0], false) {
+			nod.ctx.skipped(nod, input[s:e])
+			continue
+		}
+		if !all {
+			ls, le = s, e
+			continue
+		}
+		sub, err := getValue(input[s:e:e], nod.Next, true)
+		if err != nil {
+			return nil, err
+		}
+		res = plus(res, sub)
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	if all {
+		if !inside {
+			res = append(append([]byte{'['}, res...), ']')
+		}
+		return res, nil
+	}
+	if ls < 0 {
+		return nil, nil
+	}
+	return getValue(input[ls:le:le], nod.Next, inside)
+}
+
+// lastMember returns the start of the value of the last member of the object at input[i] matching
+// the single key of nod, -1 if there is none (see DuplicateLast)
+func (w *tWalker) lastMember(input []byte, i int, nod *tNode) (int, error) {
+	var (
+		err  error
+		key  []byte
+		s    int
+		last = -1
+	)
+	l := len(input)
+	i++ // skip '{'
+	for i < l && input[i] != '}' {
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return -1, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, _, i, err = valuate(input, i)
+		if err != nil {
+			return -1, err
+		}
+		if w.keyIn(key, nod) {
+			last = s
+		}
+	}
+	return last, nil
+}
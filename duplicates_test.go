@@ -0,0 +1,70 @@
+package jsonslice
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_DuplicateKeys(t *testing.T) {
+
+	doc := []byte(`{"a":{"b":1},"x":0,"a":{"c":2},"l":[{"id":1,"id":2},{"id":3}]}`)
+	tests := []struct {
+		Query    string
+		Policy   DuplicateKeys
+		Expected []byte
+	}{
+		// first member having a value (the default)
+		{`$.a`, DuplicateFirst, []byte(`{"b":1}`)},
+		{`$.a.c`, DuplicateFirst, []byte(`2`)},
+		{`$.l[*].id`, DuplicateFirst, []byte(`[1,3]`)},
+		// last member
+		{`$.a`, DuplicateLast, []byte(`{"c":2}`)},
+		{`$['a']`, DuplicateLast, []byte(`{"c":2}`)},
+		{`$.a.b`, DuplicateLast, []byte(``)},
+		{`$.l[*].id`, DuplicateLast, []byte(`[2,3]`)},
+		{`$.l[?(@.id == 2)]`, DuplicateLast, []byte(`[{"id":1,"id":2}]`)},
+		// all the members
+		{`$.a`, DuplicateAll, []byte(`[{"b":1},{"c":2}]`)},
+		{`$.a.b`, DuplicateAll, []byte(`[1]`)},
+		{`$.x`, DuplicateAll, []byte(`[0]`)},
+		{`$.l[0].id`, DuplicateAll, []byte(`[1,2]`)},
+		{`$.l[*].id`, DuplicateAll, []byte(`[1,2,3]`)},
+		{`$.nope`, DuplicateAll, []byte(`[]`)},
+		{`$.l[?(@.id == 1)].id`, DuplicateAll, []byte(`[1,2]`)},
+		// wildcards select every member anyway
+		{`$.*.c`, DuplicateLast, []byte(`[2]`)},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		res, err := GetWith(doc, tst.Query, WithDuplicateKeys(tst.Policy), WithOffsets(&offsets))
+		name := fmt.Sprintf("%s (%d)", tst.Query, tst.Policy)
+		if err != nil {
+			t.Errorf(name + " : " + err.Error())
+			continue
+		}
+		if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(name + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+		// offsets select the same values (none of the values is an array)
+		var values []byte
+		for _, o := range offsets {
+			values = plus(values, doc[o[0]:o[1]])
+		}
+		if len(res) > 0 && res[0] == '[' {
+			values = append(append([]byte{'['}, values...), ']')
+		}
+		if string(values) != string(res) {
+			t.Errorf(name + ": offsets select `" + string(values) + "`")
+		}
+	}
+
+	res, err := GetWith(doc, `$.a`, WithDuplicateKeys(DuplicateAll), WithKeyValues())
+	if err != nil || string(res) != `{"a":{"b":1},"a":{"c":2}}` {
+		t.Errorf("WithKeyValues: unexpected %s (%v)", res, err)
+	}
+	res, err = GetWith([]byte(`{"A":1,"a":2}`), `$.a`, WithDuplicateKeys(DuplicateLast), WithCaseInsensitiveKeys())
+	if err != nil || string(res) != `2` {
+		t.Errorf("WithCaseInsensitiveKeys: unexpected %s (%v)", res, err)
+	}
+}
@@ -0,0 +1,112 @@
+package jsonslice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidPath is matched (errors.Is) by every *PathError
+	ErrInvalidPath = errors.New("invalid path")
+	// ErrInvalidJSON is matched (errors.Is) by every *JSONError
+	ErrInvalidJSON = errors.New("invalid json")
+	// ErrPolicyViolation is returned when a path does not comply with the policy (see WithPolicy)
+	ErrPolicyViolation = errors.New("policy violation")
+	// ErrExtensionDisabled is returned when a path uses a disabled extension (see WithoutExtensions)
+	ErrExtensionDisabled = errors.New("extension disabled")
+	// ErrCompileOption is returned by Path.GetWith for an option affecting parsing (see CompileWith)
+	ErrCompileOption = errors.New("option is only accepted by CompileWith")
+)
+
+// PathError is a jsonpath syntax error:
+//
+//	var pe *jsonslice.PathError
+//	if errors.As(err, &pe) {
+//		highlight(path, pe.Pos)
+//	}
+type PathError struct {
+	Pos    int    // byte offset of the offending character in the path
+	Token  string // the offending character, empty at the end of the path
+	Reason string // what is wrong: "invalid character", "unexpected end of path", ...
+	err    error
+}
+
+func (e *PathError) Error() string {
+	return e.err.Error() + " at " + strconv.Itoa(e.Pos)
+}
+
+// Unwrap returns the underlying error
+func (e *PathError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrInvalidPath
+func (e *PathError) Is(target error) bool {
+	return target == ErrInvalidPath
+}
+
+// JSONError is a syntax error in the input. Offset points to the first malformed byte of the input
+// (the length of the input if it ends prematurely), Line and Col are 1-based, Col counts bytes.
+type JSONError struct {
+	Offset int
+	Line   int
+	Col    int
+	err    error
+}
+
+func (e *JSONError) Error() string {
+	return e.err.Error() + " at line " + strconv.Itoa(e.Line) + ", column " + strconv.Itoa(e.Col)
+}
+
+// Unwrap returns the underlying error
+func (e *JSONError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrInvalidJSON
+func (e *JSONError) Is(target error) bool {
+	return target == ErrInvalidJSON
+}
+
+// pathError makes a *PathError from an error at pos of the path with spaces removed (see unspace)
+func pathError(path string, pos int, err error) error {
+	index := make([]int, len(path)+1)
+	n := len(unspaceIndex([]byte(path), index))
+	if pos > n {
+		pos = n
+	}
+	if pos == n {
+		pos = len(path)
+	} else {
+		pos = index[pos]
+	}
+	token := ""
+	if pos < len(path) {
+		_, size := utf8.DecodeRuneInString(path[pos:])
+		token = path[pos : pos+size]
+	}
+	return &PathError{Pos: pos, Token: token, Reason: strings.TrimPrefix(err.Error(), "path: "), err: err}
+}
+
+// inputError makes a *JSONError from a syntax error found in input. Other errors are returned as is.
+func inputError(input []byte, err error) error {
+	if err != errUnexpectedEnd && err != errUnexpectedStringEnd && err != errColonExpected && err != errUnrecognizedValue {
+		return err
+	}
+	var buf bytes.Buffer
+	var syntax *json.SyntaxError
+	if !errors.As(json.Compact(&buf, input), &syntax) {
+		return err // not a syntax error after all
+	}
+	offset := int(syntax.Offset) - 1 // the error occurred after reading Offset bytes
+	if offset < 0 || offset >= len(input)-1 && strings.HasPrefix(syntax.Error(), "unexpected end") {
+		offset = len(input)
+	}
+	line := 1 + bytes.Count(input[:offset], []byte{'\n'})
+	col := offset - bytes.LastIndexByte(input[:offset], '\n')
+	return &JSONError{Offset: offset, Line: line, Col: col, err: err}
+}
@@ -0,0 +1,81 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_PathError(t *testing.T) {
+
+	tests := []struct {
+		Query  string
+		Pos    int
+		Token  string
+		Reason string
+	}{
+		{`$.store(foo`, 7, `(`, `invalid character`},
+		{`$.`, 2, ``, `unexpected end of path`},
+		{`$.foo()`, 5, `(`, `unknown function`},
+		{`$.store.book[1`, 14, ``, `unexpected end of path`},
+		{`$.store.book[ 1 `, 16, ``, `unexpected end of path`}, // position in the source path
+		{`$['single'quote']`, 10, `q`, `invalid character`},
+	}
+
+	for _, tst := range tests {
+		_, err := Get(data, tst.Query)
+		var pe *PathError
+		if !errors.As(err, &pe) {
+			t.Errorf(tst.Query+" : *PathError expected, got %v", err)
+			continue
+		}
+		if pe.Pos != tst.Pos || pe.Token != tst.Token || pe.Reason != tst.Reason {
+			t.Errorf(tst.Query+"\n\texpected {%d %q %q}\n\tbut got  {%d %q %q}", tst.Pos, tst.Token, tst.Reason, pe.Pos, pe.Token, pe.Reason)
+		}
+		if !errors.Is(err, ErrInvalidPath) || errors.Is(err, ErrInvalidJSON) {
+			t.Errorf(tst.Query + " : errors.Is mismatch")
+		}
+	}
+}
+
+func Test_JSONError(t *testing.T) {
+
+	tests := []struct {
+		Data   string
+		Query  string
+		Offset int
+		Line   int
+		Col    int
+	}{
+		{`{"foo" : `, `$.foo`, 9, 1, 10},
+		{`{"foo": Troo}`, `$.foo`, 8, 1, 9},
+		{"{\n  \"foo\" - 1\n}", `$.foo`, 10, 2, 9},
+		{"[1,\n2,\n{\"a\": moo}]", `$[2].a`, 13, 3, 7},
+	}
+
+	for _, tst := range tests {
+		for _, fn := range []func() error{
+			func() error { _, err := Get([]byte(tst.Data), tst.Query); return err },
+			func() error { _, err := Count([]byte(tst.Data), tst.Query); return err },
+			func() error { _, err := ParseStructure([]byte(tst.Data)).Get(tst.Query); return err },
+		} {
+			err := fn()
+			var je *JSONError
+			if !errors.As(err, &je) {
+				t.Errorf(tst.Query+" : *JSONError expected, got %v", err)
+				continue
+			}
+			if je.Offset != tst.Offset || je.Line != tst.Line || je.Col != tst.Col {
+				t.Errorf(tst.Query+"\n\texpected {%d %d %d}\n\tbut got  {%d %d %d}", tst.Offset, tst.Line, tst.Col, je.Offset, je.Line, je.Col)
+			}
+			if !errors.Is(err, ErrInvalidJSON) {
+				t.Errorf(tst.Query + " : errors.Is mismatch")
+			}
+		}
+	}
+
+	// not a syntax error
+	_, err := Get([]byte(`{"a": 1}`), `$.a.length()`)
+	if errors.As(err, new(*JSONError)) {
+		t.Errorf("*JSONError not expected, got %v", err)
+	}
+}
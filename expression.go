@@ -1,34 +1,256 @@
 package jsonslice
 
 import (
+	"bytes"
+	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/bhmj/xpression"
 )
 
-// readFilter reads expression in ?( ... ) filter, parses tokens and writes result to nod.Filter.
+// readFilter reads expression in ?( ... ) filter, parses tokens and writes result to nod.Filter (and nod.Calls).
+// The parentheses may be omitted (as in RFC 9535): ?@.price < 10 && @.isbn.
 // Consumes closing ) and ]
 func readFilter(path []byte, i int, nod *tNode) (int, error) {
+	l := len(path)
+	var e int
+	var err error
+	paren := i < l && path[i] == '('
+	if paren {
+		if e, err = findClosingBracket(path, i+1); err != nil {
+			return i + 1, err
+		}
+		paren = e+1 == l || path[e+1] == ']' // not ?(@.a) && (@.b)
+	}
+	s := i
+	if paren {
+		s++
+	} else if e, err = filterEnd(path, i); err != nil {
+		return i, err
+	}
+	if err = parseFilter(path[s:e], nod); err != nil {
+		return s, err
+	}
+	nod.Type |= cFilter
+	nod.Type &^= cDot
+
+	if paren && e < l {
+		e++ // ')'
+	}
+	if e < l && path[e] == ']' {
+		e++
+	}
+	return e, nil
+}
+
+// filterEnd returns the position of ']' ending a filter without parentheses (not consumed)
+func filterEnd(path []byte, i int) (int, error) {
+	var err error
+	depth := 0
+	for i < len(path) {
+		switch path[i] {
+		case '"', '\'':
+			if i, err = skipString(path, i); err != nil {
+				return i, err
+			}
+			continue
+		case '(', '[':
+			depth++
+		case ')':
+			depth--
+		case ']':
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		}
+		i++
+	}
+	return i, errPathUnexpectedEnd
+}
+
+// readScript reads script expression in ( ... ) selector: $.arr[(@.length-1)], $.obj[(@.field)].
+// Consumes closing ) and ]
+func readScript(path []byte, i int, nod *tNode) (int, error) {
 	e, err := findClosingBracket(path, i)
 	if err != nil {
 		return i, err
 	}
-	tokens, err := xpression.Parse(path[i:e])
-	if err != nil {
+	if err = parseFilter(path[i:e], nod); err != nil {
 		return i, err
 	}
-	nod.Filter = tokens
-	nod.Type |= cFilter
+	nod.Type |= cScript
 	nod.Type &^= cDot
 
-	l := len(path)
-	if e < l {
-		e++ // ')'
-		if e < l && path[e] == ']' {
-			e++
+	e++ // ')'
+	if e == len(path) || path[e] != ']' {
+		return e, errPathInvalidChar
+	}
+	return e + 1, nil
+}
+
+// scriptNode evaluates script expression on the current value (input) and returns a temporary node
+// selecting an index (number result) or a key (string result), nil if the result is neither.
+// The node must be released with releaseScriptNode.
+func scriptNode(input []byte, nod *tNode) (tmp *tNode, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tmp, err = nil, fmt.Errorf("%w: %v", errFilterEvaluation, r)
+		}
+	}()
+	vars := filterVarFunc(input, nod)
+	op, err := xpression.Evaluate(nod.Filter, func(str []byte, result *xpression.Operand) error {
+		if string(str) == "@.length" && len(input) > 0 && input[0] == '[' {
+			// Goessner's @.length of an array
+			elems, err := arrayElems(input, 0)
+			result.SetNumber(float64(len(elems)))
+			return err
 		}
+		return vars(str, result)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return e, nil
+	tmp = getEmptyNode()
+	tmp.Type = cDot
+	tmp.Next = nod.Next
+	tmp.ctx = nod.ctx
+	switch op.Type {
+	case xpression.NumberOperand:
+		tmp.Slice[0] = int(op.Number)
+		if tmp.Slice[0] < 0 {
+			tmp.Type |= cFullScan
+		}
+	case xpression.StringOperand:
+		tmp.Keys = append(tmp.Keys, op.Str)
+	default:
+		releaseScriptNode(tmp)
+		return nil, nil
+	}
+	return tmp, nil
+}
+
+// releaseScriptNode returns a temporary node to the pool keeping the rest of the node list
+func releaseScriptNode(tmp *tNode) {
+	tmp.Next = nil
+	repool(tmp)
+}
+
+// getValueScript selects a value by the index or the key computed by script expression
+func getValueScript(input []byte, nod *tNode, inside bool) ([]byte, error) {
+	tmp, err := scriptNode(input, nod)
+	if tmp == nil || err != nil {
+		return nil, err
+	}
+	defer releaseScriptNode(tmp)
+	return getValueDot(input, tmp, inside)
+}
+
+// exprOperators are the characters starting operators of a path expression
+var exprOperators = []byte{'+', '-', '*', '/', '%', '<', '>', '=', '!', '&', '|'}
+
+// isPathExpression returns true if the path is an expression rather than a path: it starts with '('
+// or an operator follows a space or a function call at the top level: $.a.sum() * 2, $.a + $.b
+func isPathExpression(path []byte) bool {
+	if len(path) > 0 && path[0] == '(' {
+		return true
+	}
+	depth := 0
+	bound := byte(0)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case bound != 0:
+			if c == '\\' {
+				i++
+			} else if c == bound {
+				bound = 0
+			}
+		case c == '\'' || c == '"':
+			bound = c
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case c == ']' || c == ')' || c == '}':
+			depth--
+			if depth == 0 && c == ')' && i+1 < len(path) && bytein(path[i+1], exprOperators) {
+				return true
+			}
+		case depth == 0 && (c == ' ' || c == '\t'):
+			j := i
+			for j < len(path) && (path[j] == ' ' || path[j] == '\t') {
+				j++
+			}
+			if j < len(path) && bytein(path[j], exprOperators) {
+				return true
+			}
+			if op, _ := wordOperator(path, i, len(path)); op != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parsePathExpression parses a path expression into a function node evaluating it on the document.
+// References ($.a, @.a) and functions are the same as in filters.
+func parsePathExpression(path string) (*tNode, error) {
+	expr := []byte(path)
+	r := &tRewriter{expr: expr}
+	out, err := r.rewrite(0, len(expr))
+	if err != nil {
+		return nil, pathError(path, 0, err)
+	}
+	toks, err := parseExpression(out)
+	if err != nil {
+		return nil, pathError(path, 0, err)
+	}
+	nod := getEmptyNode()
+	nod.Type = cFunction
+	nod.Calls = append(r.calls, &tCall{fn: fnExpression, args: []*tArg{tokenArg(toks)}})
+	nod.Src = expr
+	nod.Ext = ExtExpression
+	return nod, nil
+}
+
+// fnExpression returns the value of a path expression
+func fnExpression(ctx *tContext, args [][]byte) ([]byte, error) {
+	return finiteValue(args[0]), nil
+}
+
+// finiteValue returns the value of an expression, undefined if it is not a number ($.missing + 1 is NaN)
+func finiteValue(val []byte) []byte {
+	if x, err := strconv.ParseFloat(string(val), 64); err == nil && (math.IsNaN(x) || math.IsInf(x, 0)) {
+		return nil
+	}
+	return val
+}
+
+// parseExpression parses filter expression into tokens
+func parseExpression(expr []byte) (tokens []*xpression.Token, err error) {
+	defer func() {
+		// the parser may panic on some malformed expressions (i.e. unclosed bracket in a variable)
+		if r := recover(); r != nil {
+			tokens, err = nil, fmt.Errorf("%w: %v", errPathInvalidExpression, r)
+		}
+	}()
+	tokens, err = xpression.Parse(expr)
+	for _, tok := range tokens {
+		if tok.Category != 0 && tok.Type == xpression.StringOperand { // string literal
+			tok.Str = canonicalString(tok.Str)
+		}
+	}
+	return tokens, err
+}
+
+// canonicalString re-escapes the contents of a string literal or a json string the same way
+// (see jsonQuote), so that 'it\'s' equals "it's", 'a"b' equals "a\"b" and "\u00e9" equals "é" in comparisons
+func canonicalString(str []byte) []byte {
+	if bytes.IndexAny(str, `\"`) < 0 {
+		return str
+	}
+	res := jsonQuote(nil, unescape(str))
+	return res[1 : len(res)-1]
 }
 
 // findClosingBracket returns the position of a closing round bracket (not consumed)
@@ -60,22 +282,57 @@ func findClosingBracket(path []byte, i int) (int, error) {
 	return i, nil
 }
 
-// filterMatch evaluates previously parsed expression and returns boolean to filter out array elements
-func filterMatch(input []byte, toks []*xpression.Token) (bool, error) {
-	varFunc := func(str []byte, result *xpression.Operand) error {
+// filterMatch evaluates previously parsed expression and returns boolean to filter out array elements.
+// index is the index of the element (or the position of the object member), -1 if unknown (see index())
+func filterMatch(input []byte, nod *tNode, index int) (res bool, err error) {
+	defer func() {
+		// the evaluator may panic on some arithmetic (i.e. integer remainder of division by zero)
+		if r := recover(); r != nil {
+			res, err = false, fmt.Errorf("%w: %v", errFilterEvaluation, r)
+		}
+	}()
+	if err = nod.ctx.cancelled(); err != nil {
+		return false, err
+	}
+	nod.pos = index
+	op, err := xpression.Evaluate(nod.Filter, filterVarFunc(input, nod))
+	if err != nil {
+		return false, err
+	}
+	return xpression.ToBoolean(op), nil
+}
+
+// filterVarFunc returns a function evaluating filter variables on the current element (input)
+func filterVarFunc(input []byte, nod *tNode) xpression.VariableFunc {
+	return func(str []byte, result *xpression.Operand) error {
 		if str[0] == '$' {
 			// root-based reference has already been evaluated at start
 			return nil
 		}
+		if n, ok := placeholderIndex(str); ok && n < len(nod.Calls) {
+			val, err := evalCall(input, nod, nod.Calls[n])
+			if err != nil || len(val) == 0 {
+				result.SetUndefined()
+				return err
+			}
+			if err = decodeValue(val, result); err != nil {
+				result.SetUndefined()
+			}
+			return err
+		}
 		if str[0] != '@' {
 			// we only handle item-based references
 			result.SetUndefined()
 			return nil
 		}
-		str[0] = '$'
-		defer func() { str[0] = '@' }()
-		val, err := Get(input, string(str))
-		if val == nil || err != nil {
+		var val []byte
+		var err error
+		if path, ok := nod.refs[string(str)]; ok {
+			val, err = getRef(input, path, nod.refContext(str))
+		} else {
+			val, err = get(input, "$"+string(str[1:]), nod.refContext(str))
+		}
+		if val == nil || err != nil || emptyNodeList(str, val) {
 			// not found or other error
 			result.SetUndefined()
 			return err
@@ -86,12 +343,6 @@ func filterMatch(input []byte, toks []*xpression.Token) (bool, error) {
 		}
 		return err
 	}
-
-	op, err := xpression.Evaluate(toks, varFunc)
-	if err != nil {
-		return false, err
-	}
-	return xpression.ToBoolean(op), nil
 }
 
 // decodeValue determine data type of `input` and write parsed value to `op`
@@ -108,10 +359,10 @@ func decodeValue(input []byte, op *xpression.Operand) error {
 		// string
 		op.Type = xpression.StringOperand
 		if input[i] == '"' || input[i] == '\'' { // exclude quotes
-			i++
-			e--
+			op.Str = canonicalString(input[i+1 : e-1])
+		} else {
+			op.Str = input[i:e]
 		}
-		op.Str = input[i:e]
 	} else if (input[i] >= '0' && input[i] <= '9') || input[i] == '-' || input[i] == '.' {
 		// number
 		f, err := strconv.ParseFloat(string(input[i:e]), 64)
@@ -0,0 +1,49 @@
+package jsonslice
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// EditFile reads a json file, applies edit to its contents and atomically replaces the file with the result.
+// The result is written to a temporary file in the same directory which is then renamed over the original,
+// so the file is never left partially written. File permissions are preserved.
+// If edit returns an error the file is left untouched.
+func EditFile(path string, edit func(doc []byte) ([]byte, error)) (err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err = edit(doc)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(doc); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,51 @@
+package jsonslice
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_EditFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(name, []byte(`{"a": 1, "b": 2}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	err := EditFile(name, func(doc []byte) ([]byte, error) {
+		return CopyValue(doc, `$.a`, `$.b`)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ := os.ReadFile(name)
+	if expected := `{"a": 1, "b": 1}`; string(res) != expected {
+		t.Errorf("expected `%s` but got `%s`", expected, res)
+	}
+	info, _ := os.Stat(name)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("permissions not preserved: %v", info.Mode().Perm())
+	}
+
+	// failed edit leaves the file untouched
+	errEdit := errors.New("edit failed")
+	err = EditFile(name, func(doc []byte) ([]byte, error) { return nil, errEdit })
+	if err != errEdit {
+		t.Errorf("expected edit error, got %v", err)
+	}
+	res, _ = os.ReadFile(name)
+	if expected := `{"a": 1, "b": 1}`; string(res) != expected {
+		t.Errorf("expected `%s` but got `%s`", expected, res)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left: %d entries", len(entries))
+	}
+
+	// missing file
+	if err = EditFile(filepath.Join(dir, "missing.json"), nil); err == nil {
+		t.Errorf("missing file: error expected")
+	}
+}
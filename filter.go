@@ -0,0 +1,946 @@
+package jsonslice
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"hash"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bhmj/xpression"
+)
+
+// Filter expressions are evaluated by xpression which knows nothing about function calls, word operators
+// (`in`) and array literals. Before parsing such constructs are replaced with placeholder variables
+// (see callPrefix) which are then evaluated by filterMatch:
+//
+//	@.id in $allow.ids    -->  jsfn__0
+//	@.tag in ["a", "b"]   -->  jsfn__1   (call `in` with arguments @.tag and jsfn__2 (array literal))
+//
+// Word operators take adjacent operands: a path, a literal, a function call or a parenthesized expression.
+
+// callPrefix is a prefix of placeholder variables
+const callPrefix = "jsfn__"
+
+// tFilterFunc is a filter function. Arguments and the result are raw json values, nil means undefined.
+type tFilterFunc func(ctx *tContext, args [][]byte) ([]byte, error)
+
+// filterFunctions are the functions available in filter expressions
+var filterFunctions map[string]tFilterFunc
+
+// optInFunctions are the functions disabled unless enabled with WithFunctions
+var optInFunctions map[string]bool
+
+// methodFunctions take the current value as the first argument when applied terminally
+// with fewer arguments than specified: @.date.before("2022-01-01") is the same as before(@.date, "2022-01-01")
+var methodFunctions map[string]int
+
+// aggregateFunctions are evaluated once per query if all their arguments are root-based references ($...)
+var aggregateFunctions map[string]bool
+
+// tArrayFunc is a function of an array (the first argument of the call) taking expressions evaluated
+// on its elements as the rest of the arguments: sort(@.books, @.price)
+type tArrayFunc func(input []byte, nod *tNode, call *tCall) ([]byte, error)
+
+// arrayFunctions are evaluated by evalCall. Applied terminally they take the current value as the array,
+// the whole result if the path aggregates: $..book[*].sort(@.price)
+var arrayFunctions map[string]tArrayFunc
+
+// wordOperators are the operators spelled as words
+var wordOperators map[string]tFilterFunc
+
+func init() {
+	filterFunctions = map[string]tFilterFunc{
+		"in":     fnIn,
+		"nin":    fnNin,
+		"env":    fnEnv,
+		"uuid":   fnUUID,
+		"random": fnRandom,
+		"sha256": fnHash(func() hash.Hash { return sha256.New() }),
+		"md5":    fnHash(md5.New),
+		"crc32":  fnHash(func() hash.Hash { return crc32.NewIEEE() }),
+
+		"urldecode":  fnURLDecode,
+		"queryparam": fnQueryParam,
+
+		"tonumber": fnToNumber,
+		"tostring": fnToString,
+		"round":    fnRound,
+
+		"trim":     fnTrim(strings.TrimSpace, strings.Trim),
+		"ltrim":    fnTrim(func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) }, strings.TrimLeft),
+		"rtrim":    fnTrim(func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }, strings.TrimRight),
+		"padLeft":  fnPad(true),
+		"upper":    fnLetterCase(strings.ToUpper),
+		"lower":    fnLetterCase(strings.ToLower),
+		"substr":   fnSubstr,
+		"padRight": fnPad(false),
+		"concat":   fnConcat,
+
+		"coalesce": fnCoalesce,
+		"if":       fnIf,
+		"case":     fnCase,
+
+		"contains": fnContains,
+		"subsetof": fnSubsetOf,
+		"exists":   fnExists(true),
+		"missing":  fnExists(false),
+
+		"datetime": fnDatetime,
+		"before":   fnCompareTime(-1),
+		"after":    fnCompareTime(1),
+		"now":      fnNow,
+		"format":   fnFormat,
+
+		"typeof":    fnTypeOf,
+		"isString":  fnIsType("string"),
+		"isNumber":  fnIsType("number"),
+		"isBoolean": fnIsType("boolean"),
+		"isNull":    fnIsType("null"),
+		"isObject":  fnIsType("object"),
+		"isArray":   fnIsType("array"),
+
+		"keys":   fnKeys,
+		"values": fnValues,
+
+		"length": fnLength,
+		"count":  fnCount,
+		"match":  fnRegexp(true),
+		"search": fnRegexp(false),
+		"value":  fnValue,
+
+		"index": fnIndex,
+
+		"limit":  fnPage(true),
+		"offset": fnPage(false),
+
+		"sum": fnAggregate(aggSum),
+		"avg": fnAggregate(aggAvg),
+		"min": fnAggregate(aggMin),
+		"max": fnAggregate(aggMax),
+	}
+	methodFunctions = map[string]int{
+		"before":   2,
+		"after":    2,
+		"format":   3,
+		"trim":     1,
+		"ltrim":    1,
+		"rtrim":    1,
+		"upper":    1,
+		"lower":    1,
+		"substr":   3,
+		"padLeft":  3,
+		"padRight": 3,
+		"limit":    2,
+		"offset":   2,
+
+		"urldecode":  1,
+		"queryparam": 2,
+
+		"tonumber": 1,
+		"tostring": 1,
+		"round":    2,
+	}
+	arrayFunctions = map[string]tArrayFunc{
+		"sort":     sortArray,
+		"sortDesc": sortArray,
+		"groupBy":  groupArray,
+		"join":     joinArray,
+	}
+	aggregateFunctions = map[string]bool{
+		"sum": true,
+		"avg": true,
+		"min": true,
+		"max": true,
+	}
+	optInFunctions = map[string]bool{
+		"env":    true,
+		"uuid":   true,
+		"random": true,
+		"sha256": true,
+		"md5":    true,
+		"crc32":  true,
+	}
+	wordOperators = map[string]tFilterFunc{
+		"in":       fnIn,
+		"nin":      fnNin,
+		"contains": fnContains,
+		"subsetof": fnSubsetOf,
+	}
+}
+
+// tCall is a function call, a word operator or an array literal replaced with a placeholder
+type tCall struct {
+	name   string
+	fn     tFilterFunc
+	custom CustomFunction // user-registered function (see RegisterFunction)
+	args   []*tArg
+	raw    []byte // array literal or long number literal
+
+	once  bool   // value is evaluated once per query (see evalRootRefs)
+	value []byte // the value
+}
+
+// tArg is a function argument
+type tArg struct {
+	ref  []byte             // path reference: @.a, $.a, $doc.a
+	call int                // placeholder: index of the call + 1
+	toks []*xpression.Token // expression
+	root []byte             // value of a root-based reference ($...) evaluated once per query
+	wrap bool               // singular reference wrapped in [] to make a node list (see nodeListFunctions)
+}
+
+// parseFilter parses filter expression into nod.Filter and nod.Calls
+func parseFilter(expr []byte, nod *tNode) error {
+	r := &tRewriter{expr: expr}
+	out, err := r.rewrite(0, len(expr))
+	if err != nil {
+		return err
+	}
+	toks, err := parseExpression(out)
+	if err != nil {
+		return err
+	}
+	nod.Filter = toks
+	nod.Calls = r.calls
+	refPaths(nod)
+	if err := listComparisons(nod); err != nil {
+		return err
+	}
+	return datetimeComparisons(nod)
+}
+
+// refPaths makes the paths of the current value references (@...) of the filter expression and the call
+// arguments, so that they are not built for every value filtered (see getRef)
+func refPaths(nod *tNode) {
+	add := func(toks []*xpression.Token) {
+		for _, tok := range toks {
+			if tok.Category == 0 || tok.Type != xpression.VariableOperand || tok.Str[0] != '@' {
+				continue
+			}
+			path := unspace(append([]byte{'$'}, tok.Str[1:]...))
+			if isPathExpression(path) {
+				continue
+			}
+			if nod.refs == nil {
+				nod.refs = make(map[string][]byte)
+			}
+			nod.refs[string(tok.Str)] = path
+		}
+	}
+	add(nod.Filter)
+	for _, call := range nod.Calls {
+		for _, arg := range call.args {
+			add(arg.toks)
+		}
+	}
+}
+
+// tRewriter replaces calls, word operators and array literals in a filter expression with placeholders
+type tRewriter struct {
+	expr  []byte
+	calls []*tCall
+}
+
+// rewrite rewrites expr[s:e]
+func (r *tRewriter) rewrite(s, e int) ([]byte, error) {
+	expr := r.expr
+	out := make([]byte, 0, e-s)
+	last := -1 // start of the last operand in out
+	var parens []int
+	for i := s; i < e; {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			op, j := wordOperator(expr, i, e)
+			if op == nil || last < 0 {
+				i++
+				continue
+			}
+			right, j, err := r.operand(j, e)
+			if err != nil {
+				return nil, err
+			}
+			left, err := r.arg(out[last:])
+			if err != nil {
+				return nil, err
+			}
+			rarg, err := r.arg(right)
+			if err != nil {
+				return nil, err
+			}
+			out = r.placeholder(out[:last], &tCall{fn: op, args: []*tArg{left, rarg}})
+			i = j
+		case c == '(':
+			parens = append(parens, len(out))
+			out = append(out, c)
+			last = -1
+			i++
+		case c == ')':
+			if last >= 0 {
+				out = append(out, ' ') // a variable would take the bracket: (@.a + @.b)
+			}
+			out = append(out, c)
+			last = -1
+			if len(parens) > 0 {
+				last = parens[len(parens)-1]
+				parens = parens[:len(parens)-1]
+			}
+			i++
+		case c == '/' && last < 0:
+			// regular expression
+			j := skipRegexp(expr, i, e)
+			out = append(out, expr[i:j]...)
+			i = j
+		case isOperandStart(c) || c == '-' && last < 0 && i+1 < e && expr[i+1] >= '0' && expr[i+1] <= '9':
+			operand, j, err := r.operand(i, e)
+			if err != nil {
+				return nil, err
+			}
+			last = len(out)
+			out = append(out, operand...)
+			i = j
+		default:
+			out = append(out, c)
+			last = -1
+			i++
+		}
+	}
+	return out, nil
+}
+
+// operand reads and rewrites a single operand at expr[i]
+func (r *tRewriter) operand(i, e int) ([]byte, int, error) {
+	expr := r.expr
+	for i < e && (expr[i] == ' ' || expr[i] == '\t') {
+		i++
+	}
+	if i == e {
+		return nil, i, errPathInvalidExpression
+	}
+	c := expr[i]
+	switch {
+	case c == '"' || c == '\'':
+		j, err := skipString(expr[:e], i)
+		if err != nil {
+			return nil, i, err
+		}
+		if _, _, err = readQuotedKey(expr[:j], i); err != nil { // check escapes
+			return nil, i, err
+		}
+		return expr[i:j], j, nil
+	case c == '[':
+		j, err := closingBracket(expr, i, e, '[', ']')
+		if err != nil {
+			return nil, i, err
+		}
+		raw, err := arrayLiteral(expr[i:j])
+		if err != nil {
+			return nil, i, err
+		}
+		return r.placeholder(nil, &tCall{raw: raw}), j, nil
+	case c == '(':
+		j, err := closingBracket(expr, i, e, '(', ')')
+		if err != nil {
+			return nil, i, err
+		}
+		inner, err := r.rewrite(i+1, j-1)
+		if err != nil {
+			return nil, i, err
+		}
+		return append(append([]byte{'('}, inner...), ' ', ')'), j, nil
+	case c == '@' || c == '$':
+		j, err := skipReference(expr, i, e)
+		if err != nil {
+			return nil, i, err
+		}
+		if ref, ok := countedRef(bracketWildcards(expr[i:j])); ok {
+			// @.books[?(@.price > 20)].count() is the number of the values selected
+			return r.placeholder(nil, &tCall{name: "count", fn: fnCount, args: []*tArg{{ref: ref}}}), j, nil
+		}
+		return bracketWildcards(expr[i:j]), j, nil
+	case isLetter(c):
+		j := i
+		for j < e && (isLetter(expr[j]) || (expr[j] >= '0' && expr[j] <= '9') || expr[j] == '_') {
+			j++
+		}
+		if j == e || expr[j] != '(' {
+			return expr[i:j], j, nil // true, false, null, etc
+		}
+		if !isFunction(string(expr[i:j])) {
+			return nil, i, errPathUnknownFunction
+		}
+		fn := filterFunctions[string(expr[i:j])] // nil for array functions
+		k, err := closingBracket(expr, j, e, '(', ')')
+		if err != nil {
+			return nil, i, err
+		}
+		call := &tCall{name: string(expr[i:j]), fn: fn, custom: customFunctions[string(expr[i:j])]}
+		for _, a := range splitArgs(expr, j+1, k-1) {
+			text, err := r.rewrite(a[0], a[1])
+			if err != nil {
+				return nil, i, err
+			}
+			arg, err := r.arg(text)
+			if err != nil {
+				return nil, i, err
+			}
+			if nodeListFunctions[call.name] && len(arg.ref) > 0 {
+				node := parseRef(arg.ref)
+				arg.wrap = !aggregates(node)
+				repool(node)
+			}
+			call.args = append(call.args, arg)
+		}
+		return r.placeholder(nil, call), k, nil
+	default:
+		// number
+		j := i
+		if c == '-' {
+			j++
+		}
+		for j < e && (isLetter(expr[j]) || (expr[j] >= '0' && expr[j] <= '9') || expr[j] == '.' ||
+			((expr[j] == '-' || expr[j] == '+') && (expr[j-1] == 'e' || expr[j-1] == 'E'))) {
+			j++
+		}
+		if j == i || c == '-' && j == i+1 {
+			return nil, i, errPathInvalidExpression
+		}
+		if exactLiteral(expr[i:j]) {
+			// digits beyond float64 precision are kept for WithExactNumbers
+			return r.placeholder(nil, &tCall{raw: append([]byte(nil), expr[i:j]...)}), j, nil
+		}
+		return expr[i:j], j, nil
+	}
+}
+
+// arg makes a function argument from a rewritten operand or expression
+func (r *tRewriter) arg(text []byte) (*tArg, error) {
+	text = append([]byte(nil), bytes.TrimSpace(text)...) // out buffer is going to be reused
+	if n, ok := placeholderIndex(text); ok && len(text) == len(callPrefix)+len(strconv.Itoa(n)) {
+		return &tArg{call: n + 1}, nil
+	}
+	if len(text) > 0 && (text[0] == '@' || text[0] == '$') {
+		if j, err := skipReference(text, 0, len(text)); err == nil && j == len(text) {
+			return &tArg{ref: text}, nil
+		}
+	}
+	toks, err := parseExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	return &tArg{toks: toks}, nil
+}
+
+// placeholder registers the call and appends its placeholder to out
+func (r *tRewriter) placeholder(out []byte, call *tCall) []byte {
+	r.calls = append(r.calls, call)
+	out = append(out, callPrefix...)
+	out = strconv.AppendInt(out, int64(len(r.calls)-1), 10)
+	return append(out, ' ') // space ends the variable for xpression
+}
+
+// placeholderIndex returns the call index of a placeholder variable
+func placeholderIndex(str []byte) (int, bool) {
+	if !bytes.HasPrefix(str, []byte(callPrefix)) {
+		return 0, false
+	}
+	n := 0
+	i := len(callPrefix)
+	for ; i < len(str) && str[i] >= '0' && str[i] <= '9'; i++ {
+		n = n*10 + int(str[i]-'0')
+	}
+	return n, i > len(callPrefix)
+}
+
+// wordOperator detects a word operator surrounded by spaces at expr[i]
+func wordOperator(expr []byte, i, e int) (tFilterFunc, int) {
+	for i < e && (expr[i] == ' ' || expr[i] == '\t') {
+		i++
+	}
+	s := i
+	for i < e && isLetter(expr[i]) {
+		i++
+	}
+	if i == s || i == e || (expr[i] != ' ' && expr[i] != '\t') {
+		return nil, s
+	}
+	return wordOperators[string(expr[s:i])], i
+}
+
+// skipReference skips a path reference (@.a[0].b, $doc.a, @.a.length()) in a filter expression
+func skipReference(expr []byte, i, e int) (int, error) {
+	var err error
+	i++ // @ or $
+	for i < e {
+		switch c := expr[i]; {
+		case c == '[':
+			if i, err = closingBracket(expr, i, e, '[', ']'); err != nil {
+				return i, err
+			}
+		case c == '(':
+			if i, err = closingBracket(expr, i, e, '(', ')'); err != nil {
+				return i, err
+			}
+		case c == '\'' || c == '"':
+			if i, err = skipString(expr[:e], i); err != nil {
+				return i, err
+			}
+		case c == '*' && expr[i-1] == '.':
+			i++ // wildcard: @.*
+		case bytein(c, []byte{' ', '\t', ')', ',', '<', '=', '>', '+', '-', '*', '/', '%', '&', '|', '!', '^', '~'}):
+			return i, nil
+		default:
+			i++
+		}
+	}
+	return i, nil
+}
+
+// bracketWildcards rewrites the wildcards of a reference (@.*, @..*) in brackets (@[*], @..[*])
+// for the expression parser to read them as a part of the variable
+func bracketWildcards(ref []byte) []byte {
+	if !bytes.Contains(ref, []byte(".*")) {
+		return ref
+	}
+	out := make([]byte, 0, len(ref)+2)
+	for i := 0; i < len(ref); {
+		j := i + 1
+		var err error
+		switch c := ref[i]; {
+		case c == '[':
+			j, err = closingBracket(ref, i, len(ref), '[', ']')
+		case c == '(':
+			j, err = closingBracket(ref, i, len(ref), '(', ')')
+		case c == '\'' || c == '"':
+			j, err = skipString(ref, i)
+		case c == '.' && j < len(ref) && ref[j] == '*':
+			if i > 0 && ref[i-1] == '.' {
+				out = append(out, '.')
+			}
+			out = append(out, '[', '*', ']')
+			i += 2
+			continue
+		}
+		if err != nil {
+			return ref
+		}
+		out = append(out, ref[i:j]...)
+		i = j
+	}
+	return out
+}
+
+// countedRef returns the reference preceding a trailing .count() if the reference selects several values
+func countedRef(ref []byte) ([]byte, bool) {
+	suffix := []byte(".count()")
+	if !bytes.HasSuffix(ref, suffix) {
+		return nil, false
+	}
+	ref = append([]byte(nil), ref[:len(ref)-len(suffix)]...)
+	node := parseRef(ref)
+	defer repool(node)
+	return ref, aggregates(node)
+}
+
+// emptyNodeList returns true if a reference selecting several values (@.books[?(@.price > 20)], $..isbn)
+// has selected nothing: such a reference is undefined rather than an empty array
+func emptyNodeList(ref, val []byte) bool {
+	if len(val) != 2 || val[0] != '[' || val[1] != ']' {
+		return false
+	}
+	node := parseRef(ref)
+	defer repool(node)
+	return aggregates(node)
+}
+
+// refContext returns the context of a reference evaluated on the current value. $ in nested filters
+// (@.books[?(@.price > $.max)]) refers to the document.
+func (nod *tNode) refContext(ref []byte) *tContext {
+	ctx := nod.ctx.refContext()
+	if bytes.IndexByte(ref, '?') < 0 || nod.root == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = &tContext{}
+	}
+	ctx.root = nod.root
+	return ctx
+}
+
+// closingBracket returns the position next to the bracket closing expr[i]
+func closingBracket(expr []byte, i, e int, open, close byte) (int, error) {
+	var err error
+	depth := 0
+	for i < e {
+		switch expr[i] {
+		case '"', '\'':
+			if i, err = skipString(expr[:e], i); err != nil {
+				return i, err
+			}
+			continue
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+		i++
+	}
+	return i, errUnexpectedStringEnd
+}
+
+// splitArgs splits expr[s:e] by top level commas
+func splitArgs(expr []byte, s, e int) [][2]int {
+	var args [][2]int
+	if len(bytes.TrimSpace(expr[s:e])) == 0 {
+		return nil
+	}
+	depth := 0
+	start := s
+	for i := s; i < e; i++ {
+		switch expr[i] {
+		case '"', '\'':
+			j, err := skipString(expr[:e], i)
+			if err != nil {
+				i = e
+				continue
+			}
+			i = j - 1
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, [2]int{start, i})
+				start = i + 1
+			}
+		}
+	}
+	return append(args, [2]int{start, e})
+}
+
+// skipRegexp skips /regexp/flags
+func skipRegexp(expr []byte, i, e int) int {
+	for i++; i < e && expr[i] != '/'; i++ {
+		if expr[i] == '\\' {
+			i++
+		}
+	}
+	for i++; i < e && isLetter(expr[i]); i++ {
+	}
+	if i > e {
+		return e
+	}
+	return i
+}
+
+// arrayLiteral converts an array literal (strings may be single-quoted) into json
+func arrayLiteral(lit []byte) ([]byte, error) {
+	res := make([]byte, 0, len(lit))
+	for i := 0; i < len(lit); {
+		switch c := lit[i]; c {
+		case '"', '\'':
+			str, j, err := readQuotedKey(lit, i)
+			if err != nil {
+				return nil, err
+			}
+			res = jsonQuote(res, str)
+			i = j
+		default:
+			res = append(res, c)
+			i++
+		}
+	}
+	if err := checkValue(res); err != nil {
+		return nil, errPathInvalidExpression
+	}
+	return res, nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isOperandStart(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9') || bytein(c, []byte{'@', '$', '"', '\'', '[', '.'})
+}
+
+// isFunction returns true if name is a function available in filter expressions: a filter function
+// or an array function
+func isFunction(name string) bool {
+	return filterFunctions[name] != nil || arrayFunctions[name] != nil
+}
+
+// evalCall evaluates a call replaced with a placeholder
+func evalCall(input []byte, nod *tNode, call *tCall) ([]byte, error) {
+	if call.raw != nil {
+		return call.raw, nil
+	}
+	if call.once {
+		return call.value, nil
+	}
+	if call.name == "index" && len(call.args) == 0 {
+		return nodeIndex(nod), nil
+	}
+	if fn := arrayFunctions[call.name]; fn != nil {
+		return fn(input, nod, call)
+	}
+	if optInFunctions[call.name] && !nod.ctx.enabled(call.name) {
+		return nil, errFunctionDisabled
+	}
+	args := make([][]byte, len(call.args))
+	for i, arg := range call.args {
+		val, err := evalArg(input, nod, arg)
+		if err != nil {
+			return nil, err
+		}
+		if arg.wrap && len(val) > 0 {
+			val = append(append([]byte{'['}, val...), ']')
+		}
+		args[i] = val
+	}
+	if call.custom != nil {
+		return call.custom(currentValue(input), args...)
+	}
+	return call.fn(nod.ctx, args)
+}
+
+// evalArg evaluates a function argument on the current element
+func evalArg(input []byte, nod *tNode, arg *tArg) ([]byte, error) {
+	switch {
+	case arg.call > 0:
+		return evalCall(input, nod, nod.Calls[arg.call-1])
+	case len(arg.ref) > 0 && arg.ref[0] == '$':
+		return arg.root, nil
+	case len(arg.ref) == 1:
+		return currentValue(input), nil
+	case len(arg.ref) > 0:
+		val, err := get(input, "$"+string(arg.ref[1:]), nod.refContext(arg.ref))
+		if err != nil || len(val) == 0 || emptyNodeList(arg.ref, val) {
+			return nil, nil
+		}
+		return val, nil
+	}
+	op, err := xpression.Evaluate(arg.toks, filterVarFunc(input, nod))
+	if err != nil {
+		return nil, err
+	}
+	return operandJSON(op), nil
+}
+
+// methodCall returns true if a function applied terminally takes the current value as the first argument.
+// padLeft, padRight and round called with fewer arguments are ambiguous: padLeft(@.id, 5) is a call with
+// the string given, @.id.padLeft(5, "0") is a method call, its first argument being the width.
+// Likewise round(@.price) rounds the price, @.price.round(2) rounds it to 2 digits.
+func methodCall(call *tCall) bool {
+	if arrayFunctions[call.name] != nil {
+		return true
+	}
+	if len(call.args) >= methodFunctions[call.name] {
+		return false
+	}
+	if call.name == "round" && len(call.args) == 0 {
+		return true
+	}
+	if call.name == "padLeft" || call.name == "padRight" || call.name == "round" {
+		return len(call.args) > 0 && numberLiteral(call.args[0])
+	}
+	return true
+}
+
+// numberLiteral returns true if the argument is a number, possibly negative
+func numberLiteral(arg *tArg) bool {
+	if len(arg.ref) > 0 || arg.call > 0 {
+		return false
+	}
+	toks := arg.toks
+	if len(toks) == 3 && toks[0].Operator == opUnaryMinus {
+		toks = toks[2:] // operator, its result, operand
+	}
+	return len(toks) == 1 && toks[0].Operator == 0 && toks[0].Operand.Type == xpression.NumberOperand
+}
+
+// currentValue returns the current value (@): input may extend past it
+func currentValue(input []byte) []byte {
+	e, err := skipValue(input, 0)
+	if err != nil {
+		return nil
+	}
+	return bytes.TrimSpace(input[:e])
+}
+
+// operandJSON converts an operand into a raw json value
+func operandJSON(op *xpression.Operand) []byte {
+	switch op.Type {
+	case xpression.StringOperand:
+		return jsonQuote(nil, unescape(op.Str))
+	case xpression.NumberOperand:
+		return strconv.AppendFloat(nil, op.Number, 'f', -1, 64)
+	case xpression.BooleanOperand:
+		return strconv.AppendBool(nil, op.Bool)
+	case xpression.NullOperand:
+		return []byte("null")
+	}
+	return nil
+}
+
+// unescape decodes escape sequences of a string operand
+func unescape(str []byte) []byte {
+	if bytes.IndexByte(str, '\\') < 0 {
+		return str
+	}
+	res := make([]byte, 0, len(str))
+	for i := 0; i < len(str); {
+		if str[i] != '\\' {
+			res = append(res, str[i])
+			i++
+			continue
+		}
+		esc, j, err := readEscape(str, i)
+		if err != nil {
+			return str
+		}
+		res = append(res, esc...)
+		i = j
+	}
+	return res
+}
+
+// argString returns the contents of a json string argument
+func argString(val []byte) ([]byte, bool) {
+	var op xpression.Operand
+	i, err := skipSpaces(val, 0)
+	if err != nil || val[i] != '"' || decodeValue(val, &op) != nil {
+		return nil, false
+	}
+	return unescape(op.Str), true
+}
+
+// jsonEqual compares two json values
+func jsonEqual(a, b []byte) bool {
+	var x, y xpression.Operand
+	if decodeValue(a, &x) != nil || decodeValue(b, &y) != nil || x.Type != y.Type {
+		return false
+	}
+	switch x.Type {
+	case xpression.NumberOperand:
+		return x.Number == y.Number
+	case xpression.BooleanOperand:
+		return x.Bool == y.Bool
+	case xpression.NullOperand:
+		return true
+	}
+	return bytes.Equal(unescape(x.Str), unescape(y.Str))
+}
+
+var (
+	jsonTrue  = []byte("true")
+	jsonFalse = []byte("false")
+)
+
+func jsonBool(b bool) []byte {
+	if b {
+		return jsonTrue
+	}
+	return jsonFalse
+}
+
+// fnIn returns true if the first argument equals one of the elements of the second (array) argument
+func fnIn(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errPathInvalidExpression
+	}
+	if args[0] == nil || len(args[1]) == 0 {
+		return jsonFalse, nil
+	}
+	i, err := skipSpaces(args[1], 0)
+	if err != nil || args[1][i] != '[' {
+		return jsonFalse, nil
+	}
+	elems, err := arrayElems(args[1], i)
+	if err != nil {
+		return nil, err
+	}
+	for _, el := range elems {
+		if jsonEqual(args[0], args[1][el.start:el.end]) {
+			return jsonTrue, nil
+		}
+	}
+	return jsonFalse, nil
+}
+
+// fnNin is a negation of fnIn
+func fnNin(ctx *tContext, args [][]byte) ([]byte, error) {
+	res, err := fnIn(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return jsonBool(bytes.Equal(res, jsonFalse)), nil
+}
+
+// argArray returns the elements of a json array argument
+func argArray(val []byte) ([][]byte, bool) {
+	i, err := skipSpaces(val, 0)
+	if err != nil || val[i] != '[' {
+		return nil, false
+	}
+	elems, err := arrayElems(val, i)
+	if err != nil {
+		return nil, false
+	}
+	res := make([][]byte, len(elems))
+	for k, el := range elems {
+		res[k] = val[el.start:el.end]
+	}
+	return res, true
+}
+
+// fnContains returns true if the first argument (a string) contains the second one (a substring)
+// or the first argument (an array) has an element equal to the second one
+func fnContains(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errPathInvalidExpression
+	}
+	if args[0] == nil || args[1] == nil {
+		return jsonFalse, nil
+	}
+	if str, ok := argString(args[0]); ok {
+		sub, ok := argString(args[1])
+		return jsonBool(ok && bytes.Contains(str, sub)), nil
+	}
+	return fnIn(ctx, [][]byte{args[1], args[0]})
+}
+
+// fnSubsetOf returns true if every element of the first array argument equals one of the elements of the second one
+func fnSubsetOf(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errPathInvalidExpression
+	}
+	sub, ok := argArray(args[0])
+	if !ok {
+		return jsonFalse, nil
+	}
+	set, ok := argArray(args[1])
+	if !ok {
+		return jsonFalse, nil
+	}
+	for _, a := range sub {
+		found := false
+		for _, b := range set {
+			if found = jsonEqual(a, b); found {
+				break
+			}
+		}
+		if !found {
+			return jsonFalse, nil
+		}
+	}
+	return jsonTrue, nil
+}
@@ -0,0 +1,147 @@
+package jsonslice
+
+// Compact returns the json value with insignificant whitespace removed.
+// The value is reformatted by the scanner in a single pass, strings and numbers are copied as is.
+func Compact(input []byte) ([]byte, error) {
+	return reformat(make([]byte, 0, len(input)), input, false, "", "")
+}
+
+// Indent returns the json value with every array element and object member on a new line
+// beginning with prefix followed by copies of indent according to the nesting, as json.Indent does:
+// the first line is not prefixed, empty arrays and objects are kept on a single line.
+func Indent(input []byte, prefix, indent string) ([]byte, error) {
+	return reformat(make([]byte, 0, len(input)*2), input, true, prefix, indent)
+}
+
+// WithCompact removes insignificant whitespace from the result (see Compact)
+func WithCompact() Option {
+	return func(ctx *tContext) {
+		f := ctx.output()
+		f.whitespace, f.pretty, f.prefix, f.indent = true, false, "", ""
+	}
+}
+
+// WithIndent pretty-prints the result (see Indent)
+func WithIndent(prefix, indent string) Option {
+	return func(ctx *tContext) {
+		f := ctx.output()
+		f.whitespace, f.pretty, f.prefix, f.indent = true, true, prefix, indent
+	}
+}
+
+// tFormat is the output format of the result, see WithCompact, WithIndent and WithNormalizedNumbers
+type tFormat struct {
+	whitespace bool // whitespace is reformatted
+	pretty     bool
+	prefix     string
+	indent     string
+	numbers    bool // numbers are normalized
+}
+
+// output returns the output format of the context
+func (ctx *tContext) output() *tFormat {
+	if ctx.format == nil {
+		ctx.format = &tFormat{}
+	}
+	return ctx.format
+}
+
+// apply reformats the result
+func (f *tFormat) apply(result []byte) ([]byte, error) {
+	if len(result) == 0 {
+		return result, nil
+	}
+	if f.numbers {
+		result = normalizeNumbers(make([]byte, 0, len(result)), result)
+	}
+	if !f.whitespace {
+		return result, nil
+	}
+	return reformat(make([]byte, 0, len(result)), result, f.pretty, f.prefix, f.indent)
+}
+
+// reformat appends the json value reformatted (compacted or indented) to buf
+func reformat(buf, input []byte, pretty bool, prefix, indent string) ([]byte, error) {
+	newline := func(buf []byte, depth int) []byte {
+		if !pretty {
+			return buf
+		}
+		buf = append(append(buf, '\n'), prefix...)
+		for ; depth > 0; depth-- {
+			buf = append(buf, indent...)
+		}
+		return buf
+	}
+	var closers []byte // expected closing brackets
+	value := false     // a value has just been read
+	start := len(buf)
+	l := len(input)
+	for i := 0; i < l; {
+		ch := input[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+			continue
+		case ch == ',' || ch == ':':
+			if !value || len(closers) == 0 || ch == ':' && closers[len(closers)-1] != '}' {
+				return nil, errInvalidValue
+			}
+			buf = append(buf, ch)
+			if ch == ',' {
+				buf = newline(buf, len(closers))
+			} else if pretty {
+				buf = append(buf, ' ')
+			}
+			value = false
+			i++
+			continue
+		case ch == '}' || ch == ']':
+			if !value || len(closers) == 0 || closers[len(closers)-1] != ch {
+				return nil, errInvalidValue
+			}
+			closers = closers[:len(closers)-1]
+			buf = append(newline(buf, len(closers)), ch)
+			i++
+			continue
+		}
+		// a value
+		if value || len(closers) == 0 && len(buf) > start {
+			return nil, errInvalidValue
+		}
+		value = true
+		var e int
+		var err error
+		switch {
+		case ch == '{' || ch == '[':
+			e = i + 1
+			for e < l && (input[e] == ' ' || input[e] == '\t' || input[e] == '\r' || input[e] == '\n') {
+				e++
+			}
+			if e < l && input[e] == ch+2 { // empty
+				buf = append(buf, ch, ch+2)
+				i = e + 1
+				continue
+			}
+			closers = append(closers, ch+2)
+			buf = newline(append(buf, ch), len(closers))
+			value = false
+			i++
+			continue
+		case ch == '"':
+			e, err = skipString(input, i)
+		case (ch >= '0' && ch <= '9') || ch == '-' || ch == '.':
+			e = skipNumber(input, i)
+		default:
+			e, err = skipBoolNull(input, i)
+		}
+		if err != nil {
+			return nil, inputError(input, err)
+		}
+		buf = append(buf, input[i:e]...)
+		i = e
+	}
+	if len(closers) > 0 || !value {
+		return nil, inputError(input, errUnexpectedEnd)
+	}
+	return buf, nil
+}
@@ -0,0 +1,74 @@
+package jsonslice
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func Test_Format(t *testing.T) {
+
+	inputs := []string{
+		`1`,
+		` "a b" `,
+		`true`,
+		`{}`,
+		`[ ]`,
+		`{ "a" : [ 1 , 2, { } , [] ] , "b":{"c" :null,"d":"x\"y,]:"} }`,
+		"[\n\t-1.5e3,\r\n\t\"\\u0041\", false ]",
+		`[[[]], [{"a": [{}]}]]`,
+		string(data),
+	}
+	for _, input := range inputs {
+		var expected bytes.Buffer
+		_ = json.Compact(&expected, []byte(input))
+		res, err := Compact([]byte(input))
+		if err != nil {
+			t.Errorf(input + " : " + err.Error())
+		} else if !bytes.Equal(res, expected.Bytes()) {
+			t.Errorf(input + "\n\texpected `" + expected.String() + "`\n\tbut got  `" + string(res) + "`")
+		}
+
+		expected.Reset()
+		_ = json.Indent(&expected, []byte(input), "> ", "\t")
+		res, err = Indent([]byte(input), "> ", "\t")
+		if err != nil {
+			t.Errorf(input + " : " + err.Error())
+		} else if !bytes.Equal(bytes.TrimSpace(res), bytes.TrimSpace(expected.Bytes())) {
+			t.Errorf(input + "\n\texpected `" + expected.String() + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// errors
+	for _, input := range []string{``, ` `, `[1, 2`, `{"a": 1`, `"abc`, `[1 2]`, `1 2`, `{"a" 1}`, `[1:2]`, `[1,]`, `[}`, `{"a": 1]`, `nul`, `[,1]`} {
+		if _, err := Compact([]byte(input)); err == nil {
+			t.Errorf(input + " : error expected")
+		}
+	}
+
+	// options
+	tests := []struct {
+		Query    string
+		Opt      Option
+		Expected string
+	}{
+		{`$.store.bicycle.equipment[1:]`, WithCompact(), `[["peg leg","parrot","map"],["light saber","apparel"],["\"quoted\""]]`},
+		{`$.store.book[0:2].author`, WithCompact(), `["Nigel Rees","Evelyn Waugh"]`},
+		{`$.store.book[0]['author','price']`, WithCompact(), `["Nigel Rees",8.95]`},
+		{`$.store.bicycle['color','price']`, WithIndent("", "  "), "[\n  \"red\",\n  19.95\n]"},
+		{`$.store.book[?(@.price > 20)]{"t": @.title}`, WithIndent("", "  "), "[\n  {\n    \"t\": \"The Lord of the Rings\"\n  }\n]"},
+		{`$.store.book[0:2].price`, WithIndent("", " "), "[\n 8.95,\n 12.99\n]"},
+		{`$.expensive`, WithIndent("", " "), `10`},
+		{`$.nothing`, WithCompact(), ``},
+	}
+	for _, tst := range tests {
+		res, err := GetWith(data, tst.Query, tst.Opt)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if string(res) != tst.Expected {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
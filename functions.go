@@ -0,0 +1,892 @@
+package jsonslice
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"hash"
+	"math"
+	"math/big"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bhmj/xpression"
+)
+
+// CustomFunction is a user-registered function. input is the current value (@), args are the
+// evaluated arguments. Arguments and the result are raw json values, nil means undefined.
+type CustomFunction func(input []byte, args ...[]byte) ([]byte, error)
+
+// customFunctions are the functions registered with RegisterFunction
+var customFunctions = map[string]CustomFunction{}
+
+// pathFunctions are the built-in functions which are not available in filters
+var pathFunctions = map[string]bool{"length": true, "count": true, "size": true, "type": true, "first": true, "last": true}
+
+// RegisterFunction adds a function available in filters (`?(base64(@.data) == "...")`) and terminally
+// (`$.data.base64()`). The function receives the current value (@) as input: an element being filtered
+// or the value the function is applied to.
+// RegisterFunction is meant to be called on initialization: it is not safe for concurrent use with evaluation.
+// It panics if the name is not an identifier, fn is nil or the name is already taken.
+func RegisterFunction(name string, fn func(input []byte, args ...[]byte) ([]byte, error)) {
+	if fn == nil {
+		panic("jsonslice: RegisterFunction " + name + ": nil function")
+	}
+	for i := 0; i < len(name); i++ {
+		if !isLetter(name[i]) && name[i] != '_' && (i == 0 || name[i] < '0' || name[i] > '9') {
+			panic("jsonslice: RegisterFunction " + name + ": invalid name")
+		}
+	}
+	if isFunction(name) || name == "" || pathFunctions[name] {
+		panic("jsonslice: RegisterFunction " + name + ": already registered")
+	}
+	customFunctions[name] = fn
+	filterFunctions[name] = func(ctx *tContext, args [][]byte) ([]byte, error) {
+		return nil, nil // evaluated by evalCall
+	}
+}
+
+// WithFunctions enables opt-in filter functions, which are disabled by default
+// because they expose the environment to the path author:
+//
+//	env("NAME")      -- environment variable as a string (undefined if not set)
+//	uuid()           -- random UUID (version 4)
+//	random(min, max) -- random integer in [min, max] if both are integers, random number in [min, max) otherwise
+//	sha256(val)      -- hex encoded hash of a value: of the contents of a string, of raw json otherwise
+//	md5(val)         -- same as above
+//	crc32(val)       -- same as above
+//
+// Functions can be used in filters or terminally, applied to the current value: $.id.uuid(), $.random(1, 6).
+// Using a disabled function results in an error.
+func WithFunctions(names ...string) Option {
+	return func(ctx *tContext) {
+		if ctx.functions == nil {
+			ctx.functions = make(map[string]bool)
+		}
+		for _, name := range names {
+			ctx.functions[name] = true
+		}
+	}
+}
+
+// enabled returns true if an opt-in function is enabled
+func (ctx *tContext) enabled(name string) bool {
+	return ctx != nil && ctx.functions[name]
+}
+
+// checkFunctions makes sure every opt-in function used in the node list is enabled
+func checkFunctions(node *tNode, ctx *tContext) error {
+	for n := node; n != nil; n = n.Next {
+		if err := checkCalls(n.Calls, ctx); err != nil {
+			return err
+		}
+		for _, part := range n.Union {
+			if err := checkCalls(part.Calls, ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCalls makes sure every opt-in function of the calls is enabled
+func checkCalls(calls []*tCall, ctx *tContext) error {
+	for _, call := range calls {
+		if optInFunctions[call.name] && !ctx.enabled(call.name) {
+			return errFunctionDisabled
+		}
+	}
+	return nil
+}
+
+// fnEnv returns the value of an environment variable
+func fnEnv(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	name, ok := argString(args[0])
+	if !ok {
+		return nil, nil
+	}
+	val, ok := os.LookupEnv(string(name))
+	if !ok {
+		return nil, nil
+	}
+	return jsonQuote(nil, []byte(val)), nil
+}
+
+// fnUUID returns a random (version 4) UUID
+func fnUUID(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 0 {
+		return nil, errPathInvalidExpression
+	}
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return nil, err
+	}
+	u[6] = u[6]&0x0f | 0x40 // version 4
+	u[8] = u[8]&0x3f | 0x80 // variant 10
+	buf := make([]byte, 38)
+	buf[0], buf[37] = '"', '"'
+	hex.Encode(buf[1:9], u[0:4])
+	buf[9] = '-'
+	hex.Encode(buf[10:14], u[4:6])
+	buf[14] = '-'
+	hex.Encode(buf[15:19], u[6:8])
+	buf[19] = '-'
+	hex.Encode(buf[20:24], u[8:10])
+	buf[24] = '-'
+	hex.Encode(buf[25:37], u[10:])
+	return buf, nil
+}
+
+// fnRandom returns a random number between min and max
+func fnRandom(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errPathInvalidExpression
+	}
+	min, ok1 := argNumber(args[0])
+	max, ok2 := argNumber(args[1])
+	if !ok1 || !ok2 || max < min {
+		return nil, nil
+	}
+	if min == math.Trunc(min) && max == math.Trunc(max) && max-min < 1<<53 && math.Abs(min) < 1<<63 && math.Abs(max) < 1<<63 {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min)+1))
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(min)+n.Int64(), 10), nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	if err != nil {
+		return nil, err
+	}
+	return strconv.AppendFloat(nil, min+(max-min)*float64(n.Int64())/(1<<53), 'f', -1, 64), nil
+}
+
+// argNumber returns the value of a numeric argument
+func argNumber(val []byte) (float64, bool) {
+	var op xpression.Operand
+	if len(val) == 0 || decodeValue(val, &op) != nil || op.Type != xpression.NumberOperand {
+		return 0, false
+	}
+	return op.Number, true
+}
+
+// fnHash returns a function computing hex encoded hash of a value
+func fnHash(h func() hash.Hash) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, errPathInvalidExpression
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		val, ok := argString(args[0])
+		if !ok {
+			val = bytes.TrimSpace(args[0])
+		}
+		hh := h()
+		hh.Write(val)
+		sum := hh.Sum(nil)
+		buf := make([]byte, hex.EncodedLen(len(sum))+2)
+		buf[0], buf[len(buf)-1] = '"', '"'
+		hex.Encode(buf[1:], sum)
+		return buf, nil
+	}
+}
+
+// fnURLDecode decodes a URL-encoded (query escaped) string
+func fnURLDecode(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	str, ok := argString(args[0])
+	if !ok {
+		return nil, nil
+	}
+	res, err := url.QueryUnescape(string(str))
+	if err != nil {
+		return nil, nil
+	}
+	return jsonQuote(nil, []byte(res)), nil
+}
+
+// fnQueryParam returns the (first) value of a query parameter of a URL or a query string
+func fnQueryParam(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, errPathInvalidExpression
+	}
+	str, ok1 := argString(args[0])
+	name, ok2 := argString(args[1])
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	query := string(str)
+	if i := strings.IndexByte(query, '?'); i >= 0 {
+		query = query[i+1:]
+	} else if strings.Contains(query, "://") {
+		return nil, nil // URL without query
+	}
+	if i := strings.IndexByte(query, '#'); i >= 0 {
+		query = query[:i]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil && len(values) == 0 {
+		return nil, nil
+	}
+	vals, ok := values[string(name)]
+	if !ok || len(vals) == 0 {
+		return nil, nil
+	}
+	return jsonQuote(nil, []byte(vals[0])), nil
+}
+
+// fnToNumber converts a value into a number: strings are parsed, booleans become 1 or 0
+func fnToNumber(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	if str, ok := argString(args[0]); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(str)), 64)
+		if err != nil {
+			return nil, nil
+		}
+		return formatNumber(f), nil
+	}
+	var op xpression.Operand
+	if len(args[0]) == 0 || decodeValue(args[0], &op) != nil {
+		return nil, nil
+	}
+	switch op.Type {
+	case xpression.NumberOperand:
+		return bytes.TrimSpace(args[0]), nil
+	case xpression.BooleanOperand:
+		if op.Bool {
+			return []byte("1"), nil
+		}
+		return []byte("0"), nil
+	}
+	return nil, nil
+}
+
+// fnToString converts a value into a string: numbers keep their source form, objects and arrays become json text
+func fnToString(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	if _, ok := argString(args[0]); ok {
+		return args[0], nil
+	}
+	return jsonQuote(nil, bytes.TrimSpace(args[0])), nil
+}
+
+// maxRoundDigits is the largest scale of round() (10^308 is the largest power of 10 of float64)
+const maxRoundDigits = 308
+
+// fnRound rounds a number to the given number of decimal digits (0 by default), half away from zero
+func fnRound(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, errPathInvalidExpression
+	}
+	x, ok := argNumber(args[0])
+	if !ok {
+		return nil, nil
+	}
+	digits := 0.0
+	if len(args) == 2 {
+		if digits, ok = argNumber(args[1]); !ok {
+			return nil, nil
+		}
+	}
+	digits = math.Max(-maxRoundDigits, math.Min(math.Trunc(digits), maxRoundDigits))
+	p := math.Pow(10, digits)
+	if math.IsInf(x*p, 0) { // no digits that far
+		return formatNumber(x), nil
+	}
+	return formatNumber(math.Round(x*p) / p), nil
+}
+
+// formatNumber formats a number as json
+func formatNumber(f float64) []byte {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return []byte("null")
+	}
+	return strconv.AppendFloat(nil, f, 'f', -1, 64)
+}
+
+// argText returns the contents of a string argument or the source text of other values
+func argText(val []byte) ([]byte, bool) {
+	if str, ok := argString(val); ok {
+		return str, true
+	}
+	val = bytes.TrimSpace(val)
+	return val, len(val) > 0
+}
+
+// fnTrim returns a function removing leading and/or trailing whitespace (or the characters given as the second argument)
+func fnTrim(space func(string) string, cutset func(string, string) string) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return nil, errPathInvalidExpression
+		}
+		str, ok := argText(args[0])
+		if !ok {
+			return nil, nil
+		}
+		if len(args) == 1 {
+			return jsonQuote(nil, []byte(space(string(str)))), nil
+		}
+		chars, ok := argString(args[1])
+		if !ok {
+			return nil, nil
+		}
+		return jsonQuote(nil, []byte(cutset(string(str), string(chars)))), nil
+	}
+}
+
+// maxPadWidth limits the width of a padded string
+const maxPadWidth = 1 << 16
+
+// fnPad returns a function padding a string to the given width (in characters) with spaces
+// (or the string given as the third argument), undefined if the width exceeds maxPadWidth
+func fnPad(left bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) < 2 || len(args) > 3 {
+			return nil, errPathInvalidExpression
+		}
+		str, ok := argText(args[0])
+		if !ok {
+			return nil, nil
+		}
+		width, ok := argNumber(args[1])
+		if !ok || width > maxPadWidth {
+			return nil, nil
+		}
+		pad := []byte{' '}
+		if len(args) == 3 {
+			if pad, ok = argString(args[2]); !ok || len(pad) == 0 {
+				return nil, nil
+			}
+		}
+		n := int(width) - utf8.RuneCount(str)
+		if n <= 0 {
+			return jsonQuote(nil, str), nil
+		}
+		fill := make([]byte, 0, n*len(pad))
+		for k, i := 0, 0; k < n; k++ {
+			_, size := utf8.DecodeRune(pad[i:])
+			fill = append(fill, pad[i:i+size]...)
+			if i += size; i == len(pad) {
+				i = 0
+			}
+		}
+		if left {
+			return jsonQuote(nil, append(fill, str...)), nil
+		}
+		return jsonQuote(nil, append(append([]byte{}, str...), fill...)), nil
+	}
+}
+
+// fnLetterCase returns a function converting a string to upper or lower case
+func fnLetterCase(conv func(string) string) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, errPathInvalidExpression
+		}
+		str, ok := argString(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return jsonQuote(nil, []byte(conv(string(str)))), nil
+	}
+}
+
+// fnSubstr returns a part of a string starting at the given character (counted from the end if negative)
+// of the given length (up to the end of the string if omitted)
+func fnSubstr(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errPathInvalidExpression
+	}
+	str, ok := argString(args[0])
+	if !ok {
+		return nil, nil
+	}
+	start, ok := argNumber(args[1])
+	if !ok {
+		return nil, nil
+	}
+	runes := []rune(string(str))
+	start = math.Trunc(start)
+	if start < 0 {
+		start += float64(len(runes))
+	}
+	from := int(math.Max(0, math.Min(start, float64(len(runes)))))
+	to := len(runes)
+	if len(args) == 3 {
+		length, ok := argNumber(args[2])
+		if !ok {
+			return nil, nil
+		}
+		to = from + int(math.Max(0, math.Min(length, float64(len(runes)-from))))
+	}
+	return jsonQuote(nil, []byte(string(runes[from:to]))), nil
+}
+
+// fnConcat returns a string of the values (strings or json text of other values) following one another,
+// undefined if any of them is undefined
+func fnConcat(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errPathInvalidExpression
+	}
+	var res []byte
+	for _, arg := range args {
+		str, ok := argText(arg)
+		if !ok {
+			return nil, nil
+		}
+		res = append(res, str...)
+	}
+	return jsonQuote(nil, res), nil
+}
+
+// joinArray returns a string of the elements of an array (the first argument of a call) separated
+// by the second argument (a comma by default): $.store.book[*].author.join("; ").
+// The separator is evaluated once, on the array.
+func joinArray(input []byte, nod *tNode, call *tCall) ([]byte, error) {
+	if len(call.args) < 1 || len(call.args) > 2 {
+		return nil, errPathInvalidExpression
+	}
+	val, err := evalArg(input, nod, call.args[0])
+	if err != nil {
+		return nil, err
+	}
+	elems, ok := argArray(val)
+	if !ok {
+		return nil, nil
+	}
+	sep := []byte{','}
+	if len(call.args) == 2 {
+		arg, err := evalArg(val, nod, call.args[1])
+		if err != nil {
+			return nil, err
+		}
+		if sep, ok = argString(arg); !ok {
+			return nil, nil
+		}
+	}
+	var res []byte
+	n := 0
+	for _, el := range elems {
+		str, ok := argText(el)
+		if !ok {
+			continue
+		}
+		if n > 0 {
+			res = append(res, sep...)
+		}
+		res = append(res, str...)
+		n++
+	}
+	return jsonQuote(nil, res), nil
+}
+
+// fnCoalesce returns the first defined non-null argument
+func fnCoalesce(ctx *tContext, args [][]byte) ([]byte, error) {
+	for _, arg := range args {
+		if val := bytes.TrimSpace(arg); len(val) > 0 && !bytes.Equal(val, []byte("null")) {
+			return val, nil
+		}
+	}
+	return nil, nil
+}
+
+// argTrue returns true for a truthy value: true, a non-zero number, a non-empty string, an object or an array
+func argTrue(val []byte) bool {
+	var op xpression.Operand
+	if len(val) == 0 || decodeValue(val, &op) != nil {
+		return false
+	}
+	switch op.Type {
+	case xpression.BooleanOperand:
+		return op.Bool
+	case xpression.NumberOperand:
+		return op.Number != 0
+	case xpression.StringOperand:
+		return len(op.Str) > 0
+	}
+	return false
+}
+
+// fnIf returns the second argument if the first one is true, the third one (if any) otherwise
+func fnIf(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, errPathInvalidExpression
+	}
+	if argTrue(args[0]) {
+		return args[1], nil
+	}
+	if len(args) == 3 {
+		return args[2], nil
+	}
+	return nil, nil
+}
+
+// fnCase takes condition-value pairs and returns the value of the first true condition,
+// or the last unpaired argument (default) if there is no such condition
+func fnCase(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) < 2 {
+		return nil, errPathInvalidExpression
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		if argTrue(args[i]) {
+			return args[i+1], nil
+		}
+	}
+	if len(args)%2 == 1 {
+		return args[len(args)-1], nil
+	}
+	return nil, nil
+}
+
+// argNumbers returns numeric values of the elements of a single array argument or of the arguments themselves.
+// Non-numeric values are skipped.
+func argNumbers(args [][]byte) []float64 {
+	if len(args) == 1 {
+		if i, err := skipSpaces(args[0], 0); err == nil && args[0][i] == '[' {
+			elems, err := arrayElems(args[0], i)
+			if err != nil {
+				return nil
+			}
+			arr := args[0]
+			args = make([][]byte, len(elems))
+			for j, el := range elems {
+				args[j] = arr[el.start:el.end]
+			}
+		}
+	}
+	nums := make([]float64, 0, len(args))
+	for _, arg := range args {
+		var op xpression.Operand
+		if decodeValue(arg, &op) == nil && op.Type == xpression.NumberOperand {
+			nums = append(nums, op.Number)
+		}
+	}
+	return nums
+}
+
+func aggSum(nums []float64) float64 {
+	sum := 0.0
+	for _, n := range nums {
+		sum += n
+	}
+	return sum
+}
+
+func aggAvg(nums []float64) float64 {
+	return aggSum(nums) / float64(len(nums))
+}
+
+func aggMin(nums []float64) float64 {
+	min := nums[0]
+	for _, n := range nums[1:] {
+		if n < min {
+			min = n
+		}
+	}
+	return min
+}
+
+func aggMax(nums []float64) float64 {
+	max := nums[0]
+	for _, n := range nums[1:] {
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+// fnAggregate returns a function aggregating numbers: elements of a single array argument or the arguments themselves
+func fnAggregate(agg func([]float64) float64) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) == 0 {
+			return nil, errPathInvalidExpression
+		}
+		nums := argNumbers(args)
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		return formatNumber(agg(nums)), nil
+	}
+}
+
+// fnKeys returns an array of the keys of an object
+func fnKeys(ctx *tContext, args [][]byte) ([]byte, error) {
+	return objectMembers(args, true)
+}
+
+// fnValues returns an array of the values of an object (an array is returned as is)
+func fnValues(ctx *tContext, args [][]byte) ([]byte, error) {
+	return objectMembers(args, false)
+}
+
+// objectMembers collects keys or values of an object into an array
+func objectMembers(args [][]byte, keys bool) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	input := args[0]
+	i, err := skipSpaces(input, 0)
+	if err != nil {
+		return nil, nil
+	}
+	if input[i] == '[' && !keys {
+		e, err := skipValue(input, i)
+		return input[i:e], err
+	}
+	if input[i] != '{' {
+		return nil, nil
+	}
+	res := []byte{'['}
+	l := len(input)
+	for i++; i < l && input[i] != '}'; {
+		var key []byte
+		if key, i, err = readObjectKey(input, i); err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		if len(res) > 1 {
+			res = append(res, ',')
+		}
+		var vs, ve int
+		if vs, ve, i, err = valuate(input, i); err != nil {
+			return nil, err
+		}
+		if keys {
+			res = jsonQuote(res, key)
+		} else {
+			res = append(res, input[vs:ve]...)
+		}
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	return append(res, ']'), nil
+}
+
+// fnExists returns a function checking whether its argument is defined (or undefined if exists is false).
+// Unlike ?(@.val) a value of 0, false, "" or null is defined.
+func fnExists(exists bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, errPathInvalidExpression
+		}
+		return jsonBool((len(args[0]) > 0) == exists), nil
+	}
+}
+
+// jsonType returns the json type of a value: string, number, boolean, null, object or array ("" if undefined)
+func jsonType(val []byte) string {
+	i, err := skipSpaces(val, 0)
+	if err != nil {
+		return ""
+	}
+	switch c := val[i]; {
+	case c == '"':
+		return "string"
+	case c == '{':
+		return "object"
+	case c == '[':
+		return "array"
+	case c == 't' || c == 'f':
+		return "boolean"
+	case c == 'n':
+		return "null"
+	}
+	return "number"
+}
+
+// fnTypeOf returns the json type of a value as a string: "string", "number", "boolean", "null", "object" or "array"
+func fnTypeOf(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	typ := jsonType(args[0])
+	if typ == "" {
+		return nil, nil
+	}
+	return jsonQuote(nil, []byte(typ)), nil
+}
+
+// fnIsType returns a function checking the json type of a value
+func fnIsType(typ string) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 1 {
+			return nil, errPathInvalidExpression
+		}
+		return jsonBool(jsonType(args[0]) == typ), nil
+	}
+}
+
+// datetimeLayouts are the timestamp formats accepted by datetime functions
+var datetimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// argTime returns the time of a timestamp argument: an RFC 3339 string (time zone defaults to UTC,
+// time defaults to midnight) or a number of seconds since the Unix epoch
+func argTime(val []byte) (time.Time, bool) {
+	if str, ok := argString(val); ok {
+		for _, layout := range datetimeLayouts {
+			if t, err := time.Parse(layout, string(str)); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+	sec, ok := argNumber(val)
+	if !ok {
+		return time.Time{}, false
+	}
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC(), true
+}
+
+// fnDatetime converts a timestamp into a number of seconds since the Unix epoch, so that timestamps
+// in different time zones or formats can be compared: datetime(@.date) > datetime("2022-01-01T00:00:00Z")
+func fnDatetime(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	t, ok := argTime(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return formatNumber(float64(t.Unix()) + float64(t.Nanosecond())/1e9), nil
+}
+
+// datetimeComparisons replaces the comparisons of a datetime() call with a value other than a datetime() call
+// with calls comparing timestamps, so that @.date > datetime("2022-01-01") compares @.date as a timestamp
+// rather than a string with a number
+func datetimeComparisons(n *tNode) error {
+	datetime := func(arg *tArg) bool {
+		return arg.call > 0 && n.Calls[arg.call-1].name == "datetime"
+	}
+	return replaceNodeComparisons(n, func(op xpression.Operator, left, right *tArg) (tComparison, bool) {
+		if datetime(left) == datetime(right) {
+			return tComparison{}, false
+		}
+		return tComparison{rfcOperators[op].name, datetimeCompare(op)}, true
+	})
+}
+
+// datetimeCompare makes a filter function comparing two timestamps (see argTime).
+// A value which is not a timestamp is not equal to anything.
+func datetimeCompare(op xpression.Operator) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		a, oka := argTime(args[0])
+		b, okb := argTime(args[1])
+		if !oka || !okb {
+			return jsonBool(op == opNotEqual || op == opStrictNotEqual), nil
+		}
+		switch op {
+		case opEqual, opStrictEqual:
+			return jsonBool(a.Equal(b)), nil
+		case opNotEqual, opStrictNotEqual:
+			return jsonBool(!a.Equal(b)), nil
+		case opLess:
+			return jsonBool(a.Before(b)), nil
+		case opLessEqual:
+			return jsonBool(!a.After(b)), nil
+		case opGreater:
+			return jsonBool(a.After(b)), nil
+		}
+		return jsonBool(!a.Before(b)), nil // opGreaterEqual
+	}
+}
+
+// fnCompareTime returns a function comparing two timestamps: true if the first one is before (sign < 0)
+// or after (sign > 0) the second one
+func fnCompareTime(sign int) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, errPathInvalidExpression
+		}
+		a, ok := argTime(args[0])
+		if !ok {
+			return jsonFalse, nil
+		}
+		b, ok := argTime(args[1])
+		if !ok {
+			return jsonFalse, nil
+		}
+		if sign < 0 {
+			return jsonBool(a.Before(b)), nil
+		}
+		return jsonBool(a.After(b)), nil
+	}
+}
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// fnNow returns the current time: now([layout [, zone]]), see formatTime
+func fnNow(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) > 2 {
+		return nil, errPathInvalidExpression
+	}
+	return formatTime(timeNow(), args)
+}
+
+// fnFormat formats a timestamp (see argTime): format(ts [, layout [, zone]]), see formatTime
+func fnFormat(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, errPathInvalidExpression
+	}
+	t, ok := argTime(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return formatTime(t, args[1:])
+}
+
+// formatTime formats t according to the optional layout and zone arguments.
+// layout is a Go time layout ("2006-01-02") or one of "rfc3339" (default), "unix" (seconds), "unixms" (milliseconds);
+// zone is an IANA time zone name ("UTC", "Europe/Berlin"), the local zone by default. Undefined for an unknown zone.
+func formatTime(t time.Time, args [][]byte) ([]byte, error) {
+	layout := []byte("rfc3339")
+	if len(args) > 0 {
+		var ok bool
+		if layout, ok = argString(args[0]); !ok {
+			return nil, nil
+		}
+	}
+	if len(args) > 1 {
+		zone, ok := argString(args[1])
+		if !ok {
+			return nil, nil
+		}
+		loc, err := time.LoadLocation(string(zone))
+		if err != nil {
+			return nil, nil
+		}
+		t = t.In(loc)
+	}
+	switch string(layout) {
+	case "unix":
+		return strconv.AppendInt(nil, t.Unix(), 10), nil
+	case "unixms":
+		return strconv.AppendInt(nil, t.UnixNano()/int64(time.Millisecond), 10), nil
+	case "rfc3339":
+		layout = []byte(time.RFC3339)
+	}
+	return jsonQuote(nil, []byte(t.Format(string(layout)))), nil
+}
@@ -0,0 +1,761 @@
+package jsonslice
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_Env(t *testing.T) {
+
+	os.Setenv("JSONSLICE_TEST_MIN", "10")
+	os.Setenv("JSONSLICE_TEST_TAG", "new")
+	defer os.Unsetenv("JSONSLICE_TEST_MIN")
+	defer os.Unsetenv("JSONSLICE_TEST_TAG")
+
+	input := []byte(`[{"price": 5, "tag": "new"}, {"price": 15, "tag": "old"}, {"price": 25, "tag": "new"}]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(@.price > env("JSONSLICE_TEST_MIN"))].price`, []byte(`[15,25]`)},
+		{`$[?(@.tag == env('JSONSLICE_TEST_TAG'))].price`, []byte(`[5,25]`)},
+		{`$[?(env("JSONSLICE_TEST_UNDEFINED"))].price`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithFunctions("env"))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// disabled by default
+	if _, err := Get(input, tests[0].Query); err != errFunctionDisabled {
+		t.Errorf("expected errFunctionDisabled, got %v", err)
+	}
+	// even if the filter is never evaluated
+	if _, err := Get([]byte(`[]`), tests[0].Query); err != errFunctionDisabled {
+		t.Errorf("expected errFunctionDisabled, got %v", err)
+	}
+}
+
+func Test_Generators(t *testing.T) {
+
+	input := []byte(`{"a": [1, 2, 3]}`)
+
+	res, err := GetWith(input, `$.uuid()`, WithFunctions("uuid"))
+	if err != nil || len(res) != 38 || res[15] != '4' || !bytein(res[20], []byte("89ab")) {
+		t.Errorf("unexpected uuid: %s (%v)", res, err)
+	}
+	res, err = GetWith(input, `$.a[*].uuid()`, WithFunctions("uuid"))
+	if err != nil || len(res) != 3*38+4 {
+		t.Errorf("unexpected uuids: %s (%v)", res, err)
+	}
+
+	for i := 0; i < 20; i++ {
+		res, err = GetWith(input, `$.random(1, 3)`, WithFunctions("random"))
+		if err != nil || !bytein(res[0], []byte("123")) || len(res) != 1 {
+			t.Errorf("unexpected random integer: %s (%v)", res, err)
+		}
+		res, err = GetWith(input, `$.random(0.5, 1)`, WithFunctions("random"))
+		if err != nil || res[0] != '0' {
+			t.Errorf("unexpected random number: %s (%v)", res, err)
+		}
+	}
+	res, err = GetWith(input, `$.a[?(random(1e300, 1e300) == 1e300 && random(-1e19, -1e19) == -1e19)]`, WithFunctions("random"))
+	if err != nil || string(res) != `[1,2,3]` {
+		t.Errorf("unexpected random number beyond int64: %s (%v)", res, err)
+	}
+	res, err = GetWith(input, `$.a[?(@ >= random(2, 2))]`, WithFunctions("random"))
+	if err != nil || string(res) != `[2,3]` {
+		t.Errorf("unexpected filter result: %s (%v)", res, err)
+	}
+
+	for _, path := range []string{`$.uuid()`, `$.random(1, 2)`} {
+		if _, err := Get(input, path); err != errFunctionDisabled {
+			t.Errorf(path+" : expected errFunctionDisabled, got %v", err)
+		}
+	}
+}
+
+func Test_Hashes(t *testing.T) {
+
+	input := []byte(`{"users": [{"email": "a@b.c", "id": 42}, {"email": "x@y.z", "id": 7}]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.users[0].email.sha256(@)`, []byte(`"d648b243a3e817eaa3309e00e183483f2867baadf522099f0c2121770536b25a"`)},
+		{`$.users[0].md5(@.email)`, []byte(`"5d60d4e28066df254d5452f92c910092"`)},
+		{`$.users[0].crc32(@.id)`, []byte(`"3224b088"`)},
+		{`$.users[?(md5(@.email) == "5d60d4e28066df254d5452f92c910092")].id`, []byte(`[42]`)},
+		{`$.users[*].crc32(@.nope)`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithFunctions("sha256", "md5", "crc32"))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	if _, err := Get(input, `$.users[0].sha256(@.email)`); err != errFunctionDisabled {
+		t.Errorf("expected errFunctionDisabled, got %v", err)
+	}
+}
+
+func Test_URLFunctions(t *testing.T) {
+
+	input := []byte(`[
+		{"url": "https://example.com/search?q=red%20shoes&id=17#top", "q": "red+shoes%21"},
+		{"url": "https://example.com/item?id=42&id=43", "q": "%zz"},
+		{"url": "https://example.com/", "q": "id=5&x=1"}
+	]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[0].urldecode(@.q)`, []byte(`"red shoes!"`)},
+		{`$[0].queryparam(@.url, "q")`, []byte(`"red shoes"`)},
+		{`$[*].queryparam(@.url, 'id')`, []byte(`["17","42"]`)},
+		{`$[2].queryparam(@.q, 'id')`, []byte(`"5"`)},
+		{`$[?(queryparam(@.url, "id") == 42)].q`, []byte(`["%zz"]`)},
+		{`$[?(urldecode(@.q) == "red shoes!")].queryparam(@.url, "id")`, []byte(`["17"]`)},
+		{`$[?(urldecode(@.q))].q`, []byte(`["red+shoes%21","id=5&x=1"]`)},
+		{`$[0].q.urldecode()`, []byte(`"red shoes!"`)},
+		{`$[*].q.urldecode()`, []byte(`["red shoes!","id=5&x=1"]`)},
+		{`$[0].url.queryparam("id")`, []byte(`"17"`)},
+		{`$[*].url.queryparam('id')`, []byte(`["17","42"]`)},
+		{`$[2].q.queryparam("x")`, []byte(`"1"`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_ConversionFunctions(t *testing.T) {
+
+	input := []byte(`[
+		{"id": "17", "price": 8.955, "ok": true},
+		{"id": 42, "price": "12.5", "ok": "yes"},
+		{"id": " 7 ", "price": 1e2, "ok": null}
+	]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[*].tonumber(@.id)`, []byte(`[17,42,7]`)},
+		{`$[*].tostring(@.id)`, []byte(`["17","42"," 7 "]`)},
+		{`$[*].tostring(@.price)`, []byte(`["8.955","12.5","1e2"]`)},
+		{`$[0].tonumber(@.ok)`, []byte(`1`)},
+		{`$[1].tonumber(@.ok)`, []byte(``)},
+		{`$[0].round(@.price, 2)`, []byte(`8.96`)},
+		{`$[0].round(@.price)`, []byte(`9`)},
+		{`$[2].round(@.price, -2)`, []byte(`100`)},
+		{`$[0].round(@.price, 400)`, []byte(`8.955`)},
+		{`$[0].round(@.price, -400)`, []byte(`0`)},
+		{`$[?(round(1e300, 20) == 1e300)].id`, []byte(`["17",42," 7 "]`)},
+		{`$[?(tonumber(@.price) > 10)].id`, []byte(`[42," 7 "]`)},
+		{`$[?(tostring(@.id) === "42")].price`, []byte(`["12.5"]`)},
+		{`$[?(round(tonumber(@.price)) == 13)].id`, []byte(`[42]`)},
+		{`$[0].price.round(2)`, []byte(`8.96`)},
+		{`$[0].price.round()`, []byte(`9`)},
+		{`$[*].price.round(-1)`, []byte(`[10,100]`)},
+		{`$[1].price.tonumber()`, []byte(`12.5`)},
+		{`$[*].id.tonumber()`, []byte(`[17,42,7]`)},
+		{`$[2].price.tostring()`, []byte(`"1e2"`)},
+		{`$[*].ok.tostring()`, []byte(`["true","yes","null"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_TrimPadFunctions(t *testing.T) {
+
+	input := []byte(`[{"code": "  AB \t", "id": 42, "name": "Ёж"}, {"code": "xxCDxx", "id": 7, "name": "verylongname"}]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[0].trim(@.code)`, []byte(`"AB"`)},
+		{`$[0].ltrim(@.code)`, []byte(`"AB \t"`)},
+		{`$[0].rtrim(@.code)`, []byte(`"  AB"`)},
+		{`$[1].trim(@.code, "x")`, []byte(`"CD"`)},
+		{`$[1].rtrim(@.code, "x")`, []byte(`"xxCD"`)},
+		{`$[*].padLeft(@.id, 5, "0")`, []byte(`["00042","00007"]`)},
+		{`$[0].padRight(@.name, 5, ".-")`, []byte(`"Ёж.-."`)},
+		{`$[*].padLeft(@.name, 4)`, []byte(`["  Ёж","verylongname"]`)},
+		{`$[?(trim(@.code) == "AB")].id`, []byte(`[42]`)},
+		{`$[?(padLeft(@.id, 3, "0") == "007")].id`, []byte(`[7]`)},
+		{`$[0].padLeft(@.name, 1e18)`, []byte(``)},
+		{`$[0].padRight(@.name, 1e9, "0")`, []byte(``)},
+		{`$[*].id.padLeft(5, "0")`, []byte(`["00042","00007"]`)},
+		{`$[0].name.padRight(5, ".-")`, []byte(`"Ёж.-."`)},
+		{`$[0].name.padLeft(4)`, []byte(`"  Ёж"`)},
+		{`$[1].name.padRight(4)`, []byte(`"verylongname"`)},
+		{`$[?(@.id.padLeft(3, "0") == "007")].id`, []byte(`[7]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_StringFunctions(t *testing.T) {
+
+	input := []byte(`{"user": {"name": "John Smith"}, "items": [{"code": " ab ", "id": 1}, {"code": "AB", "id": 2}, {"code": "Ёжик", "id": 3}, {"code": 12, "id": 4}]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.user.name.upper()`, []byte(`"JOHN SMITH"`)},
+		{`$.user.name.lower()`, []byte(`"john smith"`)},
+		{`$.items[0].code.trim()`, []byte(`"ab"`)},
+		{`$.items[0].code.ltrim()`, []byte(`"ab "`)},
+		{`$.items[*].code.upper()`, []byte(`[" AB ","AB","ЁЖИК"]`)},
+		{`$.user.name.substr(5)`, []byte(`"Smith"`)},
+		{`$.user.name.substr(0, 4)`, []byte(`"John"`)},
+		{`$.user.name.substr(-3)`, []byte(`"ith"`)},
+		{`$.user.name.substr(-30, 2)`, []byte(`"Jo"`)},
+		{`$.user.name.substr(20)`, []byte(`""`)},
+		{`$.items[2].code.substr(1, 2)`, []byte(`"жи"`)},
+		{`$.user.substr(@.name, 5, 1)`, []byte(`"S"`)},
+		{`$.user.name.substr(1, 1e19)`, []byte(`"ohn Smith"`)},
+		{`$.user.name.substr(1e19)`, []byte(`""`)},
+		{`$.user.name.substr(-1e19, 4)`, []byte(`"John"`)},
+		{`$.items[?(substr(@.code, 1, 1e19) == "жик")].id`, []byte(`[3]`)},
+		{`$.items[?(@.code.trim().upper() == "AB")].id`, []byte(`[1,2]`)},
+		{`$.items[?(@.code.trim() == "AB")].id`, []byte(`[2]`)},
+		{`$.items[?(upper(@.code) == "AB")].id`, []byte(`[2]`)},
+		{`$.items[?(lower(@.code) == "ёжик")].id`, []byte(`[3]`)},
+		{`$.items[?(substr(@.code, 0, 2) == "Ёж")].id`, []byte(`[3]`)},
+		{`$.items[3].code.upper()`, []byte(``)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Join(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"author": "Nigel Rees", "p": 8}, {"author": "Evelyn \"W\"", "p": 12}, {"author": "Herman", "p": 9}]}, "user": {"first": "John", "last": "Smith", "age": 42}, "tags": ["z", 1, "x"]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[:].author.join("; ")`, []byte(`"Nigel Rees; Evelyn \"W\"; Herman"`)},
+		{`$..author.join()`, []byte(`"Nigel Rees,Evelyn \"W\",Herman"`)},
+		{`$..p.join("")`, []byte(`"8129"`)},
+		{`$.tags.join("-")`, []byte(`"z-1-x"`)},
+		{`$.store.book[?(@.p > 100)].author.join(",")`, []byte(`""`)},
+		{`$..book[*].sort(@.author)[*].author.join(", ")`, []byte(`"Evelyn \"W\", Herman, Nigel Rees"`)},
+		{`$..author.join(1)`, nil},
+		{`$.user.concat(@.first, " ", @.last, ", ", @.age)`, []byte(`"John Smith, 42"`)},
+		{`$.user.concat(@.first, @.middle)`, nil},
+		{`$.store.book[?(concat(@.author, "!") == "Herman!")].p`, []byte(`[9]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Coalesce(t *testing.T) {
+
+	input := []byte(`{"v1": {"id": 1}, "v2": {"id": null}, "items": [{"name": "a"}, {"title": "b"}, {"name": null, "title": "c"}, {}]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.coalesce($.v2.id, $.v1.id, "none")`, []byte(`1`)},
+		{`$.coalesce($.v3.id, $.v2.id, "none")`, []byte(`"none"`)},
+		{`$.coalesce($.v3.id, $.v2.id)`, []byte(``)},
+		{`$.items[*].coalesce(@.name, @.title)`, []byte(`["a","b","c"]`)},
+		{`$.items[*].coalesce(@.name, @.title, $.v1.id)`, []byte(`["a","b","c",1]`)},
+		{`$.items[?(coalesce(@.name, @.title) == "c")].title`, []byte(`["c"]`)},
+		{`$.v1.coalesce(@.x, @)`, []byte(`{"id": 1}`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Conditionals(t *testing.T) {
+
+	input := []byte(`{"expensive": 10, "book": [{"price": 8.95, "tag": ""}, {"price": 12.99, "tag": "new"}, {"price": 22.99}]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.book[*].if(@.price > $.expensive, "pricey", "cheap")`, []byte(`["cheap","pricey","pricey"]`)},
+		{`$.book[*].if(@.tag, @.tag, "none")`, []byte(`["none","new","none"]`)},
+		{`$.book[*].if(@.price < 10, @.price)`, []byte(`[8.95]`)},
+		{`$.book[*].case(@.price < 10, "low", @.price < 20, "mid", "high")`, []byte(`["low","mid","high"]`)},
+		{`$.book[*].case(@.price < 10, "low", @.price < 20, "mid")`, []byte(`["low","mid"]`)},
+		{`$.book[?(if(@.tag, true, @.price > 20))].price`, []byte(`[12.99,22.99]`)},
+		{`$.book[?(case(@.price > 20, "x", "y") == "y")].price`, []byte(`[8.95,12.99]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Aggregates(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"price": 8.95}, {"price": 12.99}, {"price": 8.99}, {"price": 22.99}, {"price": "n/a"}]}, "limits": [10, 20]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[?(@.price > avg($.store.book[*].price))].price`, []byte(`[22.99]`)},
+		{`$.store.book[?(@.price == min($.store.book[*].price))].price`, []byte(`[8.95]`)},
+		{`$.store.book[?(@.price == max($.store.book[*].price))].price`, []byte(`[22.99]`)},
+		{`$.store.book[?(@.price < max(9, min($.limits)))].price`, []byte(`[8.95,8.99]`)},
+		{`$.sum($.store.book[*].price)`, []byte(`53.92`)},
+		{`$.limits.avg(@)`, []byte(`15`)},
+		{`$.limits.sum(@[0], @[1], 5)`, []byte(`35`)},
+		{`$.store.max(@.book[*].none)`, []byte(``)},
+		// applied to the whole result
+		{`$.store.book[:].price.sum()`, []byte(`53.92`)},
+		{`$.store.book[*].price.avg()`, []byte(`13.48`)},
+		{`$.store.book[?(@.price > 9)].price.min()`, []byte(`12.99`)},
+		{`$..price.max()`, []byte(`22.99`)},
+		{`$.limits.sum()`, []byte(`30`)},
+		{`$.limits[0].max()`, []byte(`10`)},
+		{`$.none[*].sum()`, []byte(``)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// evaluated once per query
+	node, err := parsePath(`$.store.book[?(@.price > avg($.store.book[*].price) && @.price < max(@.price, 1))]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repool(node)
+	evalRootRefs(input, node)
+	for n := node; n != nil; n = n.Next {
+		for _, call := range n.Calls {
+			if call.once != (call.name == "avg") {
+				t.Errorf("%s: expected once = %v", call.name, call.name == "avg")
+			}
+		}
+	}
+}
+
+func Test_KeysValues(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [1, 2], "bicycle": {"color": "red"}, "a\"b": null}, "items": [{"x": 1, "y": 2}, {"z": 3}], "empty": {}}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.keys()`, []byte(`["book","bicycle","a\"b"]`)},
+		{`$.store.values()`, []byte(`[[1, 2],{"color": "red"},null]`)},
+		{`$.empty.keys()`, []byte(`[]`)},
+		{`$.items[*].keys()`, []byte(`[["x","y"],["z"]]`)},
+		{`$.store.book.values()`, []byte(`[1, 2]`)},
+		{`$.store.book.keys()`, []byte(``)},
+		{`$.items[?("y" in keys(@))].x`, []byte(`[1]`)},
+		{`$.items[?(in("z", @.keys()))].z`, []byte(`[3]`)},
+		{`$.items[?(sum(values(@)) > 2)]`, []byte(`[{"x": 1, "y": 2},{"z": 3}]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_MembershipOperators(t *testing.T) {
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[?(@.category in ["fiction","poetry"])].title`, []byte(`["Sword of Honour","Moby Dick","The Lord of the Rings"]`)},
+		{`$.store.book[?(@.category nin ["fiction","poetry"])].title`, []byte(`["Sayings of the Century"]`)},
+		{`$.store.book[?(@.price nin [8.95, 8.99] && @.category in ['fiction'])].price`, []byte(`[12.99,22.99]`)},
+		{`$.store.book[?(nin(@.author, $.store.book[1:].author))].author`, []byte(`["Nigel Rees"]`)},
+		{`$.store.book[?(@.isbn nin ["0-553-21311-3"])].title`, []byte(`["Sayings of the Century","Sword of Honour","The Lord of the Rings"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(data, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_ContainmentOperators(t *testing.T) {
+
+	input := []byte(`[{"title": "The Rings", "tags": ["a", "b", 1]}, {"title": "Moby Dick", "tags": ["b"]}, {"title": "Rings\"", "tags": []}]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(@.title contains "Rings")].title`, []byte(`["The Rings","Rings\""]`)},
+		{`$[?(@.title contains 'Rings"')].title`, []byte(`["Rings\""]`)},
+		{`$[?(@.tags contains "b")].title`, []byte(`["The Rings","Moby Dick"]`)},
+		{`$[?(@.tags contains 1)].title`, []byte(`["The Rings"]`)},
+		{`$[?(@.none contains "b")].title`, []byte(`[]`)},
+		{`$[?(@.tags subsetof ["b", "c"])].title`, []byte(`["Moby Dick","Rings\""]`)},
+		{`$[?(["a", 1] subsetof @.tags)].title`, []byte(`["The Rings"]`)},
+		{`$[?(!contains(@.title, "Rings") && subsetof(@.tags, $[0].tags))].title`, []byte(`["Moby Dick"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Exists(t *testing.T) {
+
+	input := []byte(`[{"id": 1, "price": 0}, {"id": 2, "price": null}, {"id": 3}, {"id": 4, "price": 5, "isbn": ""}]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(@.price)].id`, []byte(`[4]`)},
+		{`$[?(exists(@.price))].id`, []byte(`[1,2,4]`)},
+		{`$[?(!exists(@.price))].id`, []byte(`[3]`)},
+		{`$[?(missing(@.price))].id`, []byte(`[3]`)},
+		{`$[?(exists(@.isbn) && @.price > 1)].id`, []byte(`[4]`)},
+		{`$[?(missing(@.nothing))].id`, []byte(`[1,2,3,4]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_StandardFunctions(t *testing.T) {
+
+	input := []byte(`[
+		{"id": 1, "authors": ["a", "b", "c"], "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "meta": {"x": 1}},
+		{"id": 2, "authors": ["d"], "title": "Moby Dick", "isbn": "0-553-21311-3"},
+		{"id": 3, "authors": "héllo", "title": "line\nbreak"}
+	]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		// filters without parentheses
+		{`$[?length(@.authors) > 2].id`, []byte(`[1,3]`)},
+		{`$[?length(@.authors) == 5].id`, []byte(`[3]`)},
+		{`$[?length(@.meta) == 1].id`, []byte(`[1]`)},
+		{`$[?(length(@.id) == 1)].id`, []byte(`[]`)},
+		{`$[?match(@.isbn, "0-[0-9]{3}-.*")].id`, []byte(`[1,2]`)},
+		{`$[?match(@.isbn, "0-553")].id`, []byte(`[]`)},
+		{`$[?search(@.title, "Rings")].id`, []byte(`[1]`)},
+		{`$[?search(@.title, "line.break")].id`, []byte(`[]`)},
+		{`$[?search(@.id, "1")].id`, []byte(`[]`)},
+		{`$[?count(@.authors[*]) > 1].id`, []byte(`[1]`)},
+		{`$[?count(@.authors) == 1].id`, []byte(`[1,2,3]`)},
+		{`$[?count(@.meta) == 0].id`, []byte(`[2,3]`)},
+		{`$[?count($[*]) == 3].id`, []byte(`[1,2,3]`)},
+		{`$[?count(@.*) == 4].id`, []byte(`[2]`)},
+		{`$[?count(@..*) == 9].id`, []byte(`[1]`)},
+		{`$[?@.meta.* == 1].id`, []byte(`[1]`)},
+		{`$[?value(@.authors[0]) == "d"].id`, []byte(`[2]`)},
+		{`$[?value(@..x) == 1].id`, []byte(`[1]`)},
+		{`$[?value(@.authors[*]) == "d"].id`, []byte(`[2]`)},
+		{`$[?@.isbn && length(@.authors) < 3].id`, []byte(`[2]`)},
+		{`$[?@.id in [1, 3]].id`, []byte(`[1,3]`)},
+		{`$[?(@.id > 2) || (@.id < 2)].id`, []byte(`[1,3]`)},
+		{`$[0, ?@.id == 3].id`, []byte(`[1,3]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Index(t *testing.T) {
+
+	input := []byte(`{"items": [{"n": "a", "p": 1}, {"n": "b"}, {"n": "c", "p": 3}, {"n": "d", "p": 4}, {"n": "e"}], "obj": {"x": 1, "y": 2}}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.items[?(index() % 2 == 0)].n`, []byte(`["a","c","e"]`)},
+		{`$.items[?(index() % 2 == 1)].n`, []byte(`["b","d"]`)},
+		{`$.items[?index() < 2].n`, []byte(`["a","b"]`)},
+		{`$.items[?(@.p && index() > 1)].n`, []byte(`["c","d"]`)},
+		{`$.obj[?(index() == 1)]`, []byte(`[2]`)},
+		{`$..[?(index() == 4)].n`, []byte(`["e"]`)},
+		{`$.items.index()`, nil},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_TypeFunctions(t *testing.T) {
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(@.key.type() == "string")].key`, []byte(`["some","value","","0","1","valuemore","morevalue","42"]`)},
+		{`$[?(typeof(@.key) == "boolean")].key`, []byte(`[true,false]`)},
+		{`$[?(isNumber(@.key) && @.key > 41)].key`, []byte(`[42,43,42.0001,41.9999,420]`)},
+		{`$[?(isNull(@.key))]`, []byte(`[{"key": null}]`)},
+		{`$[?(isArray(@.key))].key`, []byte(`[[],["value"],[42]]`)},
+		{`$[?(isObject(@.key) && !isString(@.key.key))].key`, []byte(`[{},{"some": "value"},{"key": 42},{"some": 42}]`)},
+		{`$[?(isString(@.some))].some`, []byte(`["value"]`)},
+		{`$[-1].key.type()`, []byte(`"object"`)},
+		{`$[*].key.some.type()`, []byte(`["string","number"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(differentTypes, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_DatetimeFunctions(t *testing.T) {
+
+	input := []byte(`{"events": [
+		{"id": 1, "date": "2021-12-31T23:59:59Z"},
+		{"id": 2, "date": "2022-01-01T01:30:00+02:00"},
+		{"id": 3, "date": "2022-01-01T00:00:00.5Z"},
+		{"id": 4, "date": "2022-03-15"},
+		{"id": 5, "date": 1640995200},
+		{"id": 6, "date": "yesterday"}
+	]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.events[?(datetime(@.date) > datetime("2022-01-01T00:00:00Z"))].id`, []byte(`[3,4]`)},
+		{`$.events[?(datetime(@.date) == datetime("2022-01-01"))].id`, []byte(`[5]`)},
+		{`$.events[?(@.date.before("2022-01-01T00:00:00Z"))].id`, []byte(`[1,2]`)},
+		{`$.events[?(@.date.after("2022-01-01") && before(@.date, "2022-02-01"))].id`, []byte(`[3]`)},
+		{`$.events[?(missing(datetime(@.date)))].id`, []byte(`[6]`)},
+		{`$.events[0].datetime(@.date)`, []byte(`1640995199`)},
+		{`$.events[2].datetime(@.date)`, []byte(`1640995200.5`)},
+		{`$.events[*].date.after("2022-01-01")`, []byte(`[false,false,true,true,false,false]`)},
+		{`$.events[?(@.date > datetime("2022-01-01T00:00:00Z"))].id`, []byte(`[3,4]`)},
+		{`$.events[?(datetime("2022-01-01") <= @.date)].id`, []byte(`[3,4,5]`)},
+		{`$.events[?(@.date == datetime("2022-01-01"))].id`, []byte(`[5]`)},
+		{`$.events[?(@.date != datetime("2022-01-01"))].id`, []byte(`[1,2,3,4,6]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_DatetimeComparison(t *testing.T) {
+
+	input := []byte(`[{"date":"2023-05-01T00:00:00Z"},{"date":"2021-05-01T00:00:00Z"}]`)
+	res, err := Get(input, `$[?(@.date > datetime("2022-01-01T00:00:00Z"))]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"date":"2023-05-01T00:00:00Z"}]`; string(res) != expected {
+		t.Errorf("expected %s, got %s", expected, res)
+	}
+}
+
+func Test_Now(t *testing.T) {
+
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { return time.Date(2022, 3, 15, 22, 30, 0, 0, time.UTC) }
+
+	input := []byte(`{"date": "2022-01-01T12:00:00+02:00", "ts": 1640995200, "events": [{"id": 1, "expires": 1647380000}, {"id": 2, "expires": 1647390000}]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.now()`, []byte(`"2022-03-15T22:30:00Z"`)},
+		{`$.now("2006-01-02", "UTC")`, []byte(`"2022-03-15"`)},
+		{`$.now("2006-01-02 15:04", "Asia/Tokyo")`, []byte(`"2022-03-16 07:30"`)},
+		{`$.now("unix")`, []byte(`1647383400`)},
+		{`$.now().format("unix")`, []byte(`1647383400`)},
+		{`$.now().format("unixms")`, []byte(`1647383400000`)},
+		{`$.date.format("15:04", "UTC")`, []byte(`"10:00"`)},
+		{`$.ts.format("rfc3339", "UTC")`, []byte(`"2022-01-01T00:00:00Z"`)},
+		{`$.events[?(@.expires > now("unix"))].id`, []byte(`[2]`)},
+		// unknown zone
+		{`$.now("unix", "No/Such_Zone")`, []byte(``)},
+		{`$.date.format("15:04", "No/Such_Zone")`, []byte(``)},
+		{`$.events[?(format(@.expires, "unix", "No/Such_Zone"))].id`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+}
+
+func Test_RegisterFunction(t *testing.T) {
+
+	RegisterFunction("testB64", func(input []byte, args ...[]byte) ([]byte, error) {
+		str, ok := argString(args[0])
+		if !ok {
+			return nil, nil
+		}
+		dec, err := base64.StdEncoding.DecodeString(string(str))
+		if err != nil {
+			return nil, err
+		}
+		return jsonQuote(nil, dec), nil
+	})
+	RegisterFunction("testSelf", func(input []byte, args ...[]byte) ([]byte, error) {
+		return input, nil
+	})
+	defer func() {
+		delete(customFunctions, "testB64")
+		delete(customFunctions, "testSelf")
+		delete(filterFunctions, "testB64")
+		delete(filterFunctions, "testSelf")
+	}()
+
+	input := []byte(`[{"id": 1, "data": "aGVsbG8="}, {"id": 2, "data": "d29ybGQ="}]`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$[?(testB64(@.data) == "world")].id`, []byte(`[2]`)},
+		{`$[*].testB64(@.data)`, []byte(`["hello","world"]`)},
+		{`$[0].data.testB64(@)`, []byte(`"hello"`)},
+		{`$[1].testSelf()`, []byte(`{"id": 2, "data": "d29ybGQ="}`)},
+		{`$[?(keys(testSelf()) contains "data")].id`, []byte(`[1,2]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	for _, name := range []string{"length", "in", "testSelf", "bad name", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterFunction(%q): panic expected", name)
+				}
+			}()
+			RegisterFunction(name, func(input []byte, args ...[]byte) ([]byte, error) { return nil, nil })
+		}()
+	}
+}
@@ -0,0 +1,30 @@
+package jsonslice_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/bhmj/jsonslice"
+	"github.com/bhmj/jsonslice/jsonslicetest"
+)
+
+var exportSeeds = flag.String("export-seeds", "", "write the FuzzGet seed corpus into `dir` (testdata/fuzz/FuzzGet)")
+
+// go test -run Test_ExportSeeds -export-seeds testdata/fuzz/FuzzGet
+func Test_ExportSeeds(t *testing.T) {
+	dir := *exportSeeds
+	if dir == "" {
+		dir = t.TempDir()
+	}
+	for _, seed := range jsonslice.SeedCorpus() {
+		name, err := jsonslicetest.WriteCorpusFile(dir, seed.Input, seed.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, path, err := jsonslicetest.ReadCorpusFile(name)
+		if err != nil || !bytes.Equal(input, seed.Input) || path != seed.Path {
+			t.Errorf("%s: round trip of %q failed: got %q (%v)", name, seed.Path, path, err)
+		}
+	}
+}
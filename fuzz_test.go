@@ -0,0 +1,59 @@
+package jsonslice
+
+import (
+	"bytes"
+	"testing"
+)
+
+// go test -fuzz=FuzzPath
+func FuzzPath(f *testing.F) {
+	for _, tst := range expressionTests() {
+		f.Add(tst.Query)
+	}
+	f.Add(`$.()`)
+	f.Add(`$..[?(@.a[-1:]..b)]['x',"y"][1:-1:2]`)
+
+	f.Fuzz(func(t *testing.T, path string) {
+		if len(path) == 0 || path[0] != '$' {
+			return
+		}
+		node, _, _ := readRef([]byte(path), 1, 0)
+		repool(node)
+	})
+}
+
+// Seed is an entry of the FuzzGet seed corpus
+type Seed struct {
+	Input []byte
+	Path  string
+}
+
+// SeedCorpus returns the seed corpus of FuzzGet derived from the test suite
+// (exported for the corpus export in fuzz_export_test.go)
+func SeedCorpus() []Seed {
+	var seeds []Seed
+	for _, tst := range expressionTests() {
+		seeds = append(seeds, Seed{data, tst.Query})
+	}
+	return append(seeds,
+		Seed{differentTypes, `$[?(@.key == 1)]`},
+		Seed{[]byte(`{"some": {"value": [1, "2", null]}}`), `$.some.value`},
+		Seed{[]byte(`{"foo":"foo \\","bar":123}`), `$.foo`},
+		Seed{[]byte(`[{"id": 1}, {"id": "2"}]`), `$[?(@.id in [1, "2"] && in(@.id, $[*].id))]`},
+	)
+}
+
+// go test -fuzz=FuzzGet
+func FuzzGet(f *testing.F) {
+	for _, seed := range SeedCorpus() {
+		f.Add(seed.Input, seed.Path)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte, path string) {
+		prev := append(input[:0:0], input...)
+		_, _ = Get(input, path)
+		if !bytes.Equal(prev, input) {
+			t.Errorf("source json modified")
+		}
+	})
+}
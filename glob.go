@@ -0,0 +1,73 @@
+package jsonslice
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isPattern returns true if a path key is a glob pattern: b*, *_id, cpu_*_total.
+// A single '*' is a wildcard, not a pattern.
+func isPattern(key []byte) bool {
+	return len(key) > 1 && bytes.IndexByte(key, '*') >= 0
+}
+
+// patternEnd returns the end of an unquoted key pattern starting at path[i] (b*, *_id),
+// or i if there is no pattern
+func patternEnd(path []byte, i int) int {
+	e, stars := i, 0
+	for e < len(path) && (path[e] == '*' || !bytein(path[e], keyTerminator)) {
+		if path[e] == '*' {
+			stars++
+		}
+		e++
+	}
+	if stars == 0 || e-i < 2 {
+		return i
+	}
+	return e
+}
+
+// globMatch reports whether key matches pattern, where '*' matches any sequence of characters.
+// If fold is set characters are compared case-insensitively.
+func globMatch(pattern, key []byte, fold bool) bool {
+	p, k := 0, 0
+	star, next := -1, 0 // position of the last '*' in pattern, where it would continue in key
+	for k < len(key) {
+		if p < len(pattern) && pattern[p] == '*' {
+			star, next = p, k
+			p++
+			continue
+		}
+		if p < len(pattern) {
+			pr, ps := utf8.DecodeRune(pattern[p:])
+			kr, ks := utf8.DecodeRune(key[k:])
+			if pr == kr || fold && equalFoldRune(pr, kr) {
+				p += ps
+				k += ks
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		// let the last '*' take one more character
+		_, size := utf8.DecodeRune(key[next:])
+		next += size
+		p, k = star+1, next
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// equalFoldRune reports whether a and b are equal under Unicode simple case folding
+func equalFoldRune(a, b rune) bool {
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
+}
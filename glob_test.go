@@ -0,0 +1,81 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_KeyPatterns(t *testing.T) {
+
+	doc := []byte(`{"metrics":{"cpu_user":1,"cpu_sys":2,"mem":3,"CPU_idle":4,"net":{"cpu_irq":5}},"*":6,"a*b":7}`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.metrics.cpu_*`, []byte(`[1,2]`)},
+		{`$.metrics['cpu_*']`, []byte(`[1,2]`)},
+		{`$.metrics["cpu_*","mem"]`, []byte(`[1,2,3]`)},
+		{`$.metrics.*_sys`, []byte(`[2]`)},
+		{`$.metrics.c*u*r`, []byte(`[1]`)},
+		{`$.metrics.m**`, []byte(`[3]`)},
+		{`$.metrics.x*`, []byte(`[]`)},
+		{`$.metrics.n*.cpu_irq`, []byte(`[5]`)},
+		{`$..cpu_*`, []byte(`[1,2,5]`)},
+		{`$.metrics.cpu_*.sum()`, []byte(`3`)},
+		{`$.metrics.*`, []byte(`[1,2,3,4,{"cpu_irq":5}]`)}, // wildcard
+		{`$['*']`, []byte(`6`)},                            // a single star is not a pattern
+		{`$.a*b`, []byte(`[7]`)},                           // star matches itself too
+	}
+
+	for _, tst := range tests {
+		res, err := Get(doc, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// case-insensitive patterns
+	res, _ := GetWith(doc, `$.metrics.cpu_*`, WithCaseInsensitiveKeys())
+	if string(res) != `[1,2,4]` {
+		t.Errorf("$.metrics.cpu_*\n\texpected `[1,2,4]`\n\tbut got  `%s`", res)
+	}
+	// walk-based evaluation
+	res, _ = Set(doc, `$.metrics.cpu_*`, []byte(`0`))
+	if string(res) != `{"metrics":{"cpu_user":0,"cpu_sys":0,"mem":3,"CPU_idle":4,"net":{"cpu_irq":5}},"*":6,"a*b":7}` {
+		t.Errorf("Set $.metrics.cpu_*\n\tbut got  `%s`", res)
+	}
+	if _, err := Get(doc, `$.metrics['cpu_*':2]`); err == nil {
+		t.Errorf("$.metrics['cpu_*':2] : error expected")
+	}
+}
+
+func Test_GlobMatch(t *testing.T) {
+
+	tests := []struct {
+		Pattern string
+		Key     string
+		Fold    bool
+		Match   bool
+	}{
+		{"a*", "a", false, true},
+		{"a*", "abc", false, true},
+		{"a*", "ba", false, false},
+		{"*c", "abc", false, true},
+		{"a*c", "abcbc", false, true},
+		{"a*c", "abcb", false, false},
+		{"*b*", "abc", false, true},
+		{"a**b", "ab", false, true},
+		{"ä*", "äb", false, true},
+		{"*ö", "aö", false, true},
+		{"Ä*", "äb", false, false},
+		{"Ä*", "äb", true, true},
+		{"*K", "ak", true, true},
+	}
+
+	for _, tst := range tests {
+		if globMatch([]byte(tst.Pattern), []byte(tst.Key), tst.Fold) != tst.Match {
+			t.Errorf("%s ~ %s (fold %v)\n\texpected %v", tst.Pattern, tst.Key, tst.Fold, tst.Match)
+		}
+	}
+}
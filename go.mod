@@ -1,5 +1,5 @@
 module github.com/bhmj/jsonslice
 
-go 1.17
+go 1.18
 
 require github.com/bhmj/xpression v0.9.1
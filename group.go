@@ -0,0 +1,60 @@
+package jsonslice
+
+import "bytes"
+
+// groupBy(key) applied to an aggregated result groups the values by a key: $..book[*].groupBy(@.category)
+// is an object of arrays of books keyed by category, in the order the keys are first met.
+// groupBy(key, value) collects the values of an expression instead: $..book[*].groupBy(@.category, @.title).
+// A string key is used as is, other values as json text ("12", "true"); values without a key are skipped.
+
+// groupArray groups the elements of an array (the first argument of a call) by a key (the second one)
+func groupArray(input []byte, nod *tNode, call *tCall) ([]byte, error) {
+	if len(call.args) < 2 || len(call.args) > 3 {
+		return nil, errPathInvalidExpression
+	}
+	val, err := evalArg(input, nod, call.args[0])
+	if err != nil {
+		return nil, err
+	}
+	elems, ok := argArray(val)
+	if !ok {
+		return nil, nil
+	}
+	var keys [][]byte
+	groups := make(map[string][]byte)
+	for _, el := range elems {
+		key, err := evalArg(el, nod, call.args[1])
+		if err != nil {
+			return nil, err
+		}
+		key = bytes.TrimSpace(key)
+		if len(key) == 0 {
+			continue
+		}
+		if str, ok := argString(key); ok {
+			key = str
+		}
+		if len(call.args) == 3 {
+			if el, err = evalArg(el, nod, call.args[2]); err != nil {
+				return nil, err
+			}
+			if len(el) == 0 {
+				continue
+			}
+		}
+		group, ok := groups[string(key)]
+		if !ok {
+			keys = append(keys, key)
+		}
+		groups[string(key)] = plus(group, bytes.TrimSpace(el))
+	}
+	res := []byte{'{'}
+	for k, key := range keys {
+		if k > 0 {
+			res = append(res, ',')
+		}
+		res = append(jsonQuote(res, key), ':', '[')
+		res = append(append(res, groups[string(key)]...), ']')
+	}
+	return append(res, '}'), nil
+}
@@ -0,0 +1,32 @@
+package jsonslice
+
+import "testing"
+
+func Test_GroupBy(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"c": "ref", "t": "a", "p": 8}, {"c": "fic", "t": "b", "p": 12}, {"c": "fic", "t": "c", "p": 9}, {"c": "ref", "t": "d", "p": 22}, {"t": "e"}, {"c": 1, "t": "f"}, {"c": "a\"b", "t": "g"}]}, "tags": ["z", "x", "z"]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[?(@.p)].groupBy(@.c)`, []byte(`{"ref":[{"c": "ref", "t": "a", "p": 8},{"c": "ref", "t": "d", "p": 22}],"fic":[{"c": "fic", "t": "b", "p": 12},{"c": "fic", "t": "c", "p": 9}]}`)},
+		{`$..book[*].groupBy(@.c, @.t)`, []byte(`{"ref":["a","d"],"fic":["b","c"],"1":["f"],"a\"b":["g"]}`)},
+		{`$..book[*].groupBy(@.c, @.t).fic`, []byte(`["b","c"]`)},
+		{`$..book[*].groupBy(@.c, @.p).ref.sum()`, []byte(`30`)},
+		{`$..book[*].sort(@.p).groupBy(@.c, @.t).fic`, []byte(`["c","b"]`)},
+		{`$.store.book.groupBy(@.c).fic[0].t`, []byte(`"b"`)},
+		{`$.tags.groupBy(@)`, []byte(`{"z":["z","z"],"x":["x"]}`)},
+		{`$.store.book[?(@.c == "x")].groupBy(@.c)`, []byte(`{}`)},
+		{`$.store.groupBy(@.c)`, nil},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package jsonslice
+
+import "sync"
+
+// Iterator yields values matched by a path one by one:
+//
+//	it := jsonslice.Iterate(input, "$.store.book[*].title")
+//	for it.Next() {
+//		fmt.Println(string(it.Value()))
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Values are slices of the input and must not be modified.
+type Iterator struct {
+	input []byte
+	path  string
+	node  *tNode   // parsed path, nil until the first scan and after the last one
+	batch [][]byte // values found by the last scan
+	pos   int      // position in batch
+	seen  int      // number of values found by the previous scans
+	size  int      // batch size of the last scan
+	value []byte
+	err   error
+	done  bool
+}
+
+// Iterate returns an iterator over the values in input matched by path. Nothing is copied.
+// Matching is lazy: the input is scanned in batches of values, the first one holding a single value
+// and every next one twice as large. Each batch rescans the input from the start, so iterating over
+// all the values costs about twice a single scan, while stopping early saves the rest of it.
+func Iterate(input []byte, path string) *Iterator {
+	return &Iterator{input: input, path: path}
+}
+
+// Next advances the iterator to the next value. Returns false when there are no more values or an error occurred.
+func (it *Iterator) Next() bool {
+	if it.pos == len(it.batch) && !it.done {
+		it.scan()
+	}
+	if it.pos == len(it.batch) {
+		it.value = nil
+		return false
+	}
+	it.value = it.batch[it.pos]
+	it.pos++
+	return true
+}
+
+// scan finds the next batch of values, skipping the ones found by the previous scans
+func (it *Iterator) scan() {
+	if it.node == nil {
+		if it.node, it.err = parsePath(it.path); it.err != nil {
+			it.done = true
+			return
+		}
+		evalRootRefs(it.input, it.node)
+	}
+	it.seen += len(it.batch)
+	it.batch, it.pos = it.batch[:0], 0
+	if it.size *= 2; it.size == 0 {
+		it.size = 1
+	}
+	n := 0
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			if n++; n > it.seen {
+				it.batch = append(it.batch, it.input[m.start:m.end:m.end])
+			}
+			return len(it.batch) < it.size, nil
+		},
+	}
+	_, it.err = walkInput(it.input, it.node, w)
+	if it.err == errNotAddressable {
+		// function result: not a part of the input
+		it.batch = it.batch[:0]
+		var val []byte
+		if val, it.err = Get(it.input, it.path); it.err == nil && len(val) > 0 {
+			it.batch = append(it.batch, val)
+		}
+		it.done = true
+	}
+	if it.err != nil {
+		it.batch = it.batch[:0]
+	}
+	if it.done || it.err != nil || len(it.batch) < it.size {
+		it.done = true
+		repool(it.node)
+		it.node = nil
+	}
+}
+
+// Value returns the current value
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+// Err returns the error occurred during iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// ForEach calls fn for every value in input matched by path, in document order, without collecting the results.
+// Returning false from fn stops the iteration. Values are slices of the input and must not be modified.
+// A function result ($.a.length()) is passed to fn as a single value.
+func ForEach(input []byte, path string, fn func(value []byte) bool) error {
+	node, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	defer repool(node)
+	evalRootRefs(input, node)
+
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			return fn(input[m.start:m.end:m.end]), nil
+		},
+	}
+	_, err = walkInput(input, node, w)
+	if err == errNotAddressable {
+		val, err := Get(input, path)
+		if err == nil && len(val) > 0 {
+			fn(val)
+		}
+		return err
+	}
+	return err
+}
+
+// GetArrayElements returns every value in input matched by path as a separate slice of input
+// (the elements of an aggregated result, i.e. $.store.book[?(@.price < 10)]), in document order.
+// alloc is the expected number of values used to preallocate the result.
+// A function result ($.a.length()) is returned as a single element.
+func GetArrayElements(input []byte, path string, alloc int) ([][]byte, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	if err = checkFunctions(node, nil); err != nil {
+		return nil, err
+	}
+	evalRootRefs(input, node)
+
+	if alloc < 0 {
+		alloc = 0
+	}
+	elems := make([][]byte, 0, alloc)
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			elems = append(elems, input[m.start:m.end:m.end])
+			return true, nil
+		},
+	}
+	_, err = walkInput(input, node, w)
+	if err == errNotAddressable {
+		val, err := Get(input, path)
+		if len(val) == 0 || err != nil {
+			return nil, err
+		}
+		return append(elems, val), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return elems, nil
+}
+
+// Exists reports whether path matches anything in input. The scan stops at the first match, no result is built.
+func Exists(input []byte, path string) (bool, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return false, err
+	}
+	defer repool(node)
+	if err = checkFunctions(node, nil); err != nil {
+		return false, err
+	}
+	evalRootRefs(input, node)
+
+	found := false
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			found = true
+			return false, nil
+		},
+	}
+	_, err = walkInput(input, node, w)
+	if err == errNotAddressable {
+		val, err := Get(input, path)
+		return len(val) > 0, err
+	}
+	return found, err
+}
+
+// Count returns the number of values in input matched by path without building the result.
+// A function result ($.a.length()) counts as a single value.
+func Count(input []byte, path string) (int, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return 0, err
+	}
+	defer repool(node)
+	if err = checkFunctions(node, nil); err != nil {
+		return 0, err
+	}
+	evalRootRefs(input, node)
+
+	n := 0
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			n++
+			return true, nil
+		},
+	}
+	_, err = walkInput(input, node, w)
+	if err == errNotAddressable {
+		val, err := Get(input, path)
+		if len(val) == 0 {
+			return 0, err
+		}
+		return 1, err
+	}
+	return n, err
+}
+
+// GetBuf appends the result of Get(input, path) to dst and returns the extended buffer.
+// A path with a single aggregating selector ($.store.book[*].price, $..price, $.items[?(@.id > 5)])
+// is walked with the matched values copied into dst directly: there are no per-match allocations
+// and a dst with enough capacity is never reallocated. A filter of references and literals does not
+// allocate per value either, function calls in a filter ($.items[?(length(@.tags) > 2)]) do.
+// Other paths (nested aggregations, functions) are evaluated by Get and the result is appended to dst.
+func GetBuf(input []byte, path string, dst []byte) ([]byte, error) {
+	if len(path) == 1 && path[0] == '$' {
+		return append(dst, input...), nil
+	}
+	node, err := parsePath(path)
+	if err != nil {
+		return dst, err
+	}
+	defer repool(node)
+	if err = checkFunctions(node, nil); err != nil {
+		return dst, err
+	}
+	evalRootRefs(input, node)
+
+	if !flatPath(node) {
+		val, err := getResult(input, node)
+		if err != nil {
+			return dst, inputError(input, err)
+		}
+		return append(dst, val...), nil
+	}
+	b := bufWalkers.Get().(*tBufWalker)
+	defer bufWalkers.Put(b)
+	start := len(dst)
+	b.input, b.dst, b.first, b.w.matches = input, append(dst, '['), true, 0
+	_, err = walkInput(input, node, &b.w)
+	dst, b.input, b.dst = b.dst, nil, nil
+	if err != nil {
+		return dst[:start], err
+	}
+	return append(dst, ']'), nil
+}
+
+// tBufWalker appends the values matched by GetBuf to dst
+type tBufWalker struct {
+	w     tWalker
+	input []byte
+	dst   []byte
+	first bool
+}
+
+// bufWalkers keeps GetBuf walkers: a walker with its callback allocated once per pooled value
+var bufWalkers = sync.Pool{
+	New: func() interface{} {
+		b := &tBufWalker{}
+		b.w.match = func(m *tMatch) (bool, error) {
+			if !b.first {
+				b.dst = append(b.dst, ',')
+			}
+			b.first = false
+			b.dst = append(b.dst, b.input[m.start:m.end]...)
+			return true, nil
+		}
+		return b
+	},
+}
+
+// flatPath returns true if walk lists the values selected by the node list exactly as getValue does:
+// the path has one aggregating selector and it is not followed by a function, a multi-key selector etc.
+// Nested aggregations ($.a[*].b[*]), multiple keys and terminal slices (returned as a part of input) are not flat.
+func flatPath(node *tNode) bool {
+	aggs := 0
+	for n := node; n != nil; n = n.Next {
+		if n.Type&(cFunction|cName|cParent|cScript|cUnion|cGlob) > 0 || len(n.Keys) > 1 {
+			return false
+		}
+		if n.Type&(cAgg|cSlice|cDeep|cWild|cFilter) == 0 {
+			continue
+		}
+		aggs++
+		switch {
+		case n.Type&cSlice > 0 && n.Type&cFullScan == 0 && n.Next == nil:
+			return false
+		case n.Type&cDeep > 0 && n.Type&(cWild|cFilter) == 0 && len(n.Keys) == 0: // $..[1], $..[0:2]
+			return false
+		}
+	}
+	return aggs == 1
+}
@@ -0,0 +1,311 @@
+package jsonslice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Iterate(t *testing.T) {
+
+	input := []byte(`{"a": [1, {"b": 2}, "x"], "c": {"b": 3}}`)
+	tests := []struct {
+		Query    string
+		Expected []string
+	}{
+		{`$.a[*]`, []string{`1`, `{"b": 2}`, `"x"`}},
+		{`$..b`, []string{`2`, `3`}},
+		{`$.c`, []string{`{"b": 3}`}},
+		{`$.a.length()`, []string{`3`}},
+		{`$.z`, nil},
+		{`$..*`, []string{`[1, {"b": 2}, "x"]`, `1`, `{"b": 2}`, `2`, `"x"`, `{"b": 3}`, `3`}},
+	}
+
+	for _, tst := range tests {
+		var res []string
+		it := Iterate(input, tst.Query)
+		for it.Next() {
+			res = append(res, string(it.Value()))
+		}
+		if it.Err() != nil {
+			t.Errorf(tst.Query + " : " + it.Err().Error())
+			continue
+		}
+		if len(res) != len(tst.Expected) {
+			t.Errorf("%s\n\texpected %q\n\tbut got  %q", tst.Query, tst.Expected, res)
+			continue
+		}
+		for i := range res {
+			if res[i] != tst.Expected[i] {
+				t.Errorf("%s\n\texpected %q\n\tbut got  %q", tst.Query, tst.Expected, res)
+				break
+			}
+		}
+	}
+
+	// early abandonment
+	it := Iterate(input, `$.a[*]`)
+	if !it.Next() || string(it.Value()) != "1" {
+		t.Errorf("first value expected")
+	}
+
+	// matching is lazy: the malformed tail is not scanned until the values before it are consumed
+	it = Iterate([]byte(`{"x": 1, "y": 2, "z": 3, "t": {`), `$.*`)
+	if !it.Next() || string(it.Value()) != "1" || it.Err() != nil {
+		t.Errorf("first value expected")
+	}
+	for it.Next() {
+	}
+	if it.Err() == nil {
+		t.Errorf("error expected")
+	}
+
+	// error
+	it = Iterate(input, `$.a[`)
+	if it.Next() || it.Err() == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_ForEach(t *testing.T) {
+
+	input := []byte(`{"a": [1, {"b": 2}, "x"], "c": {"b": 3}}`)
+	tests := []struct {
+		Query    string
+		Expected []string
+	}{
+		{`$.a[*]`, []string{`1`, `{"b": 2}`, `"x"`}},
+		{`$.a[2,0]`, []string{`1`, `"x"`}},
+		{`$..b`, []string{`2`, `3`}},
+		{`$.a[?(@.b)]`, []string{`{"b": 2}`}},
+		{`$..[?(@.b)]`, []string{`{"b": 2}`, `{"b": 3}`}},
+		{`$.a.length()`, []string{`3`}},
+		{`$.z`, nil},
+	}
+
+	for _, tst := range tests {
+		var res []string
+		err := ForEach(input, tst.Query, func(value []byte) bool {
+			res = append(res, string(value))
+			return true
+		})
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(res, tst.Expected) {
+			t.Errorf("%s\n\texpected %q\n\tbut got  %q", tst.Query, tst.Expected, res)
+		}
+	}
+
+	// stop
+	n := 0
+	_ = ForEach(input, `$.a[*]`, func([]byte) bool { n++; return false })
+	if n != 1 {
+		t.Errorf("expected 1 call, got %d", n)
+	}
+
+	// error
+	if err := ForEach(input, `$.a[`, func([]byte) bool { return true }); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_PathExists(t *testing.T) {
+
+	input := []byte(`{"a": [1, {"b": 2}, "x"], "c": {"b": 3}, "e": [], "n": null}`)
+	tests := []struct {
+		Query    string
+		Expected bool
+	}{
+		{`$.a`, true},
+		{`$.a[1].b`, true},
+		{`$.a[5]`, false},
+		{`$.a[-1]`, true},
+		{`$..b`, true},
+		{`$..z`, false},
+		{`$.a[?(@.b > 2)]`, false},
+		{`$.a[?(@.b == 2)]`, true},
+		{`$.e[*]`, false},
+		{`$.n`, true},
+		{`$.a.length()`, true},
+		{`$.z.length()`, false},
+	}
+
+	for _, tst := range tests {
+		res, err := Exists(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if res != tst.Expected {
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v", tst.Query, tst.Expected, res)
+		}
+	}
+
+	// the rest of input is not scanned after the first match
+	if ok, err := Exists([]byte(`{"x": 1, "y": [`), `$.x`); !ok || err != nil {
+		t.Errorf("expected true, got %v (%v)", ok, err)
+	}
+
+	// error
+	if _, err := Exists(input, `$.a[`); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_Count(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"isbn": "1", "price": 8}, {"price": 12}, {"isbn": "2", "price": 22}], "bicycle": {"price": 19}}}`)
+	tests := []struct {
+		Query    string
+		Expected int
+	}{
+		{`$.store.book[*]`, 3},
+		{`$.store.book[?(@.isbn)]`, 2},
+		{`$.store.book[?(@.price > 100)]`, 0},
+		{`$..price`, 4},
+		{`$.store.*`, 2},
+		{`$.store.book[0:2].price`, 2},
+		{`$.store.book[0]`, 1},
+		{`$.store.book[0,0]`, 1},
+		{`$.store.book[2,0,2].price`, 2},
+		{`$.store.nope`, 0},
+		{`$.store.book.length()`, 1},
+	}
+
+	for _, tst := range tests {
+		res, err := Count(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if res != tst.Expected {
+			t.Errorf("%s\n\texpected %d\n\tbut got  %d", tst.Query, tst.Expected, res)
+		}
+	}
+
+	if _, err := Count(input, `$.store[`); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_GetArrayElements(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"title": "A", "price": 8}, {"title": "B", "price": 12}, {"title": "C", "price": 5}]}}`)
+	tests := []struct {
+		Query    string
+		Expected []string
+	}{
+		{`$.store.book[*]`, []string{`{"title": "A", "price": 8}`, `{"title": "B", "price": 12}`, `{"title": "C", "price": 5}`}},
+		{`$.store.book[?(@.price < 10)].title`, []string{`"A"`, `"C"`}},
+		{`$..price`, []string{`8`, `12`, `5`}},
+		{`$.store.book[1].title`, []string{`"B"`}},
+		{`$.store.book.length()`, []string{`3`}},
+		{`$.store.nope[*]`, []string{}},
+	}
+
+	for _, tst := range tests {
+		elems, err := GetArrayElements(input, tst.Query, len(tst.Expected))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		res := make([]string, 0, len(elems))
+		for _, el := range elems {
+			res = append(res, string(el))
+		}
+		if !reflect.DeepEqual(res, tst.Expected) {
+			t.Errorf("%s\n\texpected %q\n\tbut got  %q", tst.Query, tst.Expected, res)
+		}
+	}
+
+	if _, err := GetArrayElements(input, `$.store[`, 0); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_GetBuf(t *testing.T) {
+
+	input := []byte(`{"a": [1, {"b": 2}, "x"], "c": {"b": 3}, "d": [5, 6, 7]}`)
+	tests := []struct {
+		Query    string
+		Expected string
+	}{
+		{`$.a[*]`, `prefix:[1,{"b": 2},"x"]`},
+		{`$..b`, `prefix:[2,3]`},
+		{`$.d[?(@ > 5)]`, `prefix:[6,7]`},
+		{`$.d[5:]`, `prefix:[]`},
+		{`$.c.b`, `prefix:3`},
+		{`$.z`, `prefix:`},
+		{`$.d.length()`, `prefix:3`},
+		{`$.d[*].sum()`, `prefix:18`},
+		{`$`, `prefix:` + string(input)},
+		{`$.d[1,0]`, `prefix:[5,6]`},
+		{`$.d[0,0]`, `prefix:[5]`},
+		{`$.d[0:2]`, `prefix:[5, 6]`},
+		{`$[*][*]`, `prefix:[1,{"b": 2},"x",3,5,6,7]`},
+	}
+
+	for _, tst := range tests {
+		res, err := GetBuf(input, tst.Query, []byte("prefix:"))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if string(res) != tst.Expected {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// same as Get
+	same := func(input []byte, path string) {
+		expected, err := Get(input, path)
+		res, err2 := GetBuf(input, path, []byte("prefix:"))
+		if (err == nil) != (err2 == nil) {
+			t.Errorf("%s : %v vs %v", path, err, err2)
+		} else if err == nil && string(res) != "prefix:"+string(expected) {
+			t.Errorf("%s\n\texpected `prefix:%s`\n\tbut got  `%s`", path, expected, res)
+		}
+	}
+	nested := []byte(`{"a": [{"b": 1, "c": [1, 2]}, {"b": 2, "c": [3]}], "d": {"c": [5, 6]}}`)
+	for _, path := range []string{`$.a[*].c[*]`, `$..c[:]`, `$.a[1,0].b`, `$..*`, `$..c[0]`} {
+		same(nested, path)
+	}
+	for _, tst := range expressionTests() {
+		same(data, tst.Query)
+	}
+
+	if res, err := GetBuf(input, `$.a[`, []byte("prefix:")); err == nil || string(res) != "prefix:" {
+		t.Errorf("error expected and dst kept intact, got `%s`", res)
+	}
+
+	// the number of allocations does not depend on the number of matches
+	// (give or take pooled buffers dropped by the race detector)
+	small := []byte(`[1,2,3]`)
+	large := []byte(`[` + strings.Repeat(`1,`, 1000) + `1]`)
+	buf := make([]byte, 0, 4096)
+	a := testing.AllocsPerRun(100, func() { buf, _ = GetBuf(small, `$[*]`, buf[:0]) })
+	b := testing.AllocsPerRun(100, func() { buf, _ = GetBuf(large, `$[*]`, buf[:0]) })
+	if b > a+10 {
+		t.Errorf("allocations: %v for 3 matches, %v for 1001 matches", a, b)
+	}
+
+	// nor on the number of values filtered by references and literals
+	items := func(n int) []byte {
+		return []byte(`{"items": [` + strings.Repeat(`{"id": 7, "name": "x"},`, n-1) + `{"id": 3}]}`)
+	}
+	small, large = items(8), items(1000)
+	for _, path := range []string{`$.items[?(@.id > 5)]`, `$.items[?(@.id > 5 && @.name == "x")].id`, `$.items[?(@)]`} {
+		a = testing.AllocsPerRun(100, func() { buf, _ = GetBuf(small, path, buf[:0]) })
+		b = testing.AllocsPerRun(100, func() { buf, _ = GetBuf(large, path, buf[:0]) })
+		if b != a && !raceEnabled {
+			t.Errorf("%s allocations: %v for 8 values, %v for 1000 values", path, a, b)
+		}
+	}
+}
+
+func Benchmark_Jsonslice_ForEach_10Mb(b *testing.B) {
+	b.StopTimer()
+	largeData := GenerateLargeData()
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ForEach(largeData, "$.store.book[*].price", func([]byte) bool { return true })
+	}
+}
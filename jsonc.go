@@ -0,0 +1,67 @@
+package jsonslice
+
+// WithJSONC allows comments (// line and /* block */) and trailing commas in the input,
+// e.g. VS Code settings or tsconfig.json files. They are replaced with spaces in a copy of the input
+// before the evaluation, so the result is valid json and the offsets (see WithOffsets) match the input.
+func WithJSONC() Option {
+	return func(ctx *tContext) {
+		ctx.jsonc = true
+	}
+}
+
+// stripJSONC returns the input with comments and trailing commas replaced with spaces (line breaks are kept).
+// The input is returned as is if there are none.
+func stripJSONC(input []byte) []byte {
+	res := input
+	copied := false
+	blank := func(s, e int) {
+		if !copied {
+			res, copied = append([]byte(nil), input...), true
+		}
+		for ; s < e; s++ {
+			if res[s] != '\n' && res[s] != '\r' {
+				res[s] = ' '
+			}
+		}
+	}
+	comma := -1 // last comma outside of a string
+	l := len(input)
+	for i := 0; i < l; i++ {
+		switch ch := input[i]; {
+		case ch == '"':
+			e, err := skipString(input, i)
+			if err != nil {
+				return res // left to the scanner to report
+			}
+			i = e - 1
+			comma = -1
+		case ch == '/' && i+1 < l && input[i+1] == '/':
+			e := i + 2
+			for e < l && input[e] != '\n' {
+				e++
+			}
+			blank(i, e)
+			i = e - 1
+		case ch == '/' && i+1 < l && input[i+1] == '*':
+			e := i + 2
+			for e < l && !(input[e] == '/' && input[e-1] == '*' && e > i+2) {
+				e++
+			}
+			if e < l {
+				e++
+			}
+			blank(i, e)
+			i = e - 1
+		case ch == ',':
+			comma = i
+		case ch == '}' || ch == ']':
+			if comma >= 0 {
+				blank(comma, comma+1)
+			}
+			comma = -1
+		case ch != ' ' && ch != '\t' && ch != '\r' && ch != '\n':
+			comma = -1
+		}
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_JSONC(t *testing.T) {
+
+	input := []byte(`// settings
+{
+	"editor.fontSize": 14, // px
+	/* "editor.tabSize": 2, */
+	"files.exclude": {
+		"**/.git": true,
+		"**/*.tmp": true, /* trailing */
+	},
+	"url": "http://example.com/*not a comment*/",
+	"list": [1, 2, 3,],
+}
+`)
+	tests := []struct {
+		Query    string
+		Expected string
+	}{
+		{`$['editor.fontSize']`, `14`},
+		{`$['editor.tabSize']`, ``},
+		{`$['files.exclude']`, "{\n\t\t\"**/.git\": true,\n\t\t\"**/*.tmp\": true                \n\t}"},
+		{`$.url`, `"http://example.com/*not a comment*/"`},
+		{`$.list`, `[1, 2, 3 ]`},
+		{`$.list[-1]`, `3`},
+		{`$.list.length()`, `3`},
+		{`$['files.exclude'].*`, `[true,true]`},
+	}
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithJSONC())
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if string(res) != tst.Expected {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// the input is not modified, offsets match it
+	var offsets [][2]int
+	res, err := GetWith(input, `$.list`, WithJSONC(), WithOffsets(&offsets), WithCompact())
+	if err != nil || string(res) != `[1,2,3]` || len(offsets) != 1 || string(input[offsets[0][0]:offsets[0][1]]) != `[1, 2, 3,]` {
+		t.Errorf("$.list: unexpected result `%s` %v %v", res, offsets, err)
+	}
+	if stripped := stripJSONC([]byte(`{"a": [1, 2]}`)); string(stripped) != `{"a": [1, 2]}` {
+		t.Errorf("no comments: unexpected `%s`", stripped)
+	}
+
+	// errors
+	if _, err = GetWith([]byte(`{"a": 1 /* unclosed`), `$.b`, WithJSONC()); err == nil {
+		t.Errorf("unclosed comment: error expected")
+	}
+}
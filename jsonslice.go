@@ -26,6 +26,7 @@ var (
 	errPathRootExpected,
 	errPathUnexpectedEnd,
 	errPathUnknownEscape,
+	errPathInvalidSurrogate,
 	errPathUnknownFunction,
 	errFieldNotFound,
 	errColonExpected,
@@ -33,7 +34,23 @@ var (
 	errUnexpectedEnd,
 	errInvalidLengthUsage,
 	errUnexpectedStringEnd,
-	errObjectOrArrayExpected error
+	errObjectOrArrayExpected,
+	errArrayExpected,
+	errNotAddressable,
+	errNotLocated,
+	errPathNotCreatable,
+	errRootNotDeletable,
+	errInvalidValue,
+	errTypeMismatch,
+	errFilterEvaluation,
+	errPathInvalidExpression,
+	errFunctionDisabled,
+	errTemplateUnclosed,
+	errDecodeUnsupported,
+	errDecodeInvalidKey,
+	errDecodeTrailingData,
+	errDecodeUnexpectedBreak,
+	errDecodeTooDeep error
 )
 
 func init() {
@@ -51,6 +68,7 @@ func init() {
 	errPathRootExpected = errors.New("path: $ expected")
 	errPathUnexpectedEnd = errors.New("path: unexpected end of path")
 	errPathUnknownEscape = errors.New("path: unknown escape")
+	errPathInvalidSurrogate = errors.New("path: unpaired surrogate in unicode escape")
 	errPathUnknownFunction = errors.New("path: unknown function")
 	errFieldNotFound = errors.New(`field not found`)
 	errColonExpected = errors.New("':' expected")
@@ -58,7 +76,23 @@ func init() {
 	errUnexpectedEnd = errors.New("unexpected end of input")
 	errInvalidLengthUsage = errors.New("length() is only applicable to array or string")
 	errObjectOrArrayExpected = errors.New("object or array expected")
+	errArrayExpected = errors.New("array expected")
 	errUnexpectedStringEnd = errors.New("unexpected end of string")
+	errNotAddressable = errors.New("path: function result is not addressable")
+	errNotLocated = errors.New("result values not located in input")
+	errPathNotCreatable = errors.New("path: cannot create non-singular node")
+	errRootNotDeletable = errors.New("path: cannot delete root")
+	errInvalidValue = errors.New("invalid json value")
+	errTypeMismatch = errors.New("value type mismatch")
+	errFilterEvaluation = errors.New("filter evaluation failed")
+	errPathInvalidExpression = errors.New("path: invalid expression")
+	errFunctionDisabled = errors.New("function is disabled")
+	errTemplateUnclosed = errors.New("template: unclosed placeholder")
+	errDecodeUnsupported = errors.New("unsupported value")
+	errDecodeInvalidKey = errors.New("map key is not a string or a number")
+	errDecodeTrailingData = errors.New("unexpected data after the value")
+	errDecodeUnexpectedBreak = errors.New("unexpected break")
+	errDecodeTooDeep = errors.New("values nested too deep")
 }
 
 type word []byte
@@ -72,11 +106,22 @@ const (
 	cFilter   = 1 << iota // 32 filter
 	cWild     = 1 << iota // 64 wildcard (*)
 	cDeep     = 1 << iota // 128 deepscan (..)
+	cScript   = 1 << iota // 256 script expression [(...)]
+	cGlob     = 1 << iota // 512 key pattern (b*, ['cpu_*'])
+	cUnion    = 1 << iota // 1024 selectors of different kinds [0,'a',1:3]
+	cParent   = 1 << iota // 2048 parent (.^, .parent())
+	cName     = 1 << iota // 4096 member names of the values (~)
+	cLocate   = 1 << iota // 8192 records the source of the values (see WithOffsets)
 
 	cEmpty = 1 << 29 // empty number
 	cNAN   = 1 << 30 // not-a-number
 )
 
+// tNode is a parsed path segment. Evaluation keeps its state in the nodes, so a node list belongs
+// to a single evaluation at a time: filter tokens receive intermediate results (see xpression.Evaluate),
+// calls and arguments cache root references (see evalRootRefs), ctx, root and pos are set per evaluation
+// and the list is split temporarily to evaluate its head (see getResult, getPage).
+// Concurrent evaluations of the same path use separate copies of the node list (see Path, cloneNodes).
 type tNode struct {
 	//Key    word
 	Keys   []word
@@ -85,18 +130,36 @@ type tNode struct {
 	Elems  []int
 	Next   *tNode
 	Filter []*xpression.Token
+	Calls  []*tCall          // function calls, word operators and literals of the filter
+	Src    word              // source text of the node
+	Union  []*tNode          // selectors of a union, followed by Next (see readUnion)
+	Index  bool              // unquoted integer index(es) [2], [-1], [0,2], first(): may select object members by position
+	Ext    Extension         // syntax extensions used by the node
+	ctx    *tContext         // evaluation context (options, counters), nil for plain Get
+	root   []byte            // the document root-based references ($) of filters refer to (see evalRootRefs)
+	pos    int               // index of the value being filtered (see filterMatch)
+	refs   map[string][]byte // paths of the current value references of the filter: @.a is $.a (see refPaths)
 }
 
 func getEmptyNode() *tNode {
 	nod := nodePool.Get().(*tNode)
 	nod.Elems = nod.Elems[:0]
 	nod.Filter = nil
+	nod.Calls = nil
 	nod.Keys = nod.Keys[:0]
 	nod.Slice[0] = cEmpty
 	nod.Slice[1] = cEmpty
 	nod.Slice[2] = 1
 	nod.Next = nil
 	nod.Type = 0
+	nod.Src = nil
+	nod.Union = nod.Union[:0]
+	nod.Index = false
+	nod.Ext = 0
+	nod.ctx = nil
+	nod.root = nil
+	nod.pos = -1
+	nod.refs = nil
 	return nod
 }
 
@@ -105,15 +168,144 @@ func getEmptyNode() *tNode {
 //  1. simple case: the result is a simple subslice of a source input.
 //  2. the result is a merge of several non-contiguous parts of input. More allocations are needed.
 func Get(input []byte, path string) ([]byte, error) {
+	return get(input, path, nil)
+}
 
-	if len(path) == 0 {
-		return nil, errPathEmpty
-	}
+func get(input []byte, path string, ctx *tContext) ([]byte, error) {
 
 	if len(path) == 1 && path[0] == '$' {
+		ctx.locateRoot(input)
 		return input, nil
 	}
 
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return getParsed(input, node, ctx)
+}
+
+// getRef evaluates an unspaced root-based path made of a filter reference (see refPaths) on input.
+// Unlike get it does not copy the path.
+func getRef(input []byte, path []byte, ctx *tContext) ([]byte, error) {
+	if len(path) == 1 {
+		ctx.locateRoot(input)
+		return input, nil
+	}
+	node, i, err := readRef(path, 1, 0)
+	if err != nil {
+		repool(node)
+		return nil, pathError(string(path), i, err)
+	}
+	return getParsed(input, node, ctx)
+}
+
+// getParsed evaluates a parsed path on input and releases the nodes
+func getParsed(input []byte, node *tNode, ctx *tContext) ([]byte, error) {
+	var err error
+	if ctx != nil && ctx.policy != nil {
+		nodes := 0
+		if err = ctx.policy.check(node, &nodes); err != nil {
+			repool(node)
+			return nil, err
+		}
+	}
+	if err = ctx.restrict(node); err != nil {
+		repool(node)
+		return nil, err
+	}
+
+	result, err := evaluate(input, node, ctx)
+	repool(node)
+	return result, err
+}
+
+// evaluate evaluates parsed node list on input
+func evaluate(input []byte, node *tNode, ctx *tContext) ([]byte, error) {
+	if ctx != nil {
+		for n := node; n != nil; n = n.Next {
+			n.ctx = ctx
+			for _, part := range n.Union {
+				part.ctx = ctx
+			}
+			ctx.emit(DebugParsed, n, nil, false)
+			ctx.aggregating = ctx.aggregating || n.Type&(cAgg|cSlice|cDeep|cWild|cFilter|cUnion) > 0 ||
+				ctx.duplicates == DuplicateAll && singular(n)
+		}
+	}
+	if err := checkFunctions(node, ctx); err != nil {
+		return nil, err
+	}
+	ctx.locate(input, node)
+	evalRootRefs(input, node)
+
+	result, err := getResult(input, node)
+	if err != nil {
+		return nil, inputError(input, err)
+	}
+	return result, ctx.located(result)
+}
+
+// getResult evaluates the node list on input. A trailing aggregate function without arguments
+// ($.store.book[*].price.sum()) is applied to the whole result rather than to every value,
+// so are limit() and offset() (see getPage) and array functions: sort(), groupBy() (see getArrayResult).
+func getResult(input []byte, node *tNode) ([]byte, error) {
+	if prev, start := arrayStart(node); start != nil {
+		return getArrayResult(input, node, prev, start)
+	}
+	if prev, start := pageStart(node); start != nil {
+		return getPage(input, node, prev, start)
+	}
+	var prev *tNode
+	last := node
+	for last != nil && last.Next != nil {
+		prev, last = last, last.Next
+	}
+	if last == nil || len(last.Calls) == 0 {
+		return getNodes(input, node)
+	}
+	call := last.Calls[len(last.Calls)-1]
+	if !aggregateFunctions[call.name] || len(call.args) > 0 {
+		return getNodes(input, node)
+	}
+	var (
+		res []byte
+		err error
+	)
+	if prev == nil {
+		res, err = getValue(input, nil, false)
+	} else {
+		prev.Next = nil
+		res, err = getResult(input, node) // $..price.limit(3).sum()
+		prev.Next = last
+	}
+	if err != nil || len(res) == 0 {
+		return nil, err
+	}
+	return call.fn(last.ctx, [][]byte{res})
+}
+
+// getNodes evaluates the node list on input
+func getNodes(input []byte, node *tNode) ([]byte, error) {
+	if hasNode(node, cName) {
+		return getNames(input, node)
+	}
+	if hasNode(node, cParent) {
+		return getParents(input, node)
+	}
+	return getValue(input, node, false)
+}
+
+// parsePath checks path prefix and reads the list of nodes.
+// Returns nil node for the root ($) itself.
+func parsePath(path string) (*tNode, error) {
+	if len(path) == 0 {
+		return nil, errPathEmpty
+	}
+
+	if isPathExpression([]byte(path)) {
+		return parsePathExpression(path)
+	}
 	if path[0] != '$' {
 		return nil, errPathRootExpected
 	}
@@ -121,35 +313,71 @@ func Get(input []byte, path string) ([]byte, error) {
 	node, i, err := readRef(unspace([]byte(path)), 1, 0)
 	if err != nil {
 		repool(node)
-		return nil, errors.New(err.Error() + " at " + strconv.Itoa(i))
+		return nil, pathError(path, i, err)
 	}
+	return node, nil
+}
 
-	n := node
-	for {
-		if n == nil {
-			break
+// evalRootRefs evaluates root-based references ($...) found in filters of the node list.
+// In a nested filter ($[?(@.books[?(@.price > $.max)])]) they refer to the document rather than to input.
+func evalRootRefs(input []byte, node *tNode) {
+	if node != nil && node.ctx != nil && node.ctx.root != nil {
+		input = node.ctx.root
+	}
+	for n := node; n != nil; n = n.Next {
+		n.root = input
+		evalNodeRootRefs(input, n)
+		for _, part := range n.Union {
+			part.root = input
+			evalNodeRootRefs(input, part)
 		}
-		if n.Filter != nil {
-			for i, tok := range n.Filter {
-				if tok.Type == xpression.VariableOperand && tok.Operand.Str[0] == '$' {
-					// every variable has an empty token right after it for storing the result
-					result := n.Filter[i+1]
-					// evaluate root-based reference
-					val, err := Get(input, string(tok.Operand.Str))
-					if err != nil {
-						// not found or other error
-						result.Type = xpression.UndefinedOperand
-					}
-					_ = decodeValue(val, &result.Operand)
-				}
+	}
+}
+
+// evalNodeRootRefs evaluates root-based references found in the filter and calls of a single node
+func evalNodeRootRefs(input []byte, n *tNode) {
+	evalRootTokens(input, n.ctx, n.Filter)
+	for _, call := range n.Calls {
+		constant := aggregateFunctions[call.name]
+		for _, arg := range call.args {
+			if len(arg.ref) > 0 && arg.ref[0] == '$' {
+				arg.root = n.ctx.rootRef(input, arg.ref)
+			} else {
+				constant = false
 			}
+			evalRootTokens(input, n.ctx, arg.toks)
+		}
+		call.once = false
+		if constant {
+			evalOnce(n.ctx, call)
 		}
-		n = n.Next
 	}
+}
 
-	result, err := getValue(input, node, false)
-	repool(node)
-	return result, err
+// evalOnce evaluates a call having root-based arguments only
+func evalOnce(ctx *tContext, call *tCall) {
+	args := make([][]byte, len(call.args))
+	for i, arg := range call.args {
+		args[i] = arg.root
+	}
+	val, err := call.fn(ctx, args)
+	call.value, call.once = val, err == nil
+}
+
+// evalRootTokens evaluates root-based references ($...) found in the expression tokens
+func evalRootTokens(input []byte, ctx *tContext, toks []*xpression.Token) {
+	for i, tok := range toks {
+		if tok.Type == xpression.VariableOperand && tok.Operand.Str[0] == '$' {
+			// every variable has an empty token right after it for storing the result
+			result := toks[i+1]
+			// evaluate root-based reference
+			val := ctx.rootRef(input, tok.Operand.Str)
+			if len(val) == 0 || emptyNodeList(tok.Operand.Str, val) || decodeValue(val, &result.Operand) != nil {
+				// not found or other error
+				result.Operand.SetUndefined()
+			}
+		}
+	}
 }
 
 // returns true if b matches one of the elements of seq
@@ -164,7 +392,7 @@ func bytein(b byte, seq []byte) bool {
 
 var pathTerminator = []byte{' ', '\t', '<', '=', '>', '+', '-', '*', '/', ')', '&', '|', '!', '^'}
 
-var keyTerminator = []byte{' ', '\t', ':', '.', ',', '[', '(', ')', ']', '<', '=', '>', '+', '-', '*', '/', '&', '|', '!'}
+var keyTerminator = []byte{' ', '\t', ':', '.', ',', '[', '(', ')', ']', '<', '=', '>', '+', '-', '*', '/', '&', '|', '!', '~'}
 
 // readRef recursively reads input path until EOL or path terminator encountered.
 // Returns single-linked list of nodes, end position or error.
@@ -185,6 +413,22 @@ func readRef(path []byte, i int, uptype int) (*tNode, int, error) {
 		return nil, i, nil
 	}
 
+	if path[i] == '~' {
+		// member names: $.store.*~, the last node of the path
+		if i+1 < len(path) {
+			return nil, i + 1, errPathInvalidChar
+		}
+		nod := getEmptyNode()
+		nod.Type = cName
+		nod.Src = path[i:]
+		return nod, i + 1, nil
+	}
+
+	if path[i] == '{' {
+		// projection: $.book[*]{"name": @.title}
+		return readProjection(path, i)
+	}
+
 	if !bytein(path[i], []byte{'.', '['}) {
 		// only dot and bracket notation allowed
 		return nil, i, errPathInvalidChar
@@ -192,6 +436,7 @@ func readRef(path []byte, i int, uptype int) (*tNode, int, error) {
 
 	nod := getEmptyNode()
 	l := len(path)
+	s := i
 	// [optional] dots
 	if path[i] == '.' {
 		nod.Type = cDot // simple dor notation
@@ -209,24 +454,70 @@ func readRef(path []byte, i int, uptype int) (*tNode, int, error) {
 
 	if path[i] == '[' {
 		// bracket notated
+		if nod.Type == cDot {
+			nod.Ext |= ExtDotBracket // .[]
+		}
 		i++
 		i, err = readBrackets(nod, path, i)
+		nod.Src = path[s:i]
 		if i == l || err != nil {
 			return nod, i, err
 		}
 	} else {
 		// dot (or deepscan) notated
 		key, nod.Slice[0], sep, i, flags, _ = readKey(path, i)
+		if nod.Slice[0] != cNAN && nod.Slice[0] != cEmpty {
+			nod.Ext |= ExtDotIndex // $.a.2
+		}
 		if len(key) > 0 {
 			nod.Keys = append(nod.Keys, key)
 		}
-		nod.Type |= flags // cWild, cFullScan
+		// parent: .^, .parent()
+		if len(key) == 1 && key[0] == '^' || sep == '(' && i+1 < l && path[i+1] == ')' && bytes.Equal(key, []byte("parent")) {
+			if nod.Type&cDeep > 0 {
+				return nil, i, errPathInvalidChar
+			}
+			nod.Keys = nod.Keys[:0]
+			nod.Type = cParent
+			if sep == '(' {
+				i += 2
+			}
+			nod.Src = path[s:i]
+			next, i, err = readRef(path, i, nod.Type)
+			nod.Next = next
+			return nod, i, err
+		}
+		nod.Type |= flags // cWild, cFullScan, cGlob
+		if flags&cGlob > 0 {
+			nod.Type |= cAgg // $.b*: all the matching members
+		}
+		nod.Src = path[s:i]
 		if i == l {
 			return nod, i, nil
 		}
+		// first(), last(): same as [0], [-1]
+		if sep == '(' && i+1 < l && path[i+1] == ')' && (bytes.Equal(key, []byte("first")) || bytes.Equal(key, []byte("last"))) {
+			nod.Keys = nod.Keys[:0]
+			nod.Slice[0] = 0
+			nod.Index = true
+			if key[0] == 'l' {
+				nod.Slice[0] = -1
+				nod.Type |= cFullScan
+			}
+			i += 2
+			nod.Src = path[s:i]
+			sep = 0
+		}
 		// function
-		if sep == '(' && i+1 < l && path[i+1] == ')' {
+		if sep == '(' && ((i+1 < l && path[i+1] == ')') || isFunction(string(key))) {
 			_, i, err = detectFn(path, i, nod)
+			nod.Src = path[s:i]
+			if err != nil {
+				return nod, i, err
+			}
+			// the rest of the path is applied to the function result: $.now().format("unix")
+			next, i, err = readRef(path, i, nod.Type)
+			nod.Next = next
 			return nod, i, err
 		}
 	}
@@ -234,6 +525,9 @@ func readRef(path []byte, i int, uptype int) (*tNode, int, error) {
 	// recurse
 	next, i, err = readRef(path, i, nod.Type)
 	nod.Next = next
+	for _, part := range nod.Union {
+		part.Next = next
+	}
 	return nod, i, err
 }
 
@@ -249,12 +543,26 @@ func readBrackets(nod *tNode, path []byte, i int) (int, error) {
 		flags int
 	)
 	l := len(path)
-	if i < l-1 && path[i] == '?' && path[i+1] == '(' {
-		// ?(...): filter
-		return readFilter(path, i+2, nod)
+	if isUnion(path, i) {
+		// [0,'a',1:3,?(...)]: union
+		return readUnion(nod, path, i)
+	}
+	if i < l && path[i] == '?' {
+		// ?(...) or ?...: filter
+		return readFilter(path, i+1, nod)
+	}
+	if i < l && path[i] == '(' {
+		// (...): script expression
+		return readScript(path, i+1, nod)
 	}
+	index := true // unquoted integers only
 	for pos := 0; i < l && path[i] != ']'; pos++ {
+		quoted := path[i] == '\'' || path[i] == '"'
 		key, ikey, sep, i, flags, err = readKey(path, i)
+		index = index && !quoted && ikey != cNAN && ikey != cEmpty
+		if !quoted && ikey == cNAN {
+			nod.Ext |= ExtUnquotedKeys // [a]
+		}
 		nod.Type |= flags // cWild, cFullScan // CAUTION: [*,1,2] is possible
 		if err != nil {
 			return i, err
@@ -279,6 +587,13 @@ func readBrackets(nod *tNode, path []byte, i int) (int, error) {
 	if len(nod.Elems) > 0 {
 		nod.Type &^= cWild
 	}
+	nod.Index = index && nod.Type&(cSlice|cWild) == 0
+	if nod.Type&cGlob > 0 {
+		if nod.Type&cSlice > 0 {
+			return i, errPathInvalidChar
+		}
+		nod.Type |= cAgg | cDot // ['cpu_*']: all the matching members
+	}
 	i++ // ']'
 	return i, nil
 }
@@ -291,7 +606,7 @@ func readBrackets(nod *tNode, path []byte, i int) (int, error) {
 //	ikey  = integer converted key
 //	sep   = key list separator (expected , : [ ] . +-*/=! 0)
 //	i     = current i (on separator)
-//	flags = cWild if wildcard
+//	flags = cWild if wildcard, cGlob if key pattern
 //	err   = error
 func readKey(path []byte, i int) ([]byte, int, byte, int, int, error) {
 	l := len(path)
@@ -309,7 +624,11 @@ func readKey(path []byte, i int) ([]byte, int, byte, int, int, error) {
 		key, i, err = readQuotedKey(path, i)
 	} else {
 		// terminator bounded string
+		s := i
 		key, i, err = readTerminatorBounded(path, i, keyTerminator)
+		if e := patternEnd(path, s); e > i { // b*, *_id
+			key, i = path[s:e], e
+		}
 		if len(key) == 1 && key[0] == '*' {
 			flag = cWild
 			key = key[:0]
@@ -318,6 +637,9 @@ func readKey(path []byte, i int) ([]byte, int, byte, int, int, error) {
 	if err != nil {
 		return nil, 0, 0, i, 0, err
 	}
+	if isPattern(key) {
+		flag |= cGlob
+	}
 
 	if i < l {
 		bound = path[i]
@@ -381,14 +703,32 @@ func detectFn(path []byte, i int, nod *tNode) (bool, int, error) {
 	if len(nod.Keys) == 0 {
 		return true, i, errPathUnknownFunction
 	}
-	if !(bytes.EqualFold(nod.Keys[0], []byte("length")) ||
+	if i+1 < len(path) && path[i+1] == ')' && (bytes.EqualFold(nod.Keys[0], []byte("length")) ||
 		bytes.EqualFold(nod.Keys[0], []byte("count")) ||
-		bytes.EqualFold(nod.Keys[0], []byte("size"))) {
+		bytes.EqualFold(nod.Keys[0], []byte("size")) ||
+		bytes.Equal(nod.Keys[0], []byte("keys")) ||
+		bytes.Equal(nod.Keys[0], []byte("values")) ||
+		bytes.Equal(nod.Keys[0], []byte("type"))) {
+		nod.Type |= cFunction
+		nod.Type &^= cDot
+		return true, i + 2, nil
+	}
+	// filter function applied to the current value (@): $.user.sha256(@.email), $.uuid()
+	if !isFunction(string(nod.Keys[0])) {
 		return true, i, errPathUnknownFunction
 	}
+	r := &tRewriter{expr: path}
+	_, e, err := r.operand(i-len(nod.Keys[0]), len(path))
+	if err != nil {
+		return true, i, err
+	}
+	if call := r.calls[len(r.calls)-1]; methodCall(call) {
+		call.args = append([]*tArg{{ref: []byte("@")}}, call.args...)
+	}
+	nod.Calls = r.calls
 	nod.Type |= cFunction
 	nod.Type &^= cDot
-	return true, i + 2, nil
+	return true, e, nil
 }
 
 // getValue returns value specified by nod or nil if no match
@@ -398,8 +738,11 @@ func getValue(input []byte, nod *tNode, inside bool) (result []byte, err error)
 	if len(input) == 0 {
 		return nil, nil
 	}
-	if nod == nil {
+	if nod == nil || nod.Type == cLocate {
 		e, err := skipValue(input, 0)
+		if nod != nil && err == nil {
+			nod.ctx.locator.add(input[:e])
+		}
 		if !inside {
 			return input[:e], err
 		}
@@ -407,17 +750,29 @@ func getValue(input []byte, nod *tNode, inside bool) (result []byte, err error)
 	}
 	i, _ := skipSpaces(input, 0) // we're at the value
 	input = input[i:]
+	nod.ctx.segment(nod, input)
 
-	agg := nod.Type&(cAgg|cSlice|cDeep|cWild|cFilter) > 0
+	agg := nod.Type&(cAgg|cSlice|cDeep|cWild|cFilter|cUnion) > 0
 	switch {
+	case nod.Type&(cParent|cName) > 0: // .^ and ~ are resolved by walk (see getParents, getNames)
+		return nil, nil
+	case nod.Type&cUnion > 0: // [0,'a',1:3]
+		result, err = getValueUnion(input, nod) // recurse inside
+	case nod.Type&cScript > 0: // [(...)]
+		result, err = getValueScript(input, nod, inside) // recurse inside
+	case nod.Type&cFilter > 0: // [?(...)], $..[?(...)]
+		result, err = getValueFilter(input, nod, agg || inside) // no recurse
+	case input[0] == '{' && nod.ctx.objectIndexes() && positional(nod): // [0], [1:3] on objects (see WithObjectIndexes)
+		result, err = objectByIndex(input, nod, agg || inside) // recurse inside
 	case nod.Type&(cDot|cDeep) > 0: // single or multiple key
 		result, err = getValueDot(input, nod, agg || inside) // recurse inside
 	case nod.Type&cSlice > 0: // array slice [::]
 		result, err = getValueSlice(input, nod) // recurse inside
 	case nod.Type&cFunction > 0: // func()
-		result, err = doFunc(input, nod) // no recurse
-	case nod.Type&cFilter > 0: // [?(...)]
-		result, err = getValueFilter(input, nod, agg || inside) // no recurse
+		result, err = doFunc(input, nod)
+		if nod.Next != nil && len(result) > 0 && err == nil {
+			result, err = getValue(result, nod.Next, inside) // $.obj.keys().length()
+		}
 	default:
 		return nil, errFieldNotFound
 	}
@@ -468,17 +823,113 @@ func getValueFilter(input []byte, nod *tNode, inside bool) ([]byte, error) {
 	switch input[0] {
 	case '{':
 		if nod.Type&cDeep > 0 {
-			return objectDeep(input, nod) // (recurse inside) (+deep)
+			return objectDeepFilter(input, nod) // (recurse inside) (+deep)
 		}
-		return nil, nil
+		return objectValueByFilter(input, nod) // 1+ (recurse inside)
 	case '[':
+		if nod.Type&cDeep > 0 {
+			return arrayDeepFilter(input, nod) // (recurse inside) (+deep)
+		}
 		return arrayElemByFilter(input, nod, true) // 1+ (recurse inside)
 	default:
 		return nil, errObjectOrArrayExpected
 	}
 }
 
-// TODO: deep
+// descend continues deepscan inside a nested value unless the depth limit (WithMaxDepth) is reached
+func descend(input []byte, nod *tNode) ([]byte, error) {
+	ctx := nod.ctx
+	if err := ctx.cancelled(); err != nil {
+		return nil, err
+	}
+	if ctx == nil || ctx.maxDepth <= 0 {
+		return getValue(input, nod, true)
+	}
+	if ctx.depth+1 >= ctx.maxDepth {
+		return nil, nil
+	}
+	ctx.depth++
+	defer func() { ctx.depth-- }()
+	return getValue(input, nod, true)
+}
+
+// deepFilter returns the value (or the rest of the path applied to it) if it matches the filter
+// followed by the matching values found inside it: $..[?(@.isbn)]
+func deepFilter(val []byte, index int, nod *tNode, res []byte) ([]byte, error) {
+	b, err := filterMatch(val, nod, index)
+	if err != nil {
+		return nil, err
+	}
+	nod.ctx.emit(DebugFilter, nod, val, b)
+	if b {
+		sub, _ := getValue(val, nod.Next, true) // recurse
+		res = plus(res, sub)
+	} else {
+		nod.ctx.skipped(nod, val)
+	}
+	return res, nil
+}
+
+// object member values matching the filter at any depth, in document order
+func objectDeepFilter(input []byte, nod *tNode) (res []byte, err error) {
+	var (
+		s, e int
+		key  []byte
+		deep []byte
+	)
+	i := 1 // skip '{'
+	l := len(input)
+	for k := 0; i < l && input[i] != '}'; k++ {
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if res, err = deepFilter(input[s:e:e], k, nod, res); err != nil {
+			return nil, err
+		}
+		if input[s] == '{' || input[s] == '[' {
+			if deep, err = descend(input[s:e:e], nod); err != nil {
+				return nil, err
+			}
+			res = plus(res, deep)
+		}
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	return res, nil
+}
+
+// array elements matching the filter followed by the matching values found inside the elements
+func arrayDeepFilter(input []byte, nod *tNode) (res []byte, err error) {
+	var deep []byte
+	elems, err := arrayElems(input, 0)
+	if err != nil {
+		return nil, err
+	}
+	for k, el := range elems {
+		if res, err = deepFilter(input[el.start:el.end:el.end], k, nod, res); err != nil {
+			return nil, err
+		}
+	}
+	for _, el := range elems {
+		if ch := input[el.start]; ch == '{' || ch == '[' {
+			if deep, err = descend(input[el.start:el.end:el.end], nod); err != nil {
+				return nil, err
+			}
+			res = plus(res, deep)
+		}
+	}
+	return res, nil
+}
+
 func arrayElemByFilter(input []byte, nod *tNode, inside bool) (result []byte, err error) {
 	var s, e int
 	var b bool
@@ -486,22 +937,76 @@ func arrayElemByFilter(input []byte, nod *tNode, inside bool) (result []byte, er
 	i := 1 // skip '['
 	l := len(input)
 
-	for i < l && input[i] != ']' {
+	if nod.ctx != nil && nod.ctx.workers > 1 {
+		elems, err := arrayElems(input, 0)
+		if err != nil {
+			return nil, err
+		}
+		if workers := nod.ctx.parallel(len(elems)); workers > 1 {
+			return arrayElemByFilterParallel(input, nod, elems, workers, inside)
+		}
+	}
+
+	for k := 0; i < l && input[i] != ']'; k++ {
 		s, e, i, err = valuate(input, i)
 		if err != nil {
 			return nil, err
 		}
-		b, err = filterMatch(input[s:e], nod.Filter)
+		b, err = filterMatch(input[s:e], nod, k)
 		if err != nil {
 			return nil, err
 		}
+		nod.ctx.emit(DebugFilter, nod, input[s:e], b)
 		if b {
 			sub, err = getValue(input[s:e], nod.Next, inside) // recurse
 			if len(sub) > 0 {
 				result = plus(result, sub)
 			}
+		} else {
+			nod.ctx.skipped(nod, input[s:e])
+		}
+	}
+	return result, err
+}
+
+// object member values matching the filter: $.store[?(@.color)]
+func objectValueByFilter(input []byte, nod *tNode) (result []byte, err error) {
+	var s, e int
+	var b bool
+	var sub []byte
+	i := 1 // skip '{'
+	l := len(input)
+
+	for k := 0; i < l && input[i] != '}'; k++ {
+		var key []byte
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return nil, err
+		}
+		b, err = filterMatch(input[s:e], nod, k)
+		if err != nil {
+			return nil, err
+		}
+		nod.ctx.emit(DebugFilter, nod, input[s:e], b)
+		if b {
+			sub, err = getValue(input[s:e], nod.Next, true) // recurse
+			if len(sub) > 0 {
+				result = plus(result, sub)
+			}
+		} else {
+			nod.ctx.skipped(nod, input[s:e])
 		}
 	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
 	return result, err
 }
 
@@ -511,6 +1016,9 @@ func objectValueByKey(input []byte, nod *tNode, inside bool) ([]byte, error) {
 		err error
 		key []byte
 	)
+	if dup := nod.ctx.duplicateKeys(); dup != DuplicateFirst && singular(nod) {
+		return objectValueByDuplicateKey(input, nod, inside, dup)
+	}
 	i := 1 // skip '{'
 	l := len(input)
 	var res []byte
@@ -520,6 +1028,9 @@ func objectValueByKey(input []byte, nod *tNode, inside bool) ([]byte, error) {
 	}
 
 	for i < l && input[i] != '}' {
+		if err = nod.ctx.cancelled(); err != nil {
+			return nil, err
+		}
 		key, i, err = readObjectKey(input, i)
 		if err != nil {
 			return nil, err
@@ -562,7 +1073,7 @@ func objectDeep(input []byte, nod *tNode) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		deep, err = getValue(input[s:e], nod, true) // recurse
+		deep, err = descend(input[s:e], nod) // recurse
 		if err != nil {
 			return nil, err
 		}
@@ -643,8 +1154,13 @@ func arraySlice(input []byte, nod *tNode) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(elems) > 0 && nod.Type&cFullScan == 0 && nod.Next == nil {
+	if len(elems) > 0 && nod.Type&cFullScan == 0 && (nod.Next == nil || nod.Next.Type == cLocate) {
 		// 5.1)
+		if nod.Next != nil {
+			for _, el := range elems {
+				nod.ctx.locator.add(input[el.start:el.end])
+			}
+		}
 		return input[elems[0].start:elems[len(elems)-1].end], nil
 	}
 	return sliceRecurse(input, nod, elems)
@@ -655,8 +1171,8 @@ func arraySlice(input []byte, nod *tNode) ([]byte, error) {
 //	cases
 //	  1) $[2]    cDot: nod.Left (>0)     --> seek to elem
 //	  2) $[2,3]  cDot: nod.Elems (>0)    --> scan collecting elems
-//	  3) $[-3]   cDot: nod.Left (<0)     --> full scan (cFullScan)
-//	  4) $[2,-3] cDot: nod.Elems (<0)    --> full scan (cFullScan)
+//	  3) $[-3]   cDot: nod.Left (<0)     --> scan from the end (see tailElems), full scan (cFullScan) if not possible
+//	  4) $[2,-3] cDot: nod.Elems (<0)    --> full scan (cFullScan), from the end if all indexes are negative
 //	  5) $[1:3]  cSlice: Left < Right    --> scan up to right --> elems
 //	     5.1) terminal: return input[left:right]
 //	     5.2) non-term: iterate and recurse
@@ -668,6 +1184,12 @@ func arraySlice(input []byte, nod *tNode) ([]byte, error) {
 //	elem   - for a single index or cDeep
 //	elems  - for a list of indexes or cDeep
 func arrayIterateElems(input []byte, nod *tNode) (elems []tElem, elem []byte, err error) {
+	if n := tailCount(nod); n > 0 {
+		// 3) 4) 6) negative indexes only: scan from the end
+		if elems, ok := tailElems(input, n); ok {
+			return elems, nil, nil
+		}
+	}
 	var i, s, e int
 	l := len(input)
 	i = 1 // skip '['
@@ -705,11 +1227,138 @@ BFOR:
 			if nod.Type&cFullScan == 0 && len(elems) == len(nod.Elems) {
 				break // $[1,2,3] --> found them all
 			}
+		} else {
+			nod.ctx.skipped(nod, input[s:e])
 		}
 	}
 	return
 }
 
+// tailCount returns the number of the last array elements enough to evaluate the node
+// ($[-1], $[-3,-1], $[-2:], $[-3:-1]) or 0 if the whole array is needed
+func tailCount(nod *tNode) int {
+	if nod.Type&(cDeep|cWild) > 0 || nod.Type&cFullScan == 0 {
+		return 0
+	}
+	n := 0
+	switch {
+	case nod.Type&cSlice > 0:
+		if nod.Slice[0] >= 0 || nod.Slice[2] <= 0 || (nod.Slice[1] != cEmpty && nod.Slice[1] >= 0) {
+			return 0
+		}
+		n = -nod.Slice[0]
+	case len(nod.Elems) > 0:
+		for _, e := range nod.Elems {
+			if e >= 0 {
+				return 0
+			}
+			if -e > n {
+				n = -e
+			}
+		}
+	case nod.Slice[0] < 0:
+		n = -nod.Slice[0]
+	}
+	return n
+}
+
+// tailElems returns the bounds of the last n elements of an array (or all of them if there are less than n)
+// scanning input from the end. Returns false if input does not end with the array (or the array is malformed).
+func tailElems(input []byte, n int) ([]tElem, bool) {
+	e := len(input) - 1
+	for e >= 0 && bytein(input[e], []byte{' ', '\t', '\r', '\n'}) {
+		e--
+	}
+	if e < 0 || input[e] != ']' {
+		// the array is followed by the rest of the document: find its end first
+		end, err := skipValue(input, 0)
+		if err != nil {
+			return nil, false
+		}
+		e = end - 1
+	}
+	elems := make([]tElem, 0, n)
+	i := e - 1 // skip ']'
+	for len(elems) < n {
+		i = skipSpacesBack(input, i)
+		if i < 0 {
+			return nil, false
+		}
+		if input[i] == '[' {
+			break // the whole array
+		}
+		if len(elems) > 0 {
+			if input[i] != ',' {
+				return nil, false
+			}
+			i = skipSpacesBack(input, i-1)
+			if i < 0 {
+				return nil, false
+			}
+		}
+		s := valueStartBack(input, i)
+		if s < 0 {
+			return nil, false
+		}
+		elems = append(elems, tElem{s, i + 1})
+		i = s - 1
+	}
+	for a, b := 0, len(elems)-1; a < b; a, b = a+1, b-1 {
+		elems[a], elems[b] = elems[b], elems[a]
+	}
+	return elems, true
+}
+
+// skipSpacesBack returns the position of the last non-space character at or before i
+func skipSpacesBack(input []byte, i int) int {
+	for i >= 0 && bytein(input[i], []byte{' ', '\t', '\r', '\n'}) {
+		i--
+	}
+	return i
+}
+
+// valueStartBack returns the start of a value ending at i (inclusive) or -1 if not found
+func valueStartBack(input []byte, i int) int {
+	switch input[i] {
+	case '"':
+		return stringStartBack(input, i)
+	case '}', ']':
+		depth := 0
+		for ; i >= 0; i-- {
+			switch input[i] {
+			case '"':
+				if i = stringStartBack(input, i); i < 0 {
+					return -1
+				}
+			case '}', ']':
+				depth++
+			case '{', '[':
+				if depth--; depth == 0 {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	for i > 0 && !bytein(input[i-1], []byte{' ', '\t', '\r', '\n', ',', '['}) {
+		i--
+	}
+	if ch := input[i]; (ch < '0' || ch > '9') && !bytein(ch, []byte{'-', '.', 't', 'f', 'n'}) {
+		return -1 // not a number, boolean or null
+	}
+	return i
+}
+
+// stringStartBack returns the position of the opening quote of a string closed at i or -1 if not found
+func stringStartBack(input []byte, i int) int {
+	for i--; i >= 0; i-- {
+		if input[i] == '"' && !escaped(input, i) {
+			return i
+		}
+	}
+	return -1
+}
+
 // aggregate non-empty elems and possibly non-empty ret
 func collectRecurse(input []byte, nod *tNode, elems []tElem, res []byte, inside bool) ([]byte, error) {
 	var err error
@@ -780,6 +1429,9 @@ func sliceRecurse(input []byte, nod *tNode, elems []tElem) ([]byte, error) {
 func subSlice(input []byte, nod *tNode, elems []tElem, i int, res []byte, inside bool) ([]byte, error) {
 	var sub []byte
 	var err error
+	if err = nod.ctx.cancelled(); err != nil {
+		return nil, err
+	}
 	if nod.Type&(cWild|cDeep) != cDeep {
 		sub, err = getValue(input[elems[i].start:elems[i].end], nod.Next, inside)
 		if err != nil {
@@ -790,7 +1442,7 @@ func subSlice(input []byte, nod *tNode, elems []tElem, i int, res []byte, inside
 		}
 	}
 	if nod.Type&cDeep > 0 {
-		sub, _ = getValue(input[elems[i].start:elems[i].end], nod, true) // deepscan
+		sub, _ = descend(input[elems[i].start:elems[i].end], nod) // deepscan
 		if len(sub) > 0 {
 			res = plus(res, sub)
 		}
@@ -811,13 +1463,17 @@ func keyCheck(key []byte, input []byte, i int, nod *tNode, elems [][]byte, res [
 	}
 
 	b := i
-	if nod.Type&cWild > 0 {
+	if nod.Type&(cWild|cDeep) > 0 { // the value is matched against all the keys at once and descended into once: $..['a','b']
 		elems, res, i, err = processKey(nod, nil, key, input, i, elems, res, false) // TODO: make option to switch the last FALSE to "inside" (nested aggregation)
 	} else {
 		for ii := range nod.Keys {
 			elems, res, i, err = processKey(nod, nod.Keys[ii], key, input, i, elems, res, false) // TODO: make option to switch the last FALSE to "inside" (nested aggregation)
 		}
 	}
+	if err == errPathInvalidExpression || err == errFunctionDisabled || // invalid function arguments: $.tags.limit(-1)
+		err != nil && nod.ctx.cancelled() != nil {
+		return elems, res, i, err
+	}
 
 	if nod.Type&cDot > 0 && len(res) > 0 {
 		return elems, res, i, err
@@ -828,6 +1484,7 @@ func keyCheck(key []byte, input []byte, i int, nod *tNode, elems [][]byte, res [
 		if err != nil {
 			return elems, res, i, err
 		}
+		nod.ctx.skipped(nod, input[b:i])
 	}
 	i, err = skipSpaces(input, i)
 	return elems, res, i, err
@@ -847,11 +1504,11 @@ func processKey(
 	var deep []byte
 	var sub []byte
 	e := i
-	match := matchKeys(key, nodkey) || nod.Type&cWild > 0
+	match := matchKey(nod, nodkey, key)
 	if nod.Type&cDeep > 0 || match {
 		// key match
-		if nod.Type&cDeep == 0 { // $.a  $.a.x  $[a,b]  $.*
-			if len(nod.Keys) == 1 {
+		if nod.Type&cDeep == 0 { // $.a  $.a.x  $[a,b]  $.*  $.a*
+			if len(nod.Keys) == 1 && nod.Type&cGlob == 0 {
 				// $.a  $.a.x
 				res, err = getValue(input[i:], nod.Next, inside || nod.Type&cWild > 0) // recurse
 				return elems, res, i, err
@@ -892,7 +1549,7 @@ func processKey(
 					res = plus(res, sub)
 				}
 			}
-			deep, err = getValue(input[i:e:e], nod, true) // deepscan
+			deep, err = descend(input[i:e:e], nod) // deepscan
 			if err != nil {
 				return elems, res, i, err
 			}
@@ -905,6 +1562,22 @@ func processKey(
 	return elems, res, e, err
 }
 
+// matchKey returns true if the key matches nodkey, any of the node keys if nodkey is nil
+func matchKey(nod *tNode, nodkey []byte, key []byte) bool {
+	if nod.Type&cWild > 0 {
+		return true
+	}
+	if nodkey != nil {
+		return nod.ctx.keysEqual(key, nodkey, nod.Type&cGlob > 0)
+	}
+	for _, k := range nod.Keys {
+		if nod.ctx.keysEqual(key, k, nod.Type&cGlob > 0) {
+			return true
+		}
+	}
+	return false
+}
+
 func matchKeys(key []byte, nodkey []byte) bool {
 	a, b := 0, 0
 	la, lb := len(key), len(nodkey)
@@ -1062,7 +1735,8 @@ func skipNumber(input []byte, i int) int {
 	l := len(input)
 	for ; i < l; i++ {
 		ch := input[i]
-		if !((ch >= '0' && ch <= '9') || ch == '.' || ch == '-' || ch == 'E' || ch == 'e') {
+		if !((ch >= '0' && ch <= '9') || ch == '.' || ch == '-' || ch == 'E' || ch == 'e' ||
+			(ch == '+' && i > 0 && (input[i-1] == 'E' || input[i-1] == 'e'))) {
 			break
 		}
 	}
@@ -1095,6 +1769,19 @@ func matchSubslice(str, needle []byte) bool {
 func doFunc(input []byte, nod *tNode) ([]byte, error) {
 	var err error
 	var result int
+	if len(nod.Calls) > 0 {
+		// the outermost call is registered last
+		return evalCall(input, nod, nod.Calls[len(nod.Calls)-1])
+	}
+	if bytes.Equal(word("keys"), nod.Keys[0]) {
+		return fnKeys(nod.ctx, [][]byte{input})
+	}
+	if bytes.Equal(word("values"), nod.Keys[0]) {
+		return fnValues(nod.ctx, [][]byte{input})
+	}
+	if bytes.Equal(word("type"), nod.Keys[0]) {
+		return fnTypeOf(nod.ctx, [][]byte{input})
+	}
 	if bytes.Equal(word("size"), nod.Keys[0]) {
 		result, err = skipValue(input, 0)
 	} else if bytes.Equal(word("length"), nod.Keys[0]) || bytes.Equal(word("count"), nod.Keys[0]) {
@@ -1196,24 +1883,71 @@ func repool(node *tNode) {
 			break
 		}
 		p := node.Next
+		for _, part := range node.Union {
+			nodePool.Put(part)
+		}
 		nodePool.Put(node)
 		node = p
 	}
 }
 
+// unspace removes spaces and tabs outside of quoted strings.
+// Word operators inside parentheses (`?(@.id in $.ids)`) keep a single space on each side.
 func unspace(buf []byte) []byte {
+	return unspaceIndex(buf, nil)
+}
+
+// unspaceIndex is unspace which also stores the source position of every output byte in index (if not nil)
+func unspaceIndex(buf []byte, index []int) []byte {
 	r, w := 0, 0
 	bound := byte(0)
+	depth := 0
+	brackets := 0
+	var filters []int // bracket depths of filters without parentheses: [?@.id in $.ids]
 	for r < len(buf) {
 		if (buf[r] == '\'' || buf[r] == '"') && bound == 0 {
 			bound = buf[r]
 		} else if buf[r] == bound {
 			bound = 0
+		} else if bound == 0 && (buf[r] == '(' || buf[r] == '{') {
+			depth++
+		} else if bound == 0 && (buf[r] == ')' || buf[r] == '}') {
+			depth--
+		} else if bound == 0 && buf[r] == '[' {
+			brackets++
+		} else if bound == 0 && buf[r] == ']' {
+			if n := len(filters); n > 0 && filters[n-1] == brackets {
+				filters = filters[:n-1]
+				depth--
+			}
+			brackets--
+		} else if bound == 0 && buf[r] == '?' && w > 0 && (buf[w-1] == '[' || buf[w-1] == ',') {
+			filters = append(filters, brackets)
+			depth++
+		}
+		if bound == 0 && depth > 0 && (buf[r] == ' ' || buf[r] == '\t') {
+			if op, e := wordOperator(buf, r, len(buf)); op != nil {
+				s := e - 1
+				for buf[s] != ' ' && buf[s] != '\t' {
+					s--
+				}
+				if index != nil {
+					for k := s; k <= e; k++ {
+						index[w+k-s] = k
+					}
+				}
+				w += copy(buf[w:], buf[s:e+1]) // " word "
+				r = e + 1
+				continue
+			}
 		}
 		if (buf[r] != ' ' && buf[r] != '\t') || bound > 0 {
 			if w != r {
 				buf[w] = buf[r]
 			}
+			if index != nil {
+				index[w] = r
+			}
 			w++
 		}
 		r++
@@ -2,12 +2,10 @@ package jsonslice
 
 import (
 	"bytes"
-	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"math/rand"
+	"errors"
+	"reflect"
 	"testing"
-	"time"
 )
 
 var data []byte
@@ -124,78 +122,6 @@ func init() {
 	`)
 }
 
-func randomBytes(p []byte, min, max int) {
-	for i := 0; i < len(p); i++ {
-		p[i] = byte(rand.Intn(max-min) + min)
-	}
-}
-
-func TestFuzzyPath(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping test in short mode.")
-	}
-	var str string
-	defer func() {
-		if v := recover(); v != nil {
-			println("'" + hex.EncodeToString([]byte(str)) + "'")
-			println("'" + str + "'")
-			panic(v)
-		}
-	}()
-	rand.Seed(time.Now().UnixNano())
-	b := make([]byte, 100)
-	top := 2000000
-	fmt.Printf("\rpath fuzzy [                    ]\rpath fuzzy [")
-	for i := 0; i < top; i++ {
-		if i%(top/20) == 1 {
-			fmt.Printf(".")
-		}
-		randomBytes(b, 32, 127)
-		n := rand.Intn(len(b)) + 1
-		b[0] = '$'
-		str = string(b[:n])
-		_, _, _ = readRef([]byte(str), 1, 0)
-	}
-	fmt.Println()
-}
-
-func TestCustomPath(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping test in short mode.")
-	}
-	_, _, _ = readRef([]byte("$.()"), 1, 0)
-}
-
-func TestFuzzyGet(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping test in short mode.")
-	}
-	var str string
-	defer func() {
-		if v := recover(); v != nil {
-			println("'" + hex.EncodeToString([]byte(str)) + "'")
-			println("'" + str + "'")
-			panic(v)
-		}
-	}()
-	rand.Seed(time.Now().UnixNano())
-	b := make([]byte, 500)
-	top := 10000000
-	fmt.Printf("\rget fuzzy  [                    ]\rget fuzzy  [")
-	for i := 0; i < top; i++ {
-		if i%(top/20) == 1 {
-			fmt.Printf(".")
-		}
-		n, err := rand.Read(b[:rand.Int()%len(b)])
-		if err != nil {
-			t.Fatal(err)
-		}
-		str = string(b[:n])
-		_, _ = Get([]byte(str), "$.some.value")
-	}
-	fmt.Println()
-}
-
 func Test_10Mb(t *testing.T) {
 	largeData := GenerateLargeData()
 	expected := []byte(`"Sword of Honour"`)
@@ -206,12 +132,14 @@ func Test_10Mb(t *testing.T) {
 	}
 }
 
-func Test_Expressions(t *testing.T) {
+type expressionTest struct {
+	Query    string
+	Expected []byte
+}
 
-	tests := []struct {
-		Query    string
-		Expected []byte
-	}{
+// expressionTests returns queries over `data` along with expected results
+func expressionTests() []expressionTest {
+	return []expressionTest{
 		// self
 		{`$`, data},
 		// simple query
@@ -323,9 +251,29 @@ func Test_Expressions(t *testing.T) {
 		{`$.store.book[?(@.price > $.expensive*1.1)]['price','title']`, []byte(`["Sword of Honour",12.99,"The Lord of the Rings",22.99]`)},
 		// functions in filter
 		{`$.store.bicycle.equipment[?(@.count() == 2)][1]`, []byte(`["apparel"]`)},
+		// script expressions: computed index
+		{`$.store.book[(@.length-1)].title`, []byte(`"The Lord of the Rings"`)},
+		// script expressions: computed negative index
+		{`$.store.book[(-1 * 2)].title`, []byte(`"Moby Dick"`)},
+		// script expressions: computed key
+		{`$.store[('bi' + 'cycle')].color`, []byte(`"red"`)},
+		// script expressions: neither a number nor a string
+		{`$.store.book[(@.none)]`, []byte(``)},
+		// filter on an object: member values
+		{`$.store[?(@.color == "red")].price`, []byte(`[19.95]`)},
+		{`$.store.bicycle[?(@ == "red")]`, []byte(`["red"]`)},
+		{`$.store.*[?(@.price > 20)].title`, []byte(`["The Lord of the Rings"]`)},
+		// first(), last()
+		{`$.store.book.last().title`, []byte(`"The Lord of the Rings"`)},
+		{`$.store.book.first().author`, []byte(`"Nigel Rees"`)},
+		{`$.store.bicycle.equipment[*].last()`, []byte(`["horn","map","apparel","\"quoted\""]`)},
+		{`$.store.last()`, []byte(``)},
 	}
+}
 
-	for _, tst := range tests {
+func Test_Expressions(t *testing.T) {
+
+	for _, tst := range expressionTests() {
 		// println(tst.Query)
 		res, err := Get(data, tst.Query)
 		if err != nil {
@@ -412,6 +360,83 @@ func Test_StringComparison(t *testing.T) {
 	}
 }
 
+func Test_PathExpressions(t *testing.T) {
+
+	input := []byte(`{"a": 1, "b": 2.5, "s": "x", "arr": [1, 2], "store": {"book": [{"price": 8, "a": 1, "i": 1}, {"price": 12, "a": 1}]}}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[:].price.sum() * 1.2`, []byte(`24`)},
+		{`$.store.book[*].price.sum()*2`, []byte(`40`)},
+		{`$.store.book[?(@.price > 10)].price.sum() - $.a`, []byte(`11`)},
+		{`($.a + $.b)`, []byte(`3.5`)},
+		{`$.a + $.b`, []byte(`3.5`)},
+		{`($.a + $.b) * 2`, []byte(`7`)},
+		{`(1 + 2) * 3`, []byte(`9`)},
+		{`$.a == 1`, []byte(`true`)},
+		{`$.a in $.arr`, []byte(`true`)},
+		{`$.s + "y"`, []byte(`"xy"`)},
+		{`($.arr)`, []byte(`[1, 2]`)},
+		{`$.missing + 1`, nil},
+		// parenthesized references in filters
+		{`$.store.book[?((@.a) && (@.i))].price`, []byte(`[8]`)},
+		{`$.arr[?(($.a + $.b) == 3.5)]`, []byte(`[1,2]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	if _, err := Get(input, `($.a +`); err == nil {
+		t.Errorf("($.a + : error expected")
+	}
+}
+
+func Test_NestedFilters(t *testing.T) {
+
+	input := []byte(`{"max": 20, "shops": [
+		{"id": 1, "books": [{"price": 10}, {"price": 30}]},
+		{"id": 2, "books": [{"price": 5}]},
+		{"id": 3},
+		{"id": 4, "books": []}
+	]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.shops[?(@.books[?(@.price > 20)].count() > 0)].id`, []byte(`[1]`)},
+		{`$.shops[?(@.books[?(@.price < 20)].count() == 1)].id`, []byte(`[1,2]`)},
+		// existence tests
+		{`$.shops[?(@.books[?(@.price > 20)])].id`, []byte(`[1]`)},
+		{`$.shops[?(!@.books[?(@.price > 20)])].id`, []byte(`[2,3,4]`)},
+		{`$.shops[?@.books[?@.price > 20]].id`, []byte(`[1]`)},
+		{`$.shops[?(@.books[*])].id`, []byte(`[1,2]`)},
+		{`$.shops[?(@.books)].id`, []byte(`[1,2,4]`)},
+		// $ refers to the document
+		{`$.shops[?(@.books[?(@.price > $.max)])].id`, []byte(`[1]`)},
+		{`$.shops[?(count(@.books[?(@.price < $.max)]) > 1)].id`, []byte(`[]`)},
+		// count() of a single value is its length
+		{`$.shops[?(@.books.count() == 1)].id`, []byte(`[2]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
 func Test_Extensions(t *testing.T) {
 
 	variant2 := []byte(`{ "book": [ {"Book one"}, {"Book two"}, {"Book three"}, {"Book four"} ] }`)
@@ -454,8 +479,8 @@ func Test_Extensions(t *testing.T) {
 		// custom extensions
 		// {`$.'book'[1]`, variant1, []byte(`{"Author": "Z.Hopp", "Title": "Trollkrittet"}`)},
 		// {`$.'book'.1`, variant1, []byte(`{"Author": "Z.Hopp", "Title": "Trollkrittet"}`)},
-		// wildcard in key list ignored if not alone
-		{`$.[0,*,-1]`, variant4, []byte(`["first","third"]`)},
+		// wildcard in key list makes a union
+		{`$.[0,*,-1]`, variant4, []byte(`["first","first","second","third","third"]`)},
 
 		// gold standard
 
@@ -596,6 +621,16 @@ func Test_Fixes(t *testing.T) {
 		// fixed in 1.0.5
 		{[]byte(`{"kind":"Pod", "spec":{ "containers": [{"name":"c1"}, {"name":"c2"}] }}`), `$.spec.containers[:]`, []byte(`[{"name":"c1"},{"name":"c2"}]`)},
 		{[]byte(`{"kind":"Pod", "spec":{ "containers": [{"name":"c1"}, {"name":"c2"}] }}`), `$..spec.containers[:]`, []byte(`[[{"name":"c1"},{"name":"c2"}]]`)},
+		// root reference to a missing key is undefined and does not compare to any value
+		{[]byte(`{"a": [{"b": 1}, {"b": null}, {}]}`), `$.a[?(@.b != $.missing)]`, []byte(`[]`)},
+		// deepscan combined with a filter used to ignore the filter and return nothing
+		{[]byte(`{"store":{"book":[{"isbn":"1"},{"price":12},{"isbn":"2","sub":[{"isbn":"3"}]}],"bicycle":{"isbn":"b"}}}`), `$..[?(@.isbn)].isbn`, []byte(`["1","2","3","b"]`)},
+		{[]byte(`{"store":{"book":[{"price":8},{"price":12}],"bicycle":{"price":19}}}`), `$.store..[?(@.price > 10)]`, []byte(`[{"price":12},{"price":19}]`)},
+		{[]byte(`{"a":[1,20,{"b":30}],"c":5}`), `$..[?(@ > 10)]`, []byte(`[20,30]`)},
+		{[]byte(`{"a":[1,20,{"b":30}],"c":5}`), `$..[?(@.z)]`, []byte(`[]`)},
+		// deepscan for several keys used to fail on the second key and return nothing
+		{[]byte(`{"book":[{"price":8,"title":"A"},{"title":"B","price":12}],"bicycle":{"price":19}}`), `$..['price','title']`, []byte(`[8,"A","B",12,19]`)},
+		{[]byte(`{"store":{"book":[{"price":8,"title":"A"}],"bicycle":{"price":19}}}`), `$.store..['title','price']`, []byte(`[8,"A",19]`)},
 	}
 
 	for _, tst := range tests {
@@ -608,6 +643,93 @@ func Test_Fixes(t *testing.T) {
 	}
 }
 
+func Test_MaxDepth(t *testing.T) {
+
+	input := []byte(`{"name": 1, "a": {"name": 2, "b": [{"name": 3, "c": {"name": 4}}]}}`)
+
+	tests := []struct {
+		Query    string
+		Depth    int
+		Expected []byte
+		Offsets  [][2]int
+	}{
+		{`$..name`, 0, []byte(`[1,2,3,4]`), [][2]int{{9, 10}, {26, 27}, {44, 45}, {61, 62}}},
+		{`$..name`, 1, []byte(`[1]`), [][2]int{{9, 10}}},
+		{`$..name`, 2, []byte(`[1,2]`), [][2]int{{9, 10}, {26, 27}}},
+		{`$..name`, 4, []byte(`[1,2,3]`), [][2]int{{9, 10}, {26, 27}, {44, 45}}},
+		{`$.a..name`, 3, []byte(`[2,3]`), [][2]int{{26, 27}, {44, 45}}},
+		{`$..[?(@.name)].name`, 3, []byte(`[2,3]`), [][2]int{{26, 27}, {44, 45}}},
+		{`$..*`, 1, []byte(`[1,{"name": 2, "b": [{"name": 3, "c": {"name": 4}}]}]`), [][2]int{{9, 10}, {17, 66}}},
+		// deepscan references in filters are limited as well (relative to @)
+		{`$[?(@..name == 4)]`, 0, []byte(`[{"name": 2, "b": [{"name": 3, "c": {"name": 4}}]}]`), [][2]int{{17, 66}}},
+		{`$[?(@..name == 3)]`, 3, []byte(`[{"name": 2, "b": [{"name": 3, "c": {"name": 4}}]}]`), [][2]int{{17, 66}}},
+		{`$[?(@..name == 4)]`, 3, []byte(`[]`), [][2]int{}},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		res, err := GetWith(input, tst.Query, WithMaxDepth(tst.Depth), WithOffsets(&offsets))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf("%s (depth %d)\n\texpected `%s`\n\tbut got  `%s`", tst.Query, tst.Depth, tst.Expected, res)
+		} else if !reflect.DeepEqual(offsets, tst.Offsets) {
+			t.Errorf("%s (depth %d)\n\texpected offsets %v\n\tbut got  %v", tst.Query, tst.Depth, tst.Offsets, offsets)
+		}
+	}
+}
+
+func Test_NegativeIndexes(t *testing.T) {
+
+	arr := `[ "a]", "b\\\"[", {"x": "][", "y": ["}"]}, [1, "[", 2], -1.5, true , null ]`
+	inputs := [][]byte{[]byte(arr), []byte(`{"a": ` + arr + `, "b": [0]}`)}
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`[-1]`, []byte(`null`)},
+		{`[-3]`, []byte(`-1.5`)},
+		{`[-4][1]`, []byte(`"["`)},
+		{`[-5].x`, []byte(`"]["`)},
+		{`[-6]`, []byte(`"b\\\"["`)},
+		{`[-7]`, []byte(`"a]"`)},
+		{`[-8]`, []byte(``)},
+		{`[-1,-7]`, []byte(`[null,"a]"]`)},
+		{`[-2:]`, []byte(`[true,null]`)},
+		{`[-4:-2]`, []byte(`[[1, "[", 2],-1.5]`)},
+		{`[-3::2]`, []byte(`[-1.5,null]`)},
+		{`[-20:]`, []byte(`["a]","b\\\"[",{"x": "][", "y": ["}"]},[1, "[", 2],-1.5,true,null]`)},
+		{`[-2:3]`, []byte(`[]`)},
+	}
+
+	for k, input := range inputs {
+		prefix := "$"
+		if k > 0 {
+			prefix = "$.a"
+		}
+		for _, tst := range tests {
+			res, err := Get(input, prefix+tst.Query)
+			if err != nil {
+				t.Errorf(prefix + tst.Query + " : " + err.Error())
+			} else if compareSlices(res, tst.Expected) != 0 {
+				t.Errorf(prefix + tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+			}
+		}
+	}
+
+	// malformed or non-standard arrays fall back to the full scan
+	for _, tst := range []struct{ Input, Query, Expected string }{
+		{`[1 2]`, `$[-1]`, `2`},
+		{`{"a": [1, 2] }`, `$.a[-1]`, `2`},
+	} {
+		res, err := Get([]byte(tst.Input), tst.Query)
+		if err != nil || string(res) != tst.Expected {
+			t.Errorf("%s on %s: expected %s, got %s (%v)", tst.Query, tst.Input, tst.Expected, res, err)
+		}
+	}
+}
+
 func Test_FutureFixes(t *testing.T) {
 	// ref := `$[?(Q[)?8W?D-lIeM%|e9b33<sERpU.(2)&D`
 	// _, _, _ = readRef([]byte(ref), 1, 0)
@@ -628,6 +750,17 @@ func Test_Unicode(t *testing.T) {
 		{[]byte(`{"Mot\u00F6rhead":"Lemmy"}`), `$."Motörhead"`, []byte(`"Lemmy"`)},
 		// json: & path: both unicode escaped
 		{[]byte(`{"Mot\u00F6rhead":"Lemmy"}`), `$."Mot\u00F6rhead"`, []byte(`"Lemmy"`)},
+		// RFC 9535 escapes
+		{[]byte(`{"a/b":1}`), `$["a\/b"]`, []byte(`1`)},
+		{[]byte(`{"it's":1}`), `$['it\'s']`, []byte(`1`)},
+		{[]byte(`{"tab\there":1}`), `$['tab\there']`, []byte(`1`)},
+		{[]byte(`{"𝄞":1}`), `$["\uD834\uDD1E"]`, []byte(`1`)},
+		{[]byte(`{"\uD834\uDD1E":1}`), `$['𝄞']`, []byte(`1`)},
+		// filter string literals
+		{[]byte(`[{"s":"it's"},{"s":"x"}]`), `$[?(@.s == 'it\'s')].s`, []byte(`["it's"]`)},
+		{[]byte(`[{"s":"a\"b"},{"s":"x"}]`), `$[?(@.s == 'a"b')].s`, []byte(`["a\"b"]`)},
+		{[]byte(`[{"s":"café"},{"s":"x"}]`), `$[?(@.s == "caf\u00e9")].s`, []byte(`["café"]`)},
+		{[]byte(`[{"s":"caf\u00e9"},{"s":"x"}]`), `$[?(@.s == 'café')].s`, []byte(`["caf\u00e9"]`)},
 	}
 
 	for _, tst := range tests {
@@ -640,6 +773,31 @@ func Test_Unicode(t *testing.T) {
 	}
 }
 
+func Test_InvalidEscapes(t *testing.T) {
+
+	tests := []struct {
+		Query    string
+		Expected error
+	}{
+		{`$["\x41"]`, errPathUnknownEscape},
+		{`$["\U00000041"]`, errPathUnknownEscape},
+		{`$["\q"]`, errPathUnknownEscape},
+		{`$["it\'s"]`, errPathUnknownEscape},
+		{`$['a\"b']`, errPathUnknownEscape},
+		{`$["\uD834"]`, errPathInvalidSurrogate},
+		{`$["\uDD1E\uD834"]`, errPathInvalidSurrogate},
+		{"$['a\tb']", errPathInvalidChar}, // raw control character
+		{`$[?(@.a == 'a\qb')]`, errPathUnknownEscape},
+	}
+
+	for _, tst := range tests {
+		_, err := Get(data, tst.Query)
+		if !errors.Is(err, tst.Expected) {
+			t.Errorf(tst.Query+"\n\texpected `%v`\n\tbut got  `%v`", tst.Expected, err)
+		}
+	}
+}
+
 func Test_Errors(t *testing.T) {
 
 	tests := []struct {
@@ -651,17 +809,17 @@ func Test_Errors(t *testing.T) {
 		// normally only . and [ expected after the key
 		{data, `$.store(foo`, `path: invalid character at 7`, []byte{}},
 		// unexpected EOF before :
-		{[]byte(`{"foo"  `), `$.foo`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo"  `), `$.foo`, `unexpected end of input at line 1, column 9`, []byte{}},
 		// unexpected EOF after :
-		{[]byte(`{"foo" : `), `$.foo`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo" : `), `$.foo`, `unexpected end of input at line 1, column 10`, []byte{}},
 		// wrong type
-		{[]byte(`{"foo" : "bar"`), `$.foo[0]`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo" : "bar"`), `$.foo[0]`, `unexpected end of input at line 1, column 15`, []byte{}},
 		// wrong type
-		{[]byte(`{"foo" : "bar"`), `$.foo[0].bar`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo" : "bar"`), `$.foo[0].bar`, `unexpected end of input at line 1, column 15`, []byte{}},
 		// wrong type
-		{[]byte(`{"foo" : "bar"`), `$.foo.bar`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo" : "bar"`), `$.foo.bar`, `unexpected end of input at line 1, column 15`, []byte{}},
 		// wrong type
-		{[]byte(`["foo" : ("bar")]`), `$.foo.bar`, `unrecognized value: true, false or null expected`, []byte{}},
+		{[]byte(`["foo" : ("bar")]`), `$.foo.bar`, `unrecognized value: true, false or null expected at line 1, column 8`, []byte{}},
 
 		// start with $
 		{data, `foo`, `path: $ expected`, []byte{}},
@@ -684,22 +842,24 @@ func Test_Errors(t *testing.T) {
 		{data, `$.store.book[-99]`, ``, []byte(``)},
 		// array: slice indexes out of bounds: not an error
 		{data, `$.store.book[-99:-15]`, ``, []byte(`[]`)},
+		// script expression: unclosed
+		{data, `$.store.book[(@.length-1)`, `path: invalid character at 25`, []byte{}},
 		// filter expression: empty
 		{data, `$.store.book[?()]`, `empty filter`, []byte{}},
 		// filter expression: invalid
 		{data, `$.store.book[?(1+)]`, `not enough arguments`, []byte{}},
 
 		// wrong bool value
-		{[]byte(`{"foo": Troo}`), `$.foo`, `unrecognized value: true, false or null expected`, []byte{}},
+		{[]byte(`{"foo": Troo}`), `$.foo`, `unrecognized value: true, false or null expected at line 1, column 9`, []byte{}},
 		// wrong value
-		{[]byte(`{"foo": moo}`), `$.foo`, `unrecognized value: true, false or null expected`, []byte{}},
+		{[]byte(`{"foo": moo}`), `$.foo`, `unrecognized value: true, false or null expected at line 1, column 9`, []byte{}},
 		// unexpected EOF
-		{[]byte(`{"foo": { "bar": "bazz"`), `$.bar`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo": { "bar": "bazz"`), `$.bar`, `unexpected end of input at line 1, column 24`, []byte{}},
 		// unexpected EOF
-		{[]byte(`{"foo": {"bar":"moo`), `$.foo.moo`, `unexpected end of input`, []byte{}},
+		{[]byte(`{"foo": {"bar":"moo`), `$.foo.moo`, `unexpected end of input at line 1, column 20`, []byte{}},
 
 		// invalid json
-		{[]byte(`{"foo" - { "bar": 0 }}`), `$.foo.bar`, `':' expected`, []byte{}},
+		{[]byte(`{"foo" - { "bar": 0 }}`), `$.foo.bar`, `':' expected at line 1, column 8`, []byte{}},
 
 		// unknown token
 		{[]byte(`{"foo":[{"bar":"moo"}]}`), `$.foo[?(@.bar == 2#3)]`, `unknown token at 8: #3 at 8`, []byte{}},
@@ -783,7 +943,7 @@ func GenerateLargeData() []byte {
 		largeData = append(largeData, ',')
 	}
 	largeData = append(largeData, book1...)
-	largeData = append(largeData, []byte("]}")...)
+	largeData = append(largeData, []byte("]}}")...)
 	return largeData
 }
 func Benchmark_Unmarshal_10Mb(b *testing.B) {
@@ -814,6 +974,16 @@ func Benchmark_Jsonslice_Get_10Mb_Last(b *testing.B) {
 	}
 }
 
+func Benchmark_Jsonslice_Get_10Mb_Negative(b *testing.B) {
+	b.StopTimer()
+	largeData := GenerateLargeData()
+	books, _ := Get(largeData, "$.store.book")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Get(books, "$[-1].title")
+	}
+}
+
 // helpers
 
 func compareSlices(s1 []byte, s2 []byte) int {
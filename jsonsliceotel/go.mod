@@ -0,0 +1,22 @@
+module github.com/bhmj/jsonslice/jsonsliceotel
+
+go 1.20
+
+require (
+	github.com/bhmj/jsonslice v1.2.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/bhmj/xpression v0.9.1 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+// Development within the repository only: a replace directive is ignored when this module is a dependency,
+// so the jsonslice version required above has to be tagged before jsonsliceotel (see README.md, OpenTelemetry).
+replace github.com/bhmj/jsonslice => ../
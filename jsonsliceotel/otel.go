@@ -0,0 +1,131 @@
+// Package jsonsliceotel provides OpenTelemetry instrumentation for jsonslice.
+//
+// Every call is wrapped in a span carrying the path, input size and the number of matched values:
+//
+//	tr := jsonsliceotel.NewTracer(otel.GetTracerProvider())
+//	res, err := tr.Get(ctx, data, "$.store.book[?(@.price > 10)].title")
+//
+// Compiled paths are evaluated within spans as well:
+//
+//	p, err := tr.Compile(ctx, "$.store.book[?(@.price > 10)].title")
+//	res, err := p.Get(ctx, data)
+package jsonsliceotel
+
+import (
+	"context"
+
+	"github.com/bhmj/jsonslice"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/bhmj/jsonslice"
+
+// span attribute keys
+const (
+	AttrPath       = attribute.Key("jsonslice.path")
+	AttrInputSize  = attribute.Key("jsonslice.input_size")
+	AttrMatchCount = attribute.Key("jsonslice.match_count")
+)
+
+// Tracer wraps jsonslice calls with spans
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates a Tracer using the given provider (nil means the global one)
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// Get calls jsonslice.GetWith within a "jsonslice.Get" span.
+// A collector passed with jsonslice.WithStats receives the counters as well.
+func (t *Tracer) Get(ctx context.Context, input []byte, path string, opts ...jsonslice.Option) ([]byte, error) {
+	_, span := t.tracer.Start(ctx, "jsonslice.Get", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+	if !span.IsRecording() {
+		return jsonslice.GetWith(input, path, opts...)
+	}
+
+	var c collector
+	res, err := jsonslice.GetWith(input, path, append(opts[:len(opts):len(opts)], jsonslice.WithStats(&c))...)
+	finish(span, path, len(input), c.matches, err)
+	return res, err
+}
+
+// Get calls jsonslice.GetWith within a span created by the global tracer provider
+func Get(ctx context.Context, input []byte, path string, opts ...jsonslice.Option) ([]byte, error) {
+	return NewTracer(nil).Get(ctx, input, path, opts...)
+}
+
+// Path is a compiled path evaluated within spans
+type Path struct {
+	tracer *Tracer
+	path   *jsonslice.Path
+}
+
+// Compile calls jsonslice.CompileWith within a "jsonslice.Compile" span
+func (t *Tracer) Compile(ctx context.Context, path string, opts ...jsonslice.Option) (*Path, error) {
+	_, span := t.tracer.Start(ctx, "jsonslice.Compile", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	p, err := jsonslice.CompileWith(path, opts...)
+	span.SetAttributes(AttrPath.String(path))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return &Path{tracer: t, path: p}, nil
+}
+
+// Compile calls jsonslice.CompileWith within a span created by the global tracer provider
+func Compile(ctx context.Context, path string, opts ...jsonslice.Option) (*Path, error) {
+	return NewTracer(nil).Compile(ctx, path, opts...)
+}
+
+// Get evaluates the compiled path within a "jsonslice.Get" span
+func (p *Path) Get(ctx context.Context, input []byte) ([]byte, error) {
+	_, span := p.tracer.tracer.Start(ctx, "jsonslice.Get", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+	if !span.IsRecording() {
+		return p.path.Get(input)
+	}
+
+	var c collector
+	res, err := p.path.GetWith(input, jsonslice.WithStats(&c))
+	finish(span, p.path.String(), len(input), c.matches, err)
+	return res, err
+}
+
+// String returns the source text of the path
+func (p *Path) String() string {
+	return p.path.String()
+}
+
+// finish sets the attributes and the status of an evaluation span
+func finish(span trace.Span, path string, inputSize, matches int, err error) {
+	span.SetAttributes(
+		AttrPath.String(path),
+		AttrInputSize.Int(inputSize),
+		AttrMatchCount.Int(matches),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// collector keeps the number of matches of a single call
+type collector struct {
+	matches int
+}
+
+func (c *collector) Collect(s *jsonslice.Stats) {
+	c.matches = s.Matches
+}
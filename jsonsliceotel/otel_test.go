@@ -0,0 +1,110 @@
+package jsonsliceotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bhmj/jsonslice"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Get(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tr := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	input := []byte(`{"book": [{"price": 8}, {"price": 22}, {"price": 30}]}`)
+
+	res, err := tr.Get(context.Background(), input, `$.book[?(@.price > 10)].price`)
+	if err != nil || string(res) != `[22,30]` {
+		t.Fatalf("unexpected result `%s` (%v)", res, err)
+	}
+	_, err = tr.Get(context.Background(), input, `$.`)
+	if err == nil {
+		t.Fatalf("error expected")
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if spans[0].Name() != "jsonslice.Get" ||
+		attrs[AttrPath].AsString() != `$.book[?(@.price > 10)].price` ||
+		attrs[AttrInputSize].AsInt64() != int64(len(input)) ||
+		attrs[AttrMatchCount].AsInt64() != 2 {
+		t.Errorf("unexpected span %s %v", spans[0].Name(), spans[0].Attributes())
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("error status expected")
+	}
+}
+
+type testCollector struct {
+	calls, matches int
+}
+
+func (c *testCollector) Collect(s *jsonslice.Stats) {
+	c.calls++
+	c.matches = s.Matches
+}
+
+func Test_GetOptions(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tr := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	input := []byte(`{"book": [{"price": 8}, {"price": 22}, {"price": 30}]}`)
+
+	// the collector of the caller is kept, the options of the caller are not modified
+	c := &testCollector{}
+	opts := make([]jsonslice.Option, 1, 2)
+	opts[0] = jsonslice.WithStats(c)
+	if _, err := tr.Get(context.Background(), input, `$.book[*].price`, opts...); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if c.calls != 1 || c.matches != 3 {
+		t.Errorf("collector of the caller expected to be called: %+v", c)
+	}
+	if opts[:2][1] != nil {
+		t.Errorf("options of the caller modified")
+	}
+}
+
+func Test_Compile(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tr := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	input := []byte(`{"book": [{"price": 8}, {"price": 22}, {"price": 30}]}`)
+
+	p, err := tr.Compile(context.Background(), `$.book[?(@.price > 10)].price`)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	res, err := p.Get(context.Background(), input)
+	if err != nil || string(res) != `[22,30]` {
+		t.Fatalf("unexpected result `%s` (%v)", res, err)
+	}
+	if _, err = tr.Compile(context.Background(), `$.`); err == nil {
+		t.Fatalf("error expected")
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[1].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if spans[0].Name() != "jsonslice.Compile" || spans[1].Name() != "jsonslice.Get" ||
+		attrs[AttrPath].AsString() != `$.book[?(@.price > 10)].price` ||
+		attrs[AttrInputSize].AsInt64() != int64(len(input)) ||
+		attrs[AttrMatchCount].AsInt64() != 2 {
+		t.Errorf("unexpected spans %s %s %v", spans[0].Name(), spans[1].Name(), spans[1].Attributes())
+	}
+	if spans[2].Status().Code != codes.Error {
+		t.Errorf("error status expected")
+	}
+}
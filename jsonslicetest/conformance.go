@@ -0,0 +1,295 @@
+package jsonslicetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/bhmj/jsonslice"
+)
+
+// Conformance case status
+const (
+	Pass      = "pass"      // result matches the expected one
+	Fail      = "fail"      // error or panic on a valid selector
+	Deviation = "deviation" // a different result, or an invalid selector accepted
+	Skip      = "skip"      // no consensus on the expected result
+)
+
+// Case is a single conformance test case
+type Case struct {
+	Name      string
+	Selector  string
+	Document  json.RawMessage
+	Results   []json.RawMessage  // acceptable node lists (json arrays)
+	Unordered bool               // node list order is not significant
+	Invalid   bool               // selector is expected to be rejected
+	Skip      bool               // no consensus
+	Options   []jsonslice.Option // evaluation options of the suite
+}
+
+// CaseResult is an outcome of a single conformance case
+type CaseResult struct {
+	Suite    string            `json:"suite"`
+	Name     string            `json:"name"`
+	Selector string            `json:"selector"`
+	Status   string            `json:"status"`
+	Got      json.RawMessage   `json:"got,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Expected []json.RawMessage `json:"expected,omitempty"`
+}
+
+// Report is a machine-readable conformance report
+type Report struct {
+	Results  []CaseResult `json:"results"`
+	Passed   int          `json:"passed"`
+	Failed   int          `json:"failed"`
+	Deviated int          `json:"deviated"`
+	Skipped  int          `json:"skipped"`
+}
+
+// LoadCTS reads the cases of jsonpath-compliance-test-suite (cts.json)
+func LoadCTS(r io.Reader) ([]Case, error) {
+	var suite struct {
+		Tests []struct {
+			Name            string            `json:"name"`
+			Selector        string            `json:"selector"`
+			Document        json.RawMessage   `json:"document"`
+			Result          json.RawMessage   `json:"result"`
+			Results         []json.RawMessage `json:"results"`
+			InvalidSelector bool              `json:"invalid_selector"`
+		} `json:"tests"`
+	}
+	if err := json.NewDecoder(r).Decode(&suite); err != nil {
+		return nil, err
+	}
+	cases := make([]Case, 0, len(suite.Tests))
+	for _, t := range suite.Tests {
+		c := Case{Name: t.Name, Selector: t.Selector, Document: t.Document, Invalid: t.InvalidSelector, Results: t.Results}
+		c.Options = []jsonslice.Option{jsonslice.WithRFCComparison()}
+		if t.Result != nil {
+			c.Results = append(c.Results, t.Result)
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// LoadComparison reads the cases of json-path-comparison regression suite
+// (regression_suite.yaml converted to json, e.g. with `yq -o json`).
+func LoadComparison(r io.Reader) ([]Case, error) {
+	var suite struct {
+		Queries []struct {
+			ID              string          `json:"id"`
+			Selector        string          `json:"selector"`
+			Document        json.RawMessage `json:"document"`
+			Consensus       json.RawMessage `json:"consensus"`
+			ScalarConsensus json.RawMessage `json:"scalar-consensus"`
+			Ordered         *bool           `json:"ordered"`
+		} `json:"queries"`
+	}
+	if err := json.NewDecoder(r).Decode(&suite); err != nil {
+		return nil, err
+	}
+	cases := make([]Case, 0, len(suite.Queries))
+	for _, q := range suite.Queries {
+		c := Case{Name: q.ID, Selector: q.Selector, Document: q.Document, Unordered: q.Ordered != nil && !*q.Ordered}
+		var tag string
+		switch {
+		case q.Consensus == nil:
+			c.Skip = true
+		case json.Unmarshal(q.Consensus, &tag) == nil:
+			// "NOT_SUPPORTED" or another marker
+			c.Invalid = tag == "NOT_SUPPORTED"
+			c.Skip = !c.Invalid
+		default:
+			c.Results = append(c.Results, q.Consensus)
+		}
+		if q.ScalarConsensus != nil {
+			c.Results = append(c.Results, append(append([]byte{'['}, q.ScalarConsensus...), ']'))
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Run evaluates the cases of a suite and adds the outcomes to the report
+func (r *Report) Run(suite string, cases []Case) {
+	for _, c := range cases {
+		res := runCase(c)
+		res.Suite = suite
+		switch res.Status {
+		case Pass:
+			r.Passed++
+		case Fail:
+			r.Failed++
+		case Deviation:
+			r.Deviated++
+		case Skip:
+			r.Skipped++
+		}
+		r.Results = append(r.Results, res)
+	}
+}
+
+func runCase(c Case) CaseResult {
+	res := CaseResult{Name: c.Name, Selector: c.Selector, Expected: c.Results}
+	if c.Skip {
+		res.Status = Skip
+		return res
+	}
+	got, err := nodeList([]byte(c.Document), c.Selector, c.Options)
+	res.Got = got
+	if err != nil {
+		res.Error = err.Error()
+	}
+	var pe *PanicError
+	switch {
+	case c.Invalid && err != nil && !errors.As(err, &pe):
+		res.Status = Pass
+	case c.Invalid:
+		res.Status = Deviation
+	case err != nil:
+		res.Status = Fail
+	case matchAny(got, c.Results, c.Unordered):
+		res.Status = Pass
+	default:
+		res.Status = Deviation
+	}
+	return res
+}
+
+// nodeList evaluates selector and returns the result as a node list (json array)
+func nodeList(doc []byte, selector string, opts []jsonslice.Option) ([]byte, error) {
+	plan, err := jsonslice.Plan(selector)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Replay(doc, selector, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return []byte("[]"), nil
+	}
+	if plan.Aggregating {
+		return res, nil
+	}
+	return append(append([]byte{'['}, res...), ']'), nil
+}
+
+// matchAny compares got against every acceptable result semantically
+func matchAny(got []byte, results []json.RawMessage, unordered bool) bool {
+	g, err := decode(got)
+	if err != nil {
+		return false
+	}
+	g = normalize(g)
+	if unordered {
+		g = sorted(g)
+	}
+	for _, r := range results {
+		w, err := decode(r)
+		if err != nil {
+			continue
+		}
+		w = normalize(w)
+		if unordered {
+			w = sorted(w)
+		}
+		if reflect.DeepEqual(g, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted returns array elements sorted by their json representation
+func sorted(v interface{}) interface{} {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+	type elem struct {
+		key string
+		val interface{}
+	}
+	elems := make([]elem, len(arr))
+	for i := range arr {
+		b, _ := json.Marshal(arr[i])
+		elems[i] = elem{string(b), arr[i]}
+	}
+	sort.Slice(elems, func(a, b int) bool { return elems[a].key < elems[b].key })
+	for i := range elems {
+		arr[i] = elems[i].val
+	}
+	return arr
+}
+
+// Markdown writes a human-readable summary of the report: totals per suite and the list of non-passing cases
+func (r *Report) Markdown(w io.Writer) error {
+	type totals struct{ pass, fail, dev, skip int }
+	var suites []string
+	bySuite := map[string]*totals{}
+	for _, res := range r.Results {
+		t := bySuite[res.Suite]
+		if t == nil {
+			t = &totals{}
+			bySuite[res.Suite] = t
+			suites = append(suites, res.Suite)
+		}
+		switch res.Status {
+		case Pass:
+			t.pass++
+		case Fail:
+			t.fail++
+		case Deviation:
+			t.dev++
+		case Skip:
+			t.skip++
+		}
+	}
+	var err error
+	p := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	p("# jsonslice conformance\n\n| Suite | Pass | Fail | Deviation | Skip |\n|---|---:|---:|---:|---:|\n")
+	for _, s := range suites {
+		t := bySuite[s]
+		p("| %s | %d | %d | %d | %d |\n", s, t.pass, t.fail, t.dev, t.skip)
+	}
+	p("| **Total** | %d | %d | %d | %d |\n", r.Passed, r.Failed, r.Deviated, r.Skipped)
+	p("\n## Deviations and failures\n\n| Suite | Case | Selector | Status | Got |\n|---|---|---|---|---|\n")
+	for _, res := range r.Results {
+		if res.Status != Fail && res.Status != Deviation {
+			continue
+		}
+		got := string(res.Got)
+		if res.Error != "" {
+			got = "error: " + res.Error
+		}
+		p("| %s | %s | `%s` | %s | %s |\n", res.Suite, mdEscape(res.Name), mdEscape(res.Selector), res.Status, mdEscape(got))
+	}
+	return err
+}
+
+// mdEscape escapes characters breaking a markdown table cell
+func mdEscape(s string) string {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '|':
+			buf = append(buf, '\\', '|')
+		case '\n', '\r':
+			buf = append(buf, ' ')
+		default:
+			buf = append(buf, s[i])
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,60 @@
+package jsonslicetest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_Conformance(t *testing.T) {
+
+	cts := `{"tests": [
+		{"name": "basic", "selector": "$.a", "document": {"a": 1}, "result": [1]},
+		{"name": "wildcard", "selector": "$[*]", "document": [1, 2], "result": [1, 2]},
+		{"name": "nondeterministic", "selector": "$.*", "document": {"a": 1, "b": 2}, "results": [[1, 2], [2, 1]]},
+		{"name": "missing", "selector": "$.b", "document": {"a": 1}, "result": []},
+		{"name": "comparison", "selector": "$[?(@.a == 1)]", "document": [{"a": 1}, {"a": "1"}], "result": [{"a": 1}]},
+		{"name": "invalid", "selector": "$[", "invalid_selector": true},
+		{"name": "not rejected", "selector": "$.a", "document": {"a": 1}, "invalid_selector": true},
+		{"name": "different", "selector": "$.a", "document": {"a": 1}, "result": [2]}
+	]}`
+	comparison := `{"queries": [
+		{"id": "unordered", "selector": "$.*", "document": {"a": 1, "b": 2}, "consensus": [2, 1], "ordered": false},
+		{"id": "scalar", "selector": "$.a", "document": {"a": 1}, "consensus": [1], "scalar-consensus": 1},
+		{"id": "no consensus", "selector": "$.a", "document": {"a": 1}},
+		{"id": "not supported", "selector": "$[", "document": {}, "consensus": "NOT_SUPPORTED"}
+	]}`
+
+	report := &Report{}
+	cases, err := LoadCTS(strings.NewReader(cts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.Run("cts", cases)
+	cases, err = LoadComparison(strings.NewReader(comparison))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.Run("comparison", cases)
+
+	expected := []string{Pass, Pass, Pass, Pass, Pass, Pass, Deviation, Deviation, Pass, Pass, Skip, Pass}
+	if len(report.Results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(report.Results))
+	}
+	for i, res := range report.Results {
+		if res.Status != expected[i] {
+			t.Errorf(res.Suite + "/" + res.Name + "\n\texpected `" + expected[i] + "`\n\tbut got  `" + res.Status + "`")
+		}
+	}
+	if report.Passed != 9 || report.Deviated != 2 || report.Skipped != 1 || report.Failed != 0 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.Markdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "| cts | 6 | 0 | 2 | 0 |") || !strings.Contains(buf.String(), "| cts | different | `$.a` | deviation | [1] |") {
+		t.Errorf("unexpected markdown:\n%s", buf.String())
+	}
+}
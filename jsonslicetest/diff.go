@@ -0,0 +1,212 @@
+package jsonslicetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var errNotSingular = errors.New("reference: path is not singular")
+
+// Divergence describes a semantic difference between jsonslice and the reference implementation
+type Divergence struct {
+	Path   string
+	Got    []byte // jsonslice result (empty if not found)
+	GotErr error  // jsonslice error
+	Want   []byte // reference result (empty if not found)
+	Reason string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("%s: %s: got `%s` (err %v), want `%s`", d.Path, d.Reason, d.Got, d.GotErr, d.Want)
+}
+
+// Diff evaluates a singular path ($.a.b, $['a'][0], $.a[-1]) with jsonslice and with the reference
+// implementation built on encoding/json and compares the results semantically.
+// Returns nil if the results agree.
+// An error is returned if the path is not singular or input is not a valid json.
+func Diff(input []byte, path string) (*Divergence, error) {
+	want, found, err := Reference(input, path)
+	if err != nil {
+		return nil, err
+	}
+	d := &Divergence{Path: path}
+	if found {
+		d.Want, _ = json.Marshal(want)
+	}
+	d.Got, d.GotErr = Replay(input, path)
+	switch {
+	case d.GotErr != nil:
+		d.Reason = "error"
+	case len(d.Got) == 0 && found:
+		d.Reason = "not found"
+	case len(d.Got) > 0 && !found:
+		d.Reason = "unexpected match"
+	case found:
+		got, err := decode(d.Got)
+		if err != nil {
+			d.Reason = "invalid result"
+		} else if !reflect.DeepEqual(normalize(got), normalize(want)) {
+			d.Reason = "value mismatch"
+		}
+	}
+	if d.Reason == "" {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// Reference evaluates a singular path using encoding/json.
+// Object members are addressed by keys, array elements by indexes (negative ones count from the end).
+// Numbers in the value are json.Number. Returns found = false if the path does not exist in input.
+func Reference(input []byte, path string) (value interface{}, found bool, err error) {
+	steps, err := parseSingular(path)
+	if err != nil {
+		return nil, false, err
+	}
+	value, err = decode(input)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if step.index {
+				return nil, false, nil
+			}
+			if value, found = v[step.key]; !found {
+				return nil, false, nil
+			}
+		case []interface{}:
+			if !step.index {
+				return nil, false, nil
+			}
+			i := step.n
+			if i < 0 {
+				i += len(v)
+			}
+			if i < 0 || i >= len(v) {
+				return nil, false, nil
+			}
+			value = v[i]
+		default:
+			return nil, false, nil
+		}
+	}
+	return value, true, nil
+}
+
+// decode unmarshals a single json value keeping numbers as json.Number
+func decode(input []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("reference: extra data after json value")
+	}
+	return v, nil
+}
+
+// tNumber is the canonical decimal text of a json number, see canonicalNumber
+type tNumber string
+
+// normalize converts json.Number values into a canonical form so that 1.0 and 1e0 compare equal.
+// Numbers are compared as decimal text rather than float64, so large integers that differ
+// only beyond float64 precision (9007199254740993 and 9007199254740992) do not compare equal.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		return canonicalNumber(t.String())
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = normalize(t[k])
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = normalize(t[i])
+		}
+	}
+	return v
+}
+
+// canonicalNumber returns significant digits and exponent of a json number: 1.50e2 -> 15e1, -0.0 -> 0e0
+func canonicalNumber(num string) tNumber {
+	s := strings.TrimPrefix(num, "-")
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil { // exponent out of range
+			return tNumber(num)
+		}
+		s, exp = s[:i], e
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		exp -= len(s) - i - 1
+		s = s[:i] + s[i+1:]
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0e0"
+	}
+	digits := strings.TrimRight(s, "0")
+	exp += len(s) - len(digits)
+	if num[0] == '-' {
+		digits = "-" + digits
+	}
+	return tNumber(digits + "e" + strconv.Itoa(exp))
+}
+
+type tStep struct {
+	key   string
+	n     int
+	index bool
+}
+
+// parseSingular parses a singular path: $, .key, ['key'], ["key"], [n]
+func parseSingular(path string) ([]tStep, error) {
+	if len(path) == 0 || path[0] != '$' {
+		return nil, errNotSingular
+	}
+	var steps []tStep
+	for i := 1; i < len(path); {
+		switch path[i] {
+		case '.':
+			s := i + 1
+			for i = s; i < len(path) && path[i] != '.' && path[i] != '['; i++ {
+			}
+			if i == s {
+				return nil, errNotSingular
+			}
+			key := path[s:i]
+			if key == "*" {
+				return nil, errNotSingular
+			}
+			steps = append(steps, tStep{key: key})
+		case '[':
+			e := strings.IndexByte(path[i:], ']')
+			if e < 0 {
+				return nil, errNotSingular
+			}
+			e += i
+			inner := path[i+1 : e]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, tStep{key: inner[1 : len(inner)-1]})
+			} else if n, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, tStep{n: n, index: true})
+			} else {
+				return nil, errNotSingular
+			}
+			i = e + 1
+		default:
+			return nil, errNotSingular
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,69 @@
+package jsonslicetest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+
+	tests := []struct {
+		Data   string
+		Path   string
+		Reason string // empty if no divergence expected
+	}{
+		{`{"a": {"b": [1, 2, 3]}}`, `$.a.b[1]`, ``},
+		{`{"a": {"b": [1, 2, 3]}}`, `$['a']["b"][-1]`, ``},
+		{`{"a": {"b": [1, 2, 3]}}`, `$.a`, ``},
+		{`{"a": 1.0}`, `$.a`, ``},
+		{`{"a": 1}`, `$.b`, ``},
+		{`{"a": [1]}`, `$.a[3]`, ``},
+		{`{"a": 1}`, `$`, ``},
+		// jsonslice dialect: $[0] addresses key "0" of an object
+		{`{"0": "zero"}`, `$[0]`, `unexpected match`},
+		// duplicate keys: encoding/json keeps the last one
+		{`{"a": 1, "a": 2}`, `$.a`, `value mismatch`},
+	}
+
+	for _, tst := range tests {
+		d, err := Diff([]byte(tst.Data), tst.Path)
+		if err != nil {
+			t.Errorf(tst.Path + " : " + err.Error())
+			continue
+		}
+		reason := ""
+		if d != nil {
+			reason = d.Reason
+		}
+		if reason != tst.Reason {
+			t.Errorf(tst.Path + "\n\texpected `" + tst.Reason + "`\n\tbut got  `" + reason + "`")
+		}
+	}
+
+	// numbers are compared as decimal text
+	numbers := []struct {
+		A, B  string
+		Equal bool
+	}{
+		{`1.0`, `1e0`, true},
+		{`150`, `1.50E+2`, true},
+		{`-0.0`, `0`, true},
+		{`[0.001]`, `[1e-3]`, true},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`{"a": 12345678901234567890}`, `{"a": 12345678901234567891}`, false},
+		{`1`, `"1e0"`, false},
+	}
+	for _, tst := range numbers {
+		a, errA := decode([]byte(tst.A))
+		b, errB := decode([]byte(tst.B))
+		if errA != nil || errB != nil || reflect.DeepEqual(normalize(a), normalize(b)) != tst.Equal {
+			t.Errorf("%s == %s: expected %v (errors %v, %v)", tst.A, tst.B, tst.Equal, errA, errB)
+		}
+	}
+
+	for _, path := range []string{`$.a[*]`, `$..a`, `$[1:2]`, `a`, `$.`} {
+		if _, err := Diff([]byte(`{}`), path); err == nil {
+			t.Errorf(path + " : error expected")
+		}
+	}
+}
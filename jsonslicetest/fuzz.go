@@ -0,0 +1,134 @@
+// Package jsonslicetest provides utilities for testing jsonslice and code built on it:
+// exporting seed corpora, replaying and minimizing fuzzer findings.
+package jsonslicetest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/bhmj/jsonslice"
+)
+
+// PanicError is returned by Replay when evaluation panics
+type PanicError struct {
+	Value interface{} // recovered value
+	Stack []byte      // stack trace of the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Replay evaluates path against input with the options recovering from panic.
+// A panic is returned as *PanicError.
+func Replay(input []byte, path string, opts ...jsonslice.Option) (res []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return jsonslice.GetWith(input, path, opts...)
+}
+
+// Crashes reports whether Replay panics on input and path
+func Crashes(input []byte, path string) bool {
+	var pe *PanicError
+	_, err := Replay(input, path)
+	return errors.As(err, &pe)
+}
+
+// Minimize shrinks input and path by removing chunks of bytes while fails still returns true.
+// If fails is nil, Crashes is used.
+// Returns the inputs unchanged if they do not fail in the first place.
+func Minimize(input []byte, path string, fails func(input []byte, path string) bool) ([]byte, string) {
+	if fails == nil {
+		fails = Crashes
+	}
+	if !fails(input, path) {
+		return input, path
+	}
+	input = shrink(input, func(b []byte) bool { return fails(b, path) })
+	path = string(shrink([]byte(path), func(b []byte) bool { return fails(input, string(b)) }))
+	return input, path
+}
+
+// shrink removes chunks of decreasing size from b while fails returns true
+func shrink(b []byte, fails func([]byte) bool) []byte {
+	b = append(b[:0:0], b...)
+	for n := len(b) / 2; n > 0; n /= 2 {
+		for i := 0; i+n <= len(b); {
+			cand := append(b[:i:i], b[i+n:]...)
+			if fails(cand) {
+				b = cand
+			} else {
+				i += n
+			}
+		}
+	}
+	return b
+}
+
+// ReadCorpusFile reads a file of Go fuzzing corpus (testdata/fuzz/FuzzGet/...) written by `go test -fuzz`.
+// Returns the input and the path stored in the file. Files of FuzzPath contain no input.
+func ReadCorpusFile(name string) (input []byte, path string, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	if !scanner.Scan() || scanner.Text() != "go test fuzz v1" {
+		return nil, "", errors.New("not a fuzzing corpus file")
+	}
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		switch {
+		case len(line) == 0:
+		case bytes.HasPrefix(line, []byte("[]byte(")) && line[len(line)-1] == ')':
+			s, err := strconv.Unquote(string(line[7 : len(line)-1]))
+			if err != nil {
+				return nil, "", err
+			}
+			input = []byte(s)
+		case bytes.HasPrefix(line, []byte("string(")) && line[len(line)-1] == ')':
+			path, err = strconv.Unquote(string(line[7 : len(line)-1]))
+			if err != nil {
+				return nil, "", err
+			}
+		default:
+			return nil, "", fmt.Errorf("unsupported corpus value: %s", line)
+		}
+	}
+	return input, path, scanner.Err()
+}
+
+// WriteCorpusFile writes input and path as a Go fuzzing corpus file of FuzzGet into dir
+// (testdata/fuzz/FuzzGet), creating dir if needed. The file is named after its contents
+// the way `go test -fuzz` names the files it writes, so writing the same entry twice is harmless.
+// Returns the name of the file.
+func WriteCorpusFile(dir string, input []byte, path string) (string, error) {
+	data := []byte("go test fuzz v1\n[]byte(" + strconv.Quote(string(input)) + ")\nstring(" + strconv.Quote(path) + ")\n")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%x", sha256.Sum256(data))[:16])
+	return name, os.WriteFile(name, data, 0644)
+}
+
+// ReplayFile replays a fuzzing corpus file.
+func ReplayFile(name string) ([]byte, error) {
+	input, path, err := ReadCorpusFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Replay(input, path)
+}
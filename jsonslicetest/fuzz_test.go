@@ -0,0 +1,76 @@
+package jsonslicetest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Minimize(t *testing.T) {
+	// pretend that any input containing "bad" along with a path containing "[" fails
+	fails := func(input []byte, path string) bool {
+		return bytes.Contains(input, []byte("bad")) && bytes.Contains([]byte(path), []byte("["))
+	}
+	input, path := Minimize([]byte(`{"a": [1, 2, "bad", 4]}`), `$.a[2]`, fails)
+	if string(input) != "bad" || path != "[" {
+		t.Errorf("unexpected minimization result: %q %q", input, path)
+	}
+
+	// no failure: unchanged
+	input, path = Minimize([]byte(`{"a": 1}`), `$.a`, nil)
+	if string(input) != `{"a": 1}` || path != `$.a` {
+		t.Errorf("unexpected minimization result: %q %q", input, path)
+	}
+}
+
+func Test_ReplayFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "crash")
+	corpus := "go test fuzz v1\n[]byte(\"{\\\"a\\\": [1, 2]}\")\nstring(\"$.a[1]\")\n"
+	if err := os.WriteFile(name, []byte(corpus), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ReplayFile(name)
+	if err != nil || string(res) != "2" {
+		t.Errorf("unexpected replay result: %q (%v)", res, err)
+	}
+
+	if err := os.WriteFile(name, []byte("something else"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReplayFile(name); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_WriteCorpusFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "FuzzGet")
+	seeds := []struct {
+		Input []byte
+		Path  string
+	}{
+		{[]byte(`{"a": [1, 2]}`), `$.a[1]`},
+		{[]byte("{\"k\": \"\\u00e9\\\"\"}\n\t"), `$["k"]`},
+		{[]byte{0xff, '\x00', '"'}, "$.\u00e9"},
+		{nil, ``},
+	}
+	for _, seed := range seeds {
+		name, err := WriteCorpusFile(dir, seed.Input, seed.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, path, err := ReadCorpusFile(name)
+		if err != nil || !bytes.Equal(input, seed.Input) || path != seed.Path {
+			t.Errorf("round trip of %q %q: got %q %q (%v)", seed.Input, seed.Path, input, path, err)
+		}
+	}
+	if files, _ := os.ReadDir(dir); len(files) != len(seeds) {
+		t.Errorf("expected %d files, got %d", len(seeds), len(files))
+	}
+	// same entry, same file
+	a, _ := WriteCorpusFile(dir, seeds[0].Input, seeds[0].Path)
+	b, _ := WriteCorpusFile(dir, seeds[0].Input, seeds[0].Path)
+	if a != b {
+		t.Errorf("same entry written into %s and %s", a, b)
+	}
+}
@@ -0,0 +1,175 @@
+package jsonslice
+
+import "math"
+
+// limit(n) and offset(n) applied to an aggregated result ($..book[?(@.isbn)].limit(1)) select a page of it:
+// the path is evaluated by walk which stops as soon as the page is complete. The rest of the path is applied
+// to every value of the page ($..book[*].limit(2).title).
+// Applied to a single array ($.tags.limit(2)) or used in filters (limit(@.tags, 2)) they select the elements.
+
+// pageFunctions are applied to the whole result of an aggregating path rather than to every value
+var pageFunctions = map[string]bool{"limit": true, "offset": true}
+
+// fnPage returns a function selecting the elements of an array: the first n (limit) or all but the first n (offset)
+func fnPage(limit bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, errPathInvalidExpression
+		}
+		elems, ok := argArray(args[0])
+		n, okn, err := argCount(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if !ok || !okn {
+			return nil, nil
+		}
+		if limit {
+			return page(elems, 0, n), nil
+		}
+		return page(elems, n, -1), nil
+	}
+}
+
+// page returns an array of take (all if negative) elements following the first skip ones
+func page(elems [][]byte, skip, take int) []byte {
+	if skip > len(elems) {
+		skip = len(elems)
+	}
+	elems = elems[skip:]
+	if take >= 0 && take < len(elems) {
+		elems = elems[:take]
+	}
+	res := []byte{'['}
+	for k, el := range elems {
+		if k > 0 {
+			res = append(res, ',')
+		}
+		res = append(res, el...)
+	}
+	return append(res, ']')
+}
+
+// argCount returns a non-negative integer argument, a negative number is an error
+func argCount(val []byte) (int, bool, error) {
+	n, ok := argNumber(val)
+	if ok && n < 0 {
+		return 0, false, errPathInvalidExpression
+	}
+	if !ok || n != math.Trunc(n) || n > math.MaxInt32 {
+		return 0, false, nil
+	}
+	return int(n), true, nil
+}
+
+// pageStart returns the first node of the first run of limit/offset nodes following an aggregating path,
+// nil if there is none
+func pageStart(node *tNode) (prev, start *tNode) {
+	var p *tNode
+	for n := node; n != nil; p, n = n, n.Next {
+		if p == nil || !pageNode(n) || pageNode(p) {
+			continue
+		}
+		p.Next = nil
+		agg := aggregates(node)
+		p.Next = n
+		if agg {
+			return p, n
+		}
+	}
+	return nil, nil
+}
+
+// pageNode returns true if the node calls limit() or offset()
+func pageNode(n *tNode) bool {
+	return n.Type&cFunction > 0 && len(n.Calls) > 0 && pageFunctions[n.Calls[len(n.Calls)-1].name]
+}
+
+// getPage evaluates an aggregating path (up to prev) followed by limit/offset nodes (start)
+// and applies the rest of the path to every value of the page
+func getPage(input []byte, node, prev, start *tNode) ([]byte, error) {
+	skip, take := 0, -1 // take < 0 means all
+	end := start
+	for n := start; n != nil && pageNode(n); n = n.Next {
+		end = n
+		call := n.Calls[len(n.Calls)-1]
+		val, err := evalArg(input, n, call.args[len(call.args)-1])
+		if err != nil {
+			return nil, err
+		}
+		count, ok, err := argCount(val)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		switch {
+		case call.name == "limit" && (take < 0 || count < take):
+			take = count
+		case call.name == "offset":
+			skip += count
+			if take >= 0 {
+				take -= count
+				if take < 0 {
+					take = 0
+				}
+			}
+		}
+	}
+	if take == 0 {
+		return []byte("[]"), nil
+	}
+
+	prev.Next = nil
+	res, err := getRange(input, node, skip, take)
+	prev.Next = start
+	if err != nil || len(res) == 0 || end.Next == nil {
+		return res, err
+	}
+	return getEach(res, end.Next) // $..book[*].limit(2).title
+}
+
+// getEach applies the node list to every element of the array given: $[*] followed by node
+func getEach(input []byte, node *tNode) ([]byte, error) {
+	each, err := parsePath("$[*]")
+	if err != nil {
+		return nil, err
+	}
+	each.ctx, each.root, each.Next = node.ctx, node.root, node
+	res, err := getResult(input, each)
+	each.Next = nil
+	repool(each)
+	return res, err
+}
+
+// getRange returns an array of take (all if negative) values of the result of an aggregating path
+// following the first skip ones
+func getRange(input []byte, node *tNode, skip, take int) ([]byte, error) {
+	var res []byte
+	matched := 0
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			matched++
+			if matched > skip {
+				res = plus(res, input[m.start:m.end])
+			}
+			return take < 0 || matched < skip+take, nil
+		},
+	}
+	w.options(node.ctx)
+	_, err := walkInput(input, node, w)
+	if err == errNotAddressable {
+		// a function or a name selector in the path: evaluate the whole result and take a page of it
+		res, err = getResult(input, node)
+		if err != nil || len(res) == 0 {
+			return res, err
+		}
+		elems, _ := argArray(res)
+		return page(elems, skip, take), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{'['}, res...), ']'), nil
+}
@@ -0,0 +1,67 @@
+package jsonslice
+
+import "testing"
+
+func Test_Limit(t *testing.T) {
+
+	input := []byte(`{"n": 2, "store": {"book": [{"t": "a", "isbn": "1", "p": 8}, {"t": "b", "p": 12}, {"t": "c", "isbn": "2", "p": 9}, {"t": "d", "isbn": "3", "p": 22}], "bicycle": {"p": 20}}, "tags": ["x", "y", "z"]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$..book[?(@.isbn)].limit(1)`, []byte(`[{"t": "a", "isbn": "1", "p": 8}]`)},
+		{`$..book[?(@.isbn)].t.limit(2)`, []byte(`["a","c"]`)},
+		{`$..book[*].t.offset(1).limit(2)`, []byte(`["b","c"]`)},
+		{`$..book[*].t.limit(3).offset(1)`, []byte(`["b","c"]`)},
+		{`$..book[*].t.offset(3)`, []byte(`["d"]`)},
+		{`$..book[*].t.offset(9)`, []byte(`[]`)},
+		{`$..book[*].t.limit($.n)`, []byte(`["a","b"]`)},
+		{`$..book[*].t.limit(0)`, []byte(`[]`)},
+		{`$..book[9].limit(1)`, []byte(`[]`)},
+		{`$..p.limit(3).sum()`, []byte(`29`)},
+		{`$.store.book[*].p.offset(2).max()`, []byte(`22`)},
+		{`$..isbn.^.t.limit(2)`, []byte(`["a","c"]`)},
+		{`$.tags.limit(2)`, []byte(`["x","y"]`)},
+		{`$.tags.offset(2)`, []byte(`["z"]`)},
+		{`$.store.book[?("y" in limit($.tags, 2))].t.limit(1)`, []byte(`["a"]`)},
+		// the rest of the path is applied to every value of the page
+		{`$.store.book[*].limit(2).t`, []byte(`["a","b"]`)},
+		{`$.store.book[*].offset(3).t`, []byte(`["d"]`)},
+		{`$.store.book[*].offset(1).limit(2).t`, []byte(`["b","c"]`)},
+		{`$..book[?(@.isbn)].limit(2).p.sum()`, []byte(`17`)},
+		{`$..book[*].offset(1).t.limit(1)`, []byte(`["b"]`)},
+		{`$..book[*].limit(3).sortDesc(@.p)[0].t`, []byte(`"b"`)},
+		{`$..book[*].limit(0).t`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	for _, path := range []string{`$..book[*].t.limit(-1)`, `$..book[?(@.p > 10)].offset(-2)`, `$.tags.limit(-1)`, `$[?(limit(@.tags, -1))]`} {
+		if _, err := Get(input, path); err != errPathInvalidExpression {
+			t.Errorf(path+" : expected errPathInvalidExpression, got %v", err)
+		}
+	}
+
+	// a page of a result is the same as the result
+	for _, path := range []string{`$.store.book[*].t`, `$..p`, `$.store.book[?(@.p > 8)]`, `$.store..t`} {
+		expected, err := Get(input, path)
+		if err != nil {
+			t.Errorf(path + " : " + err.Error())
+			continue
+		}
+		res, err := Get(input, path+".limit(100)")
+		if err != nil {
+			t.Errorf(path + " : " + err.Error())
+		} else if compareSlices(res, expected) != 0 {
+			t.Errorf(path + ".limit(100)\n\texpected `" + string(expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
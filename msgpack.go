@@ -0,0 +1,209 @@
+package jsonslice
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MessagePack values are transcoded to json as follows: nil is null, bin and ext data are base64-encoded strings,
+// timestamps (ext -1) are RFC 3339 strings, map keys of other types than string are quoted (1 becomes "1").
+
+// tMsgPack is the state of MessagePack decoding
+type tMsgPack struct {
+	tBinReader
+}
+
+// decodeMsgPack transcodes a MessagePack value into json
+func decodeMsgPack(input []byte) ([]byte, error) {
+	d := &tMsgPack{tBinReader{input: input}}
+	buf, err := d.value(make([]byte, 0, len(input)*2), 0)
+	if err == nil && d.pos < len(input) {
+		err = errDecodeTrailingData
+	}
+	if err != nil {
+		return nil, decodeError("msgpack", d.pos, err)
+	}
+	return buf, nil
+}
+
+// length reads a length of n bytes
+func (d *tMsgPack) length(n int) (int, error) {
+	u, err := d.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	return d.checkLength(u)
+}
+
+// value appends the next value of the input to buf as json
+func (d *tMsgPack) value(buf []byte, depth int) ([]byte, error) {
+	if depth > maxDecodeDepth {
+		return nil, errDecodeTooDeep
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f: // positive fixint
+		return strconv.AppendUint(buf, uint64(c), 10), nil
+	case c >= 0xe0: // negative fixint
+		return strconv.AppendInt(buf, int64(int8(c)), 10), nil
+	case c <= 0x8f: // fixmap
+		return d.object(buf, int(c&0x0f), depth)
+	case c <= 0x9f: // fixarray
+		return d.array(buf, int(c&0x0f), depth)
+	case c <= 0xbf: // fixstr
+		return d.str(buf, int(c&0x1f))
+	}
+	var n int
+	switch c {
+	case 0xc0:
+		return append(buf, "null"...), nil
+	case 0xc2:
+		return append(buf, "false"...), nil
+	case 0xc3:
+		return append(buf, "true"...), nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		if n, err = d.length(1 << (c - 0xc4)); err != nil {
+			return nil, err
+		}
+		data, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return appendBase64(buf, data), nil
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		if n, err = d.length(1 << (c - 0xc7)); err != nil {
+			return nil, err
+		}
+		return d.ext(buf, n)
+	case 0xca: // float 32
+		u, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return appendFloat(buf, float64(math.Float32frombits(uint32(u))), 32), nil
+	case 0xcb: // float 64
+		u, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return appendFloat(buf, math.Float64frombits(u), 64), nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendUint(buf, u, 10), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size // sign extension
+		return strconv.AppendInt(buf, int64(u<<shift)>>shift, 10), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return d.ext(buf, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		if n, err = d.length(1 << (c - 0xd9)); err != nil {
+			return nil, err
+		}
+		return d.str(buf, n)
+	case 0xdc, 0xdd: // array 16, 32
+		if n, err = d.length(2 << (c - 0xdc)); err != nil {
+			return nil, err
+		}
+		return d.array(buf, n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		if n, err = d.length(2 << (c - 0xde)); err != nil {
+			return nil, err
+		}
+		return d.object(buf, n, depth)
+	}
+	d.pos--
+	return nil, errDecodeUnsupported // 0xc1 is never used
+}
+
+// str appends a string of n bytes
+func (d *tMsgPack) str(buf []byte, n int) ([]byte, error) {
+	str, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return jsonQuote(buf, str), nil
+}
+
+// array appends an array of n elements
+func (d *tMsgPack) array(buf []byte, n int, depth int) ([]byte, error) {
+	var err error
+	buf = append(buf, '[')
+	for k := 0; k < n; k++ {
+		if k > 0 {
+			buf = append(buf, ',')
+		}
+		if buf, err = d.value(buf, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, ']'), nil
+}
+
+// object appends an object of n key/value pairs
+func (d *tMsgPack) object(buf []byte, n int, depth int) ([]byte, error) {
+	var err error
+	buf = append(buf, '{')
+	for k := 0; k < n; k++ {
+		if k > 0 {
+			buf = append(buf, ',')
+		}
+		start, pos := len(buf), d.pos
+		if buf, err = d.value(buf, depth+1); err != nil {
+			return nil, err
+		}
+		if buf, err = appendDecodedKey(buf, start); err != nil {
+			d.pos = pos
+			return nil, err
+		}
+		buf = append(buf, ':')
+		if buf, err = d.value(buf, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// ext appends an extension value of n bytes: a timestamp as a string, other types as base64-encoded data
+func (d *tMsgPack) ext(buf []byte, n int) ([]byte, error) {
+	typ, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ[0]) != -1 {
+		return appendBase64(buf, data), nil
+	}
+	var t time.Time
+	switch n {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(u&0x3ffffffff), int64(u>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data)))
+	default:
+		d.pos -= n + 1
+		return nil, errDecodeUnsupported
+	}
+	buf = append(buf, '"')
+	buf = t.UTC().AppendFormat(buf, time.RFC3339Nano)
+	return append(buf, '"'), nil
+}
@@ -0,0 +1,210 @@
+package jsonslice
+
+import "sort"
+
+// tMultiItem is a path of GetMulti: the index of the result and the rest of the node list
+type tMultiItem struct {
+	k    int
+	node *tNode
+}
+
+// GetMulti returns the results of several jsonpaths scanning the common parts of input once:
+// objects addressed by singular keys ($.a.b, $['a'].c) are scanned once for all the paths going through them,
+// the rest of a path (indexes, slices, filters, etc) is evaluated on the value found.
+// results[k] is the same as the result of Get(input, paths[k]). The first error encountered is returned.
+func GetMulti(input []byte, paths []string) ([][]byte, error) {
+	return getMulti(input, paths, nil)
+}
+
+// GetUnion returns the values matching any of the jsonpaths as a single array, in the order of the paths:
+// GetUnion(input, "$.a.b", "$.c[0]", "$..d"). The paths are evaluated at once as by GetMulti.
+// Every value of an aggregating path is added separately, a path matching nothing adds nothing.
+func GetUnion(input []byte, paths ...string) ([]byte, error) {
+	agg := make([]bool, len(paths))
+	results, err := getMulti(input, paths, agg)
+	if err != nil {
+		return nil, err
+	}
+	res := []byte{'['}
+	for k, val := range results {
+		if agg[k] && len(val) > 1 {
+			val = val[1 : len(val)-1] // [a,b,c] -> a,b,c
+		}
+		if len(val) == 0 {
+			continue
+		}
+		if len(res) > 1 {
+			res = append(res, ',')
+		}
+		res = append(res, val...)
+	}
+	return append(res, ']'), nil
+}
+
+// GetMap returns the results of several labeled jsonpaths evaluated at once as by GetMulti, keyed by the labels:
+// GetMap(input, map[string]string{"id": "$.order.id", "user": "$.user.name"}).
+// A label of a path matching nothing is not in the map. The first error encountered is returned.
+func GetMap(input []byte, paths map[string]string) (map[string][]byte, error) {
+	labels := make([]string, 0, len(paths))
+	for label := range paths {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels) // the same error for the same paths
+	list := make([]string, len(labels))
+	for k, label := range labels {
+		list[k] = paths[label]
+	}
+	results, err := getMulti(input, list, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]byte, len(labels))
+	for k, label := range labels {
+		if len(results[k]) > 0 {
+			res[label] = results[k]
+		}
+	}
+	return res, nil
+}
+
+// getMulti evaluates paths as GetMulti does. agg (if not nil) receives true for every path
+// whose result is an array of the matched values.
+func getMulti(input []byte, paths []string, agg []bool) ([][]byte, error) {
+	items := make([]tMultiItem, 0, len(paths))
+	defer func() {
+		for _, it := range items {
+			repool(it.node)
+		}
+	}()
+	results := make([][]byte, len(paths))
+	for k, path := range paths {
+		if len(path) == 1 && path[0] == '$' {
+			results[k] = input
+			continue
+		}
+		node, err := parsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, tMultiItem{k, node})
+		if agg != nil {
+			agg[k] = aggregates(node)
+		}
+		if err = checkFunctions(node, nil); err != nil {
+			return nil, err
+		}
+		evalRootRefs(input, node)
+	}
+	if err := multiGet(input, items, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// aggregates returns true if the result of the node list is an array of the matched values,
+// i.e. the path aggregates and does not end with an aggregate function ($.a[*].price.sum())
+func aggregates(node *tNode) bool {
+	agg := false
+	var last *tNode
+	for n := node; n != nil; n = n.Next {
+		agg = agg || n.Type&(cAgg|cSlice|cDeep|cWild|cFilter|cUnion) > 0
+		last = n
+	}
+	if agg && len(last.Calls) > 0 && last.Type&cFunction > 0 {
+		call := last.Calls[len(last.Calls)-1]
+		agg = !aggregateFunctions[call.name] || len(call.args) > 0
+	}
+	return agg
+}
+
+// multiGet evaluates items on input writing the results
+func multiGet(input []byte, items []tMultiItem, results [][]byte) error {
+	if len(input) == 0 {
+		return nil
+	}
+	i, _ := skipSpaces(input, 0)
+	var keyed []tMultiItem
+	for _, it := range items {
+		if it.node != nil && singular(it.node) && !hasNode(it.node, cParent|cName) && i < len(input) && input[i] == '{' {
+			keyed = append(keyed, it)
+			continue
+		}
+		res, err := getResult(input, it.node)
+		if err != nil {
+			return err
+		}
+		results[it.k] = res
+	}
+	if len(keyed) == 0 {
+		return nil
+	}
+	return multiObject(input[i:], keyed, results)
+}
+
+// multiObject scans an object once looking for the first keys of items
+func multiObject(input []byte, items []tMultiItem, results [][]byte) error {
+	var (
+		key []byte
+		err error
+	)
+	vals := make([][]byte, len(items))
+	found := 0
+	l := len(input)
+	i := 1 // skip '{'
+	for i < l && input[i] != '}' && found < len(items) {
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			break
+		}
+		if i, err = skipSpaces(input, i); err != nil {
+			return err
+		}
+		e, err := skipValue(input, i)
+		if err != nil {
+			return err
+		}
+		for n, it := range items {
+			if matchKeys(key, it.node.Keys[0]) {
+				if vals[n] == nil {
+					vals[n] = input[i:e]
+					found++
+				}
+			}
+		}
+		if i, err = skipSpaces(input, e); err != nil {
+			return err
+		}
+	}
+	if found < len(items) && i == l {
+		return errUnexpectedEnd
+	}
+	// group the rest of the paths by the value found
+	done := make([]bool, len(items))
+	for n := range items {
+		if done[n] || vals[n] == nil {
+			continue
+		}
+		var group []tMultiItem
+		for m := n; m < len(items); m++ {
+			if !done[m] && vals[m] != nil && &vals[m][0] == &vals[n][0] {
+				group = append(group, tMultiItem{items[m].k, items[m].node.Next})
+				done[m] = true
+			}
+		}
+		if err = multiGet(vals[n], group, results); err != nil {
+			return err
+		}
+	}
+	// nothing found after the key: Get would try the following occurrences of a duplicate key
+	for n, it := range items {
+		if len(results[it.k]) == 0 && vals[n] != nil && it.node.Next != nil {
+			if results[it.k], err = getResult(input, it.node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
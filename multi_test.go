@@ -0,0 +1,135 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_GetMulti(t *testing.T) {
+
+	paths := []string{
+		`$`,
+		`$.store.book[0].title`,
+		`$.store.book[-1].author`,
+		`$.store.bicycle.color`,
+		`$.store.bicycle`,
+		`$.store.book[?(@.price > 10)].title`,
+		`$.store.book.length()`,
+		`$.expensive`,
+		`$['store']['bicycle']['price']`,
+		`$.store.nothing.here`,
+		`$..price`,
+		`$.store.*`,
+		`$.store.book[*].price.sum()`,
+		`$.store.bicycle.price.max()`,
+	}
+	for _, tst := range expressionTests() {
+		paths = append(paths, tst.Query)
+	}
+
+	results, err := GetMulti(data, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, path := range paths {
+		expected, _ := Get(data, path)
+		if compareSlices(results[k], expected) != 0 {
+			t.Errorf(path + "\n\texpected `" + string(expected) + "`\n\tbut got  `" + string(results[k]) + "`")
+		}
+	}
+
+	// duplicate keys
+	input := []byte(`{"a": {"x": 1}, "a": {"y": 2}}`)
+	results, err = GetMulti(input, []string{`$.a.x`, `$.a.y`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, path := range []string{`$.a.x`, `$.a.y`} {
+		expected, _ := Get(input, path)
+		if compareSlices(results[k], expected) != 0 {
+			t.Errorf(path + "\n\texpected `" + string(expected) + "`\n\tbut got  `" + string(results[k]) + "`")
+		}
+	}
+
+	// errors
+	if _, err = GetMulti(data, []string{`$.store`, `$.`}); err == nil {
+		t.Errorf("invalid path: error expected")
+	}
+	if _, err = GetMulti([]byte(`{"a": 1, "b": `), []string{`$.a`, `$.b`}); err == nil {
+		t.Errorf("invalid input: error expected")
+	}
+}
+
+func Test_GetUnion(t *testing.T) {
+
+	input := []byte(`{"a": {"b": 1}, "c": [{"d": 2}, 3], "x": {"d": [4, 5]}}`)
+	tests := []struct {
+		Paths    []string
+		Expected []byte
+	}{
+		{[]string{`$.a.b`, `$.c[0]`, `$..d`}, []byte(`[1,{"d": 2},2,[4, 5]]`)},
+		{[]string{`$.x.d[*]`, `$.a.b`, `$.x.d[0]`}, []byte(`[4,5,1,4]`)}, // duplicates are kept
+		{[]string{`$.nothing`, `$.c[5:]`, `$.c[?(@.e)]`}, []byte(`[]`)},
+		{[]string{`$.x.d[*].sum()`, `$.a.keys()`}, []byte(`[9,["b"]]`)}, // single values
+		{[]string{}, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetUnion(input, tst.Paths...)
+		if err != nil {
+			t.Errorf("%v : %s", tst.Paths, err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf("%v\n\texpected `%s`\n\tbut got  `%s`", tst.Paths, tst.Expected, res)
+		}
+	}
+
+	if _, err := GetUnion(input, `$.a`, `$.`); err == nil {
+		t.Errorf("invalid path: error expected")
+	}
+}
+
+func Test_GetMap(t *testing.T) {
+
+	input := []byte(`{"order": {"id": 42, "items": [{"sku": "x1"}, {"sku": "y2"}]}, "user": {"name": "John"}}`)
+	paths := map[string]string{
+		"id":    `$.order.id`,
+		"skus":  `$.order.items[*].sku`,
+		"count": `$.order.items.length()`,
+		"user":  `$.user.name`,
+		"root":  `$`,
+		"none":  `$.user.email`,
+	}
+	expected := map[string]string{
+		"id":    `42`,
+		"skus":  `["x1","y2"]`,
+		"count": `2`,
+		"user":  `"John"`,
+		"root":  string(input),
+	}
+
+	res, err := GetMap(input, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(expected) {
+		t.Errorf("%d values expected, got %d", len(expected), len(res))
+	}
+	for label, val := range expected {
+		if string(res[label]) != val {
+			t.Errorf(label + "\n\texpected `" + val + "`\n\tbut got  `" + string(res[label]) + "`")
+		}
+	}
+
+	if _, err := GetMap(input, map[string]string{"a": `$.a`, "b": `$.`}); err == nil {
+		t.Errorf("invalid path: error expected")
+	}
+}
+
+func Benchmark_Jsonslice_GetMulti_10Mb(b *testing.B) {
+	b.StopTimer()
+	largeData := GenerateLargeData()
+	paths := []string{`$.store.book[0].title`, `$.store.book[100000].title`, `$.store.book.length()`}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = GetMulti(largeData, paths)
+	}
+}
@@ -0,0 +1,273 @@
+package jsonslice
+
+import (
+	"sort"
+)
+
+// tEdit is a replacement of input[start:end] with value
+type tEdit struct {
+	start int
+	end   int
+	value []byte
+}
+
+// CopyValue reads the value matching fromPath and writes it to the location(s) matching toPath.
+// Missing keys along toPath are created (see Set).
+// Returns the modified copy of input.
+func CopyValue(input []byte, fromPath, toPath string) ([]byte, error) {
+	val, err := Get(input, fromPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(val) == 0 {
+		return nil, errFieldNotFound
+	}
+	return Set(input, toPath, val)
+}
+
+// Set replaces every value matching path with a raw json value.
+// If a singular key ($.a, $['a']) is absent it is created along with the rest of the path,
+// the next index of an array ($.arr[3] for a 3-element array) is appended.
+// Returns the modified copy of input or errFieldNotFound if nothing has been written.
+func Set(input []byte, path string, value []byte) ([]byte, error) {
+	if err := checkValue(value); err != nil {
+		return nil, err
+	}
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	if node == nil {
+		return append([]byte{}, value...), nil
+	}
+	evalRootRefs(input, node)
+
+	var edits []tEdit
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			edits = append(edits, tEdit{m.start, m.end, value})
+			return true, nil
+		},
+		missing: func(at int, comma, array bool, nod *tNode) error {
+			ins, err := buildMember(nod, value, comma, array)
+			if err != nil {
+				return err
+			}
+			edits = append(edits, tEdit{at, at, ins})
+			return nil
+		},
+	}
+	if _, err = walkInput(input, node, w); err != nil {
+		return nil, err
+	}
+	if len(edits) == 0 {
+		return nil, errFieldNotFound
+	}
+	return applyEdits(input, edits), nil
+}
+
+// MapWhere replaces every value matching path with the result of fn applied to that value.
+// fn is called in document order; values nested within another match (deepscan) are rewritten
+// only as a part of the outermost one.
+// Returns the modified copy of input.
+func MapWhere(input []byte, path string, fn func(elem []byte) ([]byte, error)) ([]byte, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	evalRootRefs(input, node)
+
+	var edits []tEdit
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			edits = append(edits, tEdit{start: m.start, end: m.end})
+			return true, nil
+		},
+	}
+	if _, err = walkInput(input, node, w); err != nil {
+		return nil, err
+	}
+	edits = outermost(edits)
+	for i := range edits {
+		val, err := fn(input[edits[i].start:edits[i].end:edits[i].end])
+		if err != nil {
+			return nil, err
+		}
+		if err = checkValue(val); err != nil {
+			return nil, err
+		}
+		edits[i].value = val
+	}
+	return applyEdits(input, edits), nil
+}
+
+// Delete removes every value matching path: object members along with their keys, array elements.
+// Separating commas are removed accordingly, the rest of the formatting is kept as is.
+// Returns the modified copy of input (unchanged if nothing matches).
+func Delete(input []byte, path string) ([]byte, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	if node == nil {
+		return nil, errRootNotDeletable
+	}
+	evalRootRefs(input, node)
+
+	var edits []tEdit
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			edits = append(edits, tEdit{start: memberStart(input, m.start), end: m.end})
+			return true, nil
+		},
+	}
+	if _, err = walkInput(input, node, w); err != nil {
+		return nil, err
+	}
+	return applyEdits(input, commaRuns(input, outermost(edits))), nil
+}
+
+// memberStart returns the start of an object member (its key) or an array element holding the value at input[s]
+func memberStart(input []byte, s int) int {
+	i := prevToken(input, s)
+	if i < 0 || input[i] != ':' {
+		return s
+	}
+	// object member: move to the opening quote of the key
+	for i = prevToken(input, i) - 1; i > 0; i-- {
+		if input[i] == '"' && !escaped(input, i) {
+			break
+		}
+	}
+	return i
+}
+
+// commaRuns joins the deleted members (in document order) separated by a comma only into runs and extends
+// every run with a separating comma: the following one or, if the run ends the object (array), the preceding one
+func commaRuns(input []byte, edits []tEdit) []tEdit {
+	res := edits[:0]
+	for k := 0; k < len(edits); {
+		run := edits[k]
+		for k++; k < len(edits); k++ {
+			c := nextToken(input, run.end)
+			if c == len(input) || input[c] != ',' || nextToken(input, c+1) != edits[k].start {
+				break
+			}
+			run.end = edits[k].end
+		}
+		if c := nextToken(input, run.end); c < len(input) && input[c] == ',' {
+			run.end = nextToken(input, c+1) // not the last one: remove up to the next member
+		} else if c = prevToken(input, run.start); c >= 0 && input[c] == ',' {
+			run.start = c
+		}
+		res = append(res, run)
+	}
+	return res
+}
+
+// prevToken returns the position of the last non-space character before input[i] (-1 if none)
+func prevToken(input []byte, i int) int {
+	for i--; i >= 0 && bytein(input[i], []byte{' ', '\t', '\r', '\n'}); i-- {
+	}
+	return i
+}
+
+// nextToken returns the position of the first non-space character starting from input[i]
+func nextToken(input []byte, i int) int {
+	for ; i < len(input) && bytein(input[i], []byte{' ', '\t', '\r', '\n'}); i++ {
+	}
+	return i
+}
+
+// escaped returns true if input[i] is preceded by an odd number of backslashes
+func escaped(input []byte, i int) bool {
+	n := 0
+	for i--; i >= 0 && input[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// buildMember builds a new object member (or array element) for an absent node
+// followed by the rest of the path.
+func buildMember(nod *tNode, value []byte, comma, array bool) ([]byte, error) {
+	var res []byte
+	if comma {
+		res = append(res, ',')
+	}
+	if !array {
+		res = append(jsonQuote(res, nod.Keys[0]), ':')
+	}
+	return buildValue(res, nod.Next, value)
+}
+
+// buildValue appends value wrapped into objects for every node left in the path
+func buildValue(res []byte, nod *tNode, value []byte) ([]byte, error) {
+	if nod == nil {
+		return append(res, value...), nil
+	}
+	if !singular(nod) {
+		return nil, errPathNotCreatable
+	}
+	res = jsonQuote(append(res, '{'), nod.Keys[0])
+	res, err := buildValue(append(res, ':'), nod.Next, value)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, '}'), nil
+}
+
+// outermost sorts edits in document order and removes the ones nested within a previous edit (deepscan)
+// or duplicating it ($[0,0]).
+func outermost(edits []tEdit) []tEdit {
+	sort.SliceStable(edits, func(a, b int) bool { return edits[a].start < edits[b].start })
+	res := edits[:0]
+	pos := 0
+	for _, e := range edits {
+		if e.start < pos {
+			continue
+		}
+		res = append(res, e)
+		pos = e.end
+	}
+	return res
+}
+
+// applyEdits returns a copy of input with edits applied.
+// Edits nested within a previous edit (deepscan) are skipped.
+func applyEdits(input []byte, edits []tEdit) []byte {
+	edits = outermost(edits)
+	size := len(input)
+	for _, e := range edits {
+		size += len(e.value) - (e.end - e.start)
+	}
+	res := make([]byte, 0, size)
+	pos := 0
+	for _, e := range edits {
+		res = append(res, input[pos:e.start]...)
+		res = append(res, e.value...)
+		pos = e.end
+	}
+	return append(res, input[pos:]...)
+}
+
+// checkValue makes sure value is a single json value
+func checkValue(value []byte) error {
+	i, err := skipSpaces(value, 0)
+	if err != nil {
+		return errInvalidValue
+	}
+	e, err := skipValue(value, i)
+	if err != nil || e == i {
+		return errInvalidValue
+	}
+	for ; e < len(value); e++ {
+		if !bytein(value[e], []byte{' ', '\t', '\r', '\n'}) {
+			return errInvalidValue
+		}
+	}
+	return nil
+}
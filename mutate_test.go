@@ -0,0 +1,220 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_CopyValue(t *testing.T) {
+
+	tests := []struct {
+		Data     []byte
+		From     string
+		To       string
+		Expected []byte
+	}{
+		// replace existing value
+		{[]byte(`{"a": 1, "b": 2}`), `$.a`, `$.b`, []byte(`{"a": 1, "b": 1}`)},
+		// create a key
+		{[]byte(`{"a": {"x": 1}}`), `$.a`, `$.b`, []byte(`{"a": {"x": 1},"b":{"x": 1}}`)},
+		// create a path
+		{[]byte(`{"v1": {"name": "foo"}, "v2": {}}`), `$.v1.name`, `$.v2.meta.title`, []byte(`{"v1": {"name": "foo"}, "v2": {"meta":{"title":"foo"}}}`)},
+		// create in an empty object
+		{[]byte(`{ }`), `$`, `$.a`, []byte(`{"a":{ } }`)},
+		// escaped key
+		{[]byte(`{"a": true}`), `$.a`, `$['new "key"']`, []byte(`{"a": true,"new \"key\"":true}`)},
+		// replace array element
+		{[]byte(`{"a": [1, 2, 3]}`), `$.a[0]`, `$.a[-1]`, []byte(`{"a": [1, 2, 1]}`)},
+		// append array element
+		{[]byte(`{"a": [1, 2, 3]}`), `$.a[0]`, `$.a[3]`, []byte(`{"a": [1, 2, 3,1]}`)},
+		// append to an empty array
+		{[]byte(`{"a": [], "b": 0}`), `$.b`, `$.a[0]`, []byte(`{"a": [0], "b": 0}`)},
+		// write to every filtered element
+		{[]byte(`[{"a":1,"b":0},{"b":0},{"a":2,"b":0}]`), `$[0].a`, `$[?(@.a)].b`, []byte(`[{"a":1,"b":1},{"b":0},{"a":2,"b":1}]`)},
+		// create in every filtered element
+		{[]byte(`[{"a":1},{"b":0},{"a":2}]`), `$[1].b`, `$[?(@.a)].c`, []byte(`[{"a":1,"c":0},{"b":0},{"a":2,"c":0}]`)},
+		// aggregated source
+		{[]byte(`{"a": [{"x":1},{"x":2}]}`), `$.a[:].x`, `$.xs`, []byte(`{"a": [{"x":1},{"x":2}],"xs":[1,2]}`)},
+		// deepscan: outermost match only
+		{[]byte(`{"a": {"a": 1}, "b": 0}`), `$.b`, `$..a`, []byte(`{"a": 0, "b": 0}`)},
+	}
+
+	for _, tst := range tests {
+		res, err := CopyValue(tst.Data, tst.From, tst.To)
+		if err != nil {
+			t.Errorf(tst.From + " -> " + tst.To + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.From + " -> " + tst.To + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_Set(t *testing.T) {
+
+	tests := []struct {
+		Data     []byte
+		Query    string
+		Value    []byte
+		Expected []byte
+	}{
+		// dot
+		{[]byte(`{"a": {"b": 1}}`), `$.a.b`, []byte(`"x"`), []byte(`{"a": {"b": "x"}}`)},
+		// bracket
+		{[]byte(`{"a b": 1}`), `$['a b']`, []byte(`[1, 2]`), []byte(`{"a b": [1, 2]}`)},
+		// index
+		{[]byte(`[1, 2, 3]`), `$[-2]`, []byte(`null`), []byte(`[1, null, 3]`)},
+		// filter
+		{[]byte(`[{"id": 1, "ok": false}, {"id": 2, "ok": false}]`), `$[?(@.id == 2)].ok`, []byte(`true`), []byte(`[{"id": 1, "ok": false}, {"id": 2, "ok": true}]`)},
+		// create
+		{[]byte(`{}`), `$.a.b`, []byte(`0`), []byte(`{"a":{"b":0}}`)},
+		// root
+		{[]byte(`{"a": 1}`), `$`, []byte(`{}`), []byte(`{}`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Set(tst.Data, tst.Query, tst.Value)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// invalid value
+	if _, err := Set([]byte(`{"a": 1}`), `$.a`, []byte(`1 2`)); err != errInvalidValue {
+		t.Errorf("invalid value: expected errInvalidValue, got %v", err)
+	}
+}
+
+func Test_CopyValueErrors(t *testing.T) {
+
+	tests := []struct {
+		Data     []byte
+		From     string
+		To       string
+		Expected string
+	}{
+		// source not found
+		{[]byte(`{"a": 1}`), `$.b`, `$.c`, `field not found`},
+		// non-singular path cannot be created
+		{[]byte(`{"a": {}}`), `$.a`, `$.b[*].c`, `path: cannot create non-singular node`},
+		// nowhere to write
+		{[]byte(`{"a": [1]}`), `$.a`, `$.a[5]`, `field not found`},
+		// function is not addressable
+		{[]byte(`{"a": [1]}`), `$.a`, `$.a.length()`, `path: function result is not addressable`},
+		// invalid path
+		{[]byte(`{"a": 1}`), `$.a`, `$.`, `path: unexpected end of path at 2`},
+	}
+
+	for _, tst := range tests {
+		_, err := CopyValue(tst.Data, tst.From, tst.To)
+		if err == nil {
+			t.Errorf(tst.From + " -> " + tst.To + " : error expected")
+		} else if err.Error() != tst.Expected {
+			t.Errorf(tst.From + " -> " + tst.To + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + err.Error() + "`")
+		}
+	}
+}
+
+func Test_MapWhere(t *testing.T) {
+
+	wrap := func(elem []byte) ([]byte, error) {
+		return append(append([]byte(`{"wrapped":`), elem...), '}'), nil
+	}
+	count := 0
+	number := func(elem []byte) ([]byte, error) {
+		count++
+		return []byte{byte('0' + count)}, nil
+	}
+
+	tests := []struct {
+		Data     []byte
+		Query    string
+		Fn       func([]byte) ([]byte, error)
+		Expected []byte
+	}{
+		// filter
+		{[]byte(`[{"price": 8}, {"price": 22}]`), `$[?(@.price > 20)].price`, wrap, []byte(`[{"price": 8}, {"price": {"wrapped":22}}]`)},
+		// slice
+		{[]byte(`[1, 2, 3]`), `$[1:]`, wrap, []byte(`[1, {"wrapped":2}, {"wrapped":3}]`)},
+		// document order
+		{[]byte(`["a", "b", "c"]`), `$[2,0]`, number, []byte(`[1, "b", 2]`)},
+		// deepscan: outermost only
+		{[]byte(`{"a": {"a": 1}}`), `$..a`, wrap, []byte(`{"a": {"wrapped":{"a": 1}}}`)},
+		// no match: unchanged
+		{[]byte(`{"a": 1}`), `$.b`, wrap, []byte(`{"a": 1}`)},
+	}
+
+	for _, tst := range tests {
+		res, err := MapWhere(tst.Data, tst.Query, tst.Fn)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// invalid callback result
+	_, err := MapWhere([]byte(`[1]`), `$[0]`, func([]byte) ([]byte, error) { return []byte(`{`), nil })
+	if err == nil {
+		t.Errorf("invalid value: error expected")
+	}
+}
+
+func Test_Delete(t *testing.T) {
+
+	tests := []struct {
+		Data     []byte
+		Query    string
+		Expected []byte
+	}{
+		// first member
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$.a`, []byte(`{"b": 2, "c": 3}`)},
+		// middle member
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$.b`, []byte(`{"a": 1, "c": 3}`)},
+		// last member
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$.c`, []byte(`{"a": 1, "b": 2}`)},
+		// the only member
+		{[]byte(`{"user": {"ssn": "123"}}`), `$.user.ssn`, []byte(`{"user": {}}`)},
+		// escaped key
+		{[]byte(`{"a": 1, "x\"y": {"z": 0}}`), `$['x"y']`, []byte(`{"a": 1}`)},
+		// several members
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$['a','c']`, []byte(`{"b": 2}`)},
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$['b','c']`, []byte(`{"a": 1}`)},
+		{[]byte(`{"a": 1, "b": 2, "c": 3}`), `$['a','b']`, []byte(`{"c": 3}`)},
+		{[]byte(`{"a":1,"b":2,"c":3}`), `$['b','c']`, []byte(`{"a":1}`)},
+		// array elements
+		{[]byte(`[1, 2, 3]`), `$[1]`, []byte(`[1, 3]`)},
+		{[]byte(`[1, 2, 3]`), `$[-1]`, []byte(`[1, 2]`)},
+		{[]byte(`[1, 2, 3]`), `$[*]`, []byte(`[]`)},
+		{[]byte(`[1, 2, 3, 4]`), `$[1:3]`, []byte(`[1, 4]`)},
+		{[]byte(`[1, 2, 3]`), `$[1:]`, []byte(`[1]`)},
+		{[]byte(`[1,2,3]`), `$[1:]`, []byte(`[1]`)},
+		{[]byte(`[1, 2, 3, 4, 5]`), `$[0,1,3,4]`, []byte(`[3]`)},
+		{[]byte(`[1, 2, 3, 4]`), `$[1,3]`, []byte(`[1, 3]`)},
+		// filter
+		{[]byte(`[{"id": 1}, {"id": 2}, {"id": 3}]`), `$[?(@.id != 2)]`, []byte(`[{"id": 2}]`)},
+		{[]byte(`[{"id": 1, "pwd": "x"}, {"id": 2}]`), `$[?(@.pwd)].pwd`, []byte(`[{"id": 1}, {"id": 2}]`)},
+		// deepscan
+		{[]byte(`{"a": {"ssn": 1, "b": {"ssn": 2}}, "ssn": 3}`), `$..ssn`, []byte(`{"a": {"b": {}}}`)},
+		// multiline
+		{[]byte("{\n  \"a\": 1,\n  \"b\": 2\n}"), `$.b`, []byte("{\n  \"a\": 1\n}")},
+		// no match: unchanged
+		{[]byte(`{"a": 1}`), `$.b`, []byte(`{"a": 1}`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Delete(tst.Data, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	if _, err := Delete([]byte(`{}`), `$`); err != errRootNotDeletable {
+		t.Errorf("root: expected errRootNotDeletable, got %v", err)
+	}
+	if _, err := Delete([]byte(`{"a": []}`), `$.a.length()`); err != errNotAddressable {
+		t.Errorf("function: expected errNotAddressable, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package jsonslice
+
+import "strconv"
+
+// A trailing ~ (as in JSONPath-Plus) selects the member names of the matched values instead of the values:
+// $.store.*~ is ["book","bicycle"]. Array elements are named by their indexes: $.store.book[?(@.isbn)]~ is [1,2].
+// The root has no name and selects nothing. Names are not addressable: ~ is only supported by Get and the like.
+
+// getNames evaluates a node list ending with ~
+func getNames(input []byte, node *tNode) ([]byte, error) {
+	if node.Type&cName > 0 { // $~
+		return nil, nil
+	}
+	prev := node
+	for prev.Next.Type&cName == 0 {
+		prev = prev.Next
+	}
+	last := prev.Next
+	prev.Next = nil
+	defer func() { prev.Next = last }()
+
+	var res []byte
+	w := &tWalker{
+		index: -1, // the root
+		match: func(m *tMatch) (bool, error) {
+			switch {
+			case m.key != nil:
+				res = jsonQuote(comma(res), m.key)
+			case m.index >= 0:
+				res = strconv.AppendInt(comma(res), int64(m.index), 10)
+			}
+			return true, nil
+		},
+	}
+	w.options(node.ctx)
+	if _, err := walkInput(input, node, w); err != nil {
+		return nil, err
+	}
+	if aggregates(node) {
+		return append(append([]byte{'['}, res...), ']'), nil
+	}
+	return res, nil
+}
+
+// comma appends a separator to a non-empty list
+func comma(list []byte) []byte {
+	if len(list) > 0 {
+		return append(list, ',')
+	}
+	return list
+}
@@ -0,0 +1,41 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_Names(t *testing.T) {
+
+	doc := []byte(`{"store":{"book":[{"t":"a","price":8},{"t":"b","price":12},{"t":"c","price":22}],"bicycle":{"price":19},"a\"b":1}}`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.*~`, []byte(`["book","bicycle","a\"b"]`)},
+		{`$.store.book[?(@.price>10)]~`, []byte(`[1,2]`)}, // array elements are named by index
+		{`$.store[?(@.price)]~`, []byte(`["bicycle"]`)},
+		{`$.store.bicycle~`, []byte(`"bicycle"`)},
+		{`$.store['a"b']~`, []byte(`"a\"b"`)},
+		{`$..price~`, []byte(`["price","price","price","price"]`)},
+		{`$.store.book[0].^~`, []byte(`"book"`)},
+		{`$~`, []byte(``)}, // the root has no name
+		{`$.store.none~`, []byte(``)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(doc, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// names are not addressable
+	if _, err := Delete(doc, `$.store.*~`); err != errNotAddressable {
+		t.Errorf("Delete $.store.*~ : errNotAddressable expected, got %v", err)
+	}
+	if _, err := Get(doc, `$.store~.book`); err == nil {
+		t.Errorf("$.store~.book : error expected")
+	}
+}
@@ -0,0 +1,87 @@
+package jsonslice
+
+import (
+	"bytes"
+
+	"github.com/bhmj/xpression"
+)
+
+// A reference selecting several values (@..price, @.items[*].price, $..price) is a node list. Compared with
+// a value (or another node list) it is true if the comparison is true for any of the values selected:
+// $[?(@..price > 100)] selects the elements having a price over 100 at any depth. An empty node list
+// makes any comparison false.
+
+// listComparisons replaces the comparisons of node lists in the filter of a node with calls
+func listComparisons(n *tNode) error {
+	return replaceNodeComparisons(n, func(op xpression.Operator, left, right *tArg) (tComparison, bool) {
+		lists := [2]bool{nodeList(left), nodeList(right)}
+		if !lists[0] && !lists[1] {
+			return tComparison{}, false
+		}
+		return tComparison{rfcOperators[op].name, listCompare(op, lists)}, true
+	})
+}
+
+// nodeList returns true if the argument is a reference selecting several values
+func nodeList(arg *tArg) bool {
+	if len(arg.ref) == 0 {
+		return false
+	}
+	node := parseRef(arg.ref)
+	defer repool(node)
+	return aggregates(node)
+}
+
+// listCompare makes a filter function comparing every value of the node list arguments (lists) in turn
+func listCompare(op xpression.Operator, lists [2]bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		var values [2][][]byte
+		for i, arg := range args {
+			if !lists[i] {
+				values[i] = [][]byte{arg}
+			} else if len(arg) > 0 {
+				values[i], _ = argArray(arg)
+			}
+		}
+		cmp := ctx.comparison(op)
+		for _, a := range values[0] {
+			for _, b := range values[1] {
+				res, err := cmp(ctx, [][]byte{a, b})
+				if err != nil {
+					return nil, err
+				}
+				if bytes.Equal(res, jsonTrue) {
+					return jsonTrue, nil
+				}
+			}
+		}
+		return jsonFalse, nil
+	}
+}
+
+// comparison returns the function of a comparison of two values according to the comparison semantics
+// of the context (see WithRFCComparison, WithExactNumbers and ExtAbstractEquality)
+func (ctx *tContext) comparison(op xpression.Operator) tFilterFunc {
+	not := false
+	if ctx != nil && ctx.disabled&ExtAbstractEquality > 0 && (op == opEqual || op == opNotEqual) {
+		op, not = opStrictEqual, op == opNotEqual // !(a === b) as restrictTokens makes it
+	}
+	var fn tFilterFunc
+	switch {
+	case ctx != nil && ctx.exact:
+		fn = exactOperators[ctx.rfcCompare][op].fn
+	case ctx != nil && ctx.rfcCompare:
+		fn = rfcOperators[op].fn
+	default:
+		fn = func(ctx *tContext, args [][]byte) ([]byte, error) {
+			return jsonBool(legacyCompare(op, bytes.TrimSpace(args[0]), bytes.TrimSpace(args[1]))), nil
+		}
+	}
+	if !not {
+		return fn
+	}
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		res, err := fn(ctx, args)
+		return jsonBool(!bytes.Equal(res, jsonTrue)), err
+	}
+}
@@ -0,0 +1,57 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_NodeListComparison(t *testing.T) {
+
+	doc := []byte(`{"max":[150,7],"l":[{"n":1,"items":[{"price":50},{"x":{"price":150}}]},{"n":2,"items":[{"price":"5"}]},{"n":3,"price":200},{"n":4}]}`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		// any of the values
+		{`$.l[?(@..price > 100)].n`, []byte(`[1,3]`)},
+		{`$.l[?(100 < @..price)].n`, []byte(`[1,3]`)},
+		{`$.l[?(@..price == 5)].n`, []byte(`[2]`)},
+		{`$.l[?(@..price === 5)].n`, []byte(`[]`)},
+		{`$.l[?(@..price != 50)].n`, []byte(`[1,2,3]`)},
+		{`$.l[?(@.items[*].price < 10)].n`, []byte(`[2]`)},
+		{`$.l[?(@..price == $.max[*])].n`, []byte(`[1]`)},
+		{`$.l[?(@..price > 100 && @.n < 3)].n`, []byte(`[1]`)},
+		{`$.l[?(!(@..price > 100))].n`, []byte(`[2,4]`)},
+		// an empty node list
+		{`$.l[?(@..nope != 1)].n`, []byte(`[]`)},
+		{`$.l[?(!@..price)].n`, []byte(`[4]`)},
+		// nested filters
+		{`$.l[?(@.items[?(@..price > 100)])].n`, []byte(`[1]`)},
+		{`$..[?(@..price > 150)].n`, []byte(`[3]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(doc, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// the comparison semantics of the options apply to every value
+	res, _ := GetWith(doc, `$.l[?(@..price == 5)].n`, WithRFCComparison())
+	if string(res) != `[]` {
+		t.Errorf("WithRFCComparison: unexpected %s", res)
+	}
+	res, _ = GetWith(doc, `$.l[?(@..price != 5)].n`, WithoutExtensions(ExtAbstractEquality))
+	if string(res) != `[1,2,3]` {
+		t.Errorf("WithoutExtensions: unexpected %s", res)
+	}
+	if _, err := GetWith(doc, `$.l[?(@..price === 5)]`, WithoutExtensions(ExtStrictEquality)); err == nil {
+		t.Errorf("WithoutExtensions: strict equality is not reported")
+	}
+	n, err := Count(doc, `$.l[?(@..price > 100)]`)
+	if err != nil || n != 2 {
+		t.Errorf("Count: unexpected %d (%v)", n, err)
+	}
+}
@@ -0,0 +1,182 @@
+package jsonslice
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+)
+
+//go:generate go run normalize_gen.go
+
+// KeyMatch selects how object keys of the input are compared with the keys of the path (see WithKeyMatch)
+type KeyMatch int
+
+const (
+	// KeyNormalize compares keys in Unicode canonical form: "\u00e9" (é) matches "e\u0301" (e + combining acute)
+	KeyNormalize KeyMatch = 1 << iota
+	// KeyFoldCase compares keys case-insensitively (Unicode simple case folding)
+	KeyFoldCase
+)
+
+// WithKeyMatch sets the way object keys are matched with the keys of the path.
+// By default keys are compared byte by byte after decoding escape sequences.
+// Flags may be combined: WithKeyMatch(KeyNormalize | KeyFoldCase). Flags of several options are combined too.
+func WithKeyMatch(m KeyMatch) Option {
+	return func(ctx *tContext) {
+		ctx.keyMatch |= m
+	}
+}
+
+// WithCaseInsensitiveKeys makes $.Store.Book[0].Title match {"store":{"book":[{"title":...}]}}.
+// It is the same as WithKeyMatch(KeyFoldCase). A single key selects the first matching member,
+// as with duplicate keys.
+func WithCaseInsensitiveKeys() Option {
+	return WithKeyMatch(KeyFoldCase)
+}
+
+// tDecomp is a canonical decomposition of a rune
+type tDecomp struct {
+	r rune
+	d string
+}
+
+// tCombining is a range of runes with the same canonical combining class
+type tCombining struct {
+	lo, hi rune
+	ccc    uint8
+}
+
+// equal compares a document key with a path key. If glob is set the path key may be a pattern (see isPattern).
+func (m KeyMatch) equal(key, nodkey []byte, glob bool) bool {
+	if m&KeyNormalize > 0 {
+		key, nodkey = normalizeKey(key), normalizeKey(nodkey)
+	}
+	if glob && isPattern(nodkey) {
+		return globMatch(nodkey, key, m&KeyFoldCase > 0)
+	}
+	if m&KeyFoldCase > 0 {
+		return bytes.EqualFold(key, nodkey)
+	}
+	return matchKeys(key, nodkey)
+}
+
+// keysEqual compares a document key with a path key according to the key matching mode of the context
+func (ctx *tContext) keysEqual(key, nodkey []byte, glob bool) bool {
+	if ctx == nil {
+		return KeyMatch(0).equal(key, nodkey, glob)
+	}
+	return ctx.keyMatch.equal(key, nodkey, glob)
+}
+
+// refContext returns the context evaluating references in filters (@.a, $.a): only the way members are
+// matched (key matching mode, object indexes, duplicate keys), the disabled extensions, the comparison semantics,
+// the deepscan depth limit (counted from @) and the cancellation (see WithContext) are inherited.
+// Returns nil if there is nothing to inherit.
+func (ctx *tContext) refContext() *tContext {
+	dup := ctx.duplicateKeys()
+	if dup == DuplicateAll {
+		dup = DuplicateFirst // a reference is a single value
+	}
+	if ctx == nil || ctx.keyMatch == 0 && !ctx.objIndexes && ctx.disabled == 0 && !ctx.rfcCompare && !ctx.exact &&
+		dup == DuplicateFirst && ctx.done == nil && ctx.maxDepth <= 0 {
+		return nil
+	}
+	return &tContext{keyMatch: ctx.keyMatch, objIndexes: ctx.objIndexes, disabled: ctx.disabled, rfcCompare: ctx.rfcCompare,
+		exact: ctx.exact, duplicates: dup, cancel: ctx.cancel, done: ctx.done, maxDepth: ctx.maxDepth}
+}
+
+// setRefContext sets the context of the references in filters of a node list evaluated by walk
+func setRefContext(node *tNode, ctx *tContext) {
+	ref := ctx.refContext()
+	for n := node; n != nil; n = n.Next {
+		n.ctx = ref
+		for _, part := range n.Union {
+			part.ctx = ref
+		}
+	}
+}
+
+// normalizeKey returns the canonical decomposition (NFD) of key
+func normalizeKey(key []byte) []byte {
+	for _, ch := range key {
+		if ch >= 0xC3 { // U+00C0 and above: may need decomposition
+			return appendNFD(nil, key)
+		}
+	}
+	return key
+}
+
+// appendNFD appends the canonical decomposition of s to dst: every rune is decomposed,
+// then runs of combining marks are put in canonical order
+func appendNFD(dst []byte, s []byte) []byte {
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRune(s[i:])
+		i += size
+		runes = appendDecomp(runes, r)
+	}
+	// canonical ordering: stable sort of adjacent combining marks by combining class
+	for i := 1; i < len(runes); i++ {
+		c := combiningClassOf(runes[i])
+		if c == 0 {
+			continue
+		}
+		for j := i; j > 0; j-- {
+			p := combiningClassOf(runes[j-1])
+			if p <= c {
+				break
+			}
+			runes[j-1], runes[j] = runes[j], runes[j-1]
+		}
+	}
+	for _, r := range runes {
+		dst = utf8.AppendRune(dst, r)
+	}
+	return dst
+}
+
+// Hangul syllable decomposition constants
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulTCount = 28
+	hangulNCount = 21 * hangulTCount
+	hangulSCount = 19 * hangulNCount
+)
+
+// appendDecomp appends the full canonical decomposition of r
+func appendDecomp(dst []rune, r rune) []rune {
+	if r < 0xC0 {
+		return append(dst, r)
+	}
+	if r >= hangulSBase && r < hangulSBase+hangulSCount {
+		s := r - hangulSBase
+		dst = append(dst, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+		if t := s % hangulTCount; t > 0 {
+			dst = append(dst, hangulTBase+t)
+		}
+		return dst
+	}
+	k := sort.Search(len(canonicalDecomp), func(i int) bool { return canonicalDecomp[i].r >= r })
+	if k < len(canonicalDecomp) && canonicalDecomp[k].r == r {
+		for _, d := range canonicalDecomp[k].d {
+			dst = append(dst, d)
+		}
+		return dst
+	}
+	return append(dst, r)
+}
+
+// combiningClassOf returns the canonical combining class of r
+func combiningClassOf(r rune) uint8 {
+	if r < 0x300 {
+		return 0
+	}
+	k := sort.Search(len(combiningClass), func(i int) bool { return combiningClass[i].hi >= r })
+	if k < len(combiningClass) && combiningClass[k].lo <= r {
+		return combiningClass[k].ccc
+	}
+	return 0
+}
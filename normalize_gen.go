@@ -0,0 +1,138 @@
+//go:build ignore
+
+// normalize_gen generates normalize_tables.go from the Unicode Character Database:
+//
+//	go run normalize_gen.go [-ucd UnicodeData.txt]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const version = "14.0.0"
+
+func main() {
+	ucd := flag.String("ucd", "https://www.unicode.org/Public/"+version+"/ucd/UnicodeData.txt", "UnicodeData.txt url or file")
+	out := flag.String("out", "normalize_tables.go", "output file")
+	flag.Parse()
+
+	src, err := open(*ucd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+
+	decomp := map[rune][]rune{}
+	ccc := map[rune]int{}
+	sc := bufio.NewScanner(src)
+	for sc.Scan() {
+		f := strings.Split(sc.Text(), ";")
+		if len(f) < 6 {
+			continue
+		}
+		r := parseRune(f[0])
+		if c, _ := strconv.Atoi(f[3]); c > 0 {
+			ccc[r] = c
+		}
+		if f[5] == "" || f[5][0] == '<' { // no or compatibility decomposition
+			continue
+		}
+		for _, s := range strings.Fields(f[5]) {
+			decomp[r] = append(decomp[r], parseRune(s))
+		}
+	}
+	if err = sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by normalize_gen.go; DO NOT EDIT.\n\npackage jsonslice\n\n")
+	fmt.Fprintf(&buf, "// unicodeVersion is the version of the Unicode Character Database the tables are generated from\n")
+	fmt.Fprintf(&buf, "const unicodeVersion = %q\n\n", version)
+
+	fmt.Fprintf(&buf, "// canonicalDecomp holds the full canonical decomposition of every decomposable rune\n")
+	fmt.Fprintf(&buf, "// except Hangul syllables (decomposed algorithmically), sorted by rune\n")
+	fmt.Fprintf(&buf, "var canonicalDecomp = [...]tDecomp{\n")
+	for _, r := range sortedKeys(decomp) {
+		fmt.Fprintf(&buf, "\t{0x%04X, %s},\n", r, strconv.QuoteToASCII(string(full(decomp, r))))
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// combiningClass holds ranges of runes with the same non-zero canonical combining class, sorted\n")
+	fmt.Fprintf(&buf, "var combiningClass = [...]tCombining{\n")
+	runes := sortedKeys(ccc)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[j-1]+1 && ccc[runes[j]] == ccc[runes[i]] {
+			j++
+		}
+		fmt.Fprintf(&buf, "\t{0x%04X, 0x%04X, %d},\n", runes[i], runes[j-1], ccc[runes[i]])
+		i = j
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	res, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, res, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// open opens a local file or downloads a url
+func open(name string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		return os.Open(name)
+	}
+	resp, err := http.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", name, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func parseRune(s string) rune {
+	r, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rune(r)
+}
+
+// full returns the recursive canonical decomposition of r
+func full(decomp map[rune][]rune, r rune) []rune {
+	d, ok := decomp[r]
+	if !ok {
+		return []rune{r}
+	}
+	var res []rune
+	for _, x := range d {
+		res = append(res, full(decomp, x)...)
+	}
+	return res
+}
+
+func sortedKeys[T any](m map[rune]T) []rune {
+	keys := make([]rune, 0, len(m))
+	for r := range m {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,100 @@
+package jsonslice
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_KeyMatch(t *testing.T) {
+
+	// keys in NFC form (escaped in the document)
+	doc := []byte(`{"Caf\u00e9":{"Name":"x"},"list":[{"Gr\u00f6\u00dfe":"L","Type":"a"},{"gr\u00f6\u00dfe":"M","Type":"x"}]}`)
+	tests := []struct {
+		Query    string
+		Match    KeyMatch
+		Expected []byte
+	}{
+		// paths in NFD form
+		{"$.Cafe\u0301.Name", 0, []byte(``)},
+		{"$.Cafe\u0301.Name", KeyNormalize, []byte(`"x"`)},
+		{"$['Cafe\u0301'].name", KeyNormalize, []byte(``)},
+		{"$['CAF\u00c9'].name", KeyFoldCase, []byte(`"x"`)},
+		{"$.CAFE\u0301.NAME", KeyFoldCase, []byte(``)},
+		{"$.CAFE\u0301.NAME", KeyNormalize | KeyFoldCase, []byte(`"x"`)},
+		{"$.list[*]['Gro\u0308\u00dfe']", 0, []byte(`[]`)},
+		{"$.list[*]['Gro\u0308\u00dfe']", KeyNormalize, []byte(`["L"]`)},
+		{"$.list[*]['Gro\u0308\u00dfe']", KeyNormalize | KeyFoldCase, []byte(`["L","M"]`)},
+		{"$..GRO\u0308\u00dfE", KeyNormalize | KeyFoldCase, []byte(`["L","M"]`)},
+		// references in filters
+		{"$.LIST[?(@.TYPE == 'a')].type", KeyFoldCase, []byte(`["a"]`)},
+		{"$.list[?(@.type == $.CAFE\u0301.NAME)].type", KeyNormalize | KeyFoldCase, []byte(`["x"]`)},
+		{"$.list[?(@['Gro\u0308\u00dfe'])].Type", KeyNormalize, []byte(`["a"]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(doc, tst.Query, WithKeyMatch(tst.Match))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
+
+func Test_CaseInsensitiveKeys(t *testing.T) {
+
+	doc := []byte(`{"store":{"book":[{"title":"a","Title":"b"},{"TITLE":"c"}]}}`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+		Offsets  [][2]int
+	}{
+		{`$.Store.Book[0].Title`, []byte(`"a"`), [][2]int{{27, 30}}},
+		{`$.STORE.BOOK[*].title`, []byte(`["a","c"]`), [][2]int{{27, 30}, {53, 56}}},
+		{`$..Title`, []byte(`["a","b","c"]`), [][2]int{{27, 30}, {39, 42}, {53, 56}}},
+		{`$.store.book[?(@.TITLE == 'c')].title`, []byte(`["c"]`), [][2]int{{53, 56}}},
+		{`$.store.book[?(@.Title == 'c')].TITLE`, []byte(`["c"]`), [][2]int{{53, 56}}},
+		{`$.store.Books`, []byte(``), [][2]int{}},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		res, err := GetWith(doc, tst.Query, WithCaseInsensitiveKeys(), WithOffsets(&offsets))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		} else if !reflect.DeepEqual(offsets, tst.Offsets) {
+			t.Errorf(tst.Query+"\n\texpected offsets %v\n\tbut got  %v", tst.Offsets, offsets)
+		}
+	}
+
+	// combined with other key matching flags
+	res, _ := GetWith([]byte(`{"CAF\u00c9":1}`), "$.cafe\u0301", WithKeyMatch(KeyNormalize), WithCaseInsensitiveKeys())
+	if string(res) != "1" {
+		t.Errorf("$.cafe\\u0301\n\texpected `1`\n\tbut got  `%s`", res)
+	}
+}
+
+func Test_NFD(t *testing.T) {
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{"abc", "abc"},
+		{"\u00e9", "e\u0301"},
+		{"\u1e69", "s\u0323\u0307"},                              // recursive decomposition
+		{"q\u0307\u0323", "q\u0323\u0307"},                       // canonical order of combining marks
+		{"\u1e1d\u0323", "e\u0327\u0323\u0306"},                  // decomposed marks are reordered too
+		{"\ud55c\uae00", "\u1112\u1161\u11ab\u1100\u1173\u11af"}, // hangul syllables
+		{"\u212b", "A\u030a"},                                    // singleton (angstrom sign)
+	}
+
+	for _, tst := range tests {
+		res := string(appendNFD(nil, []byte(tst.Input)))
+		if res != tst.Expected {
+			t.Errorf("%+q\n\texpected %+q\n\tbut got  %+q", tst.Input, tst.Expected, res)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package jsonslice
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/bhmj/xpression"
+)
+
+// Numbers are returned exactly as they appear in the input. With WithNormalizedNumbers they are rewritten
+// in the shortest form, as encoding/json writes a float64, except that the digits are never rounded:
+// a big integer keeps every digit. With WithExactNumbers filters compare numbers as decimals rather than
+// as float64 values, so 64-bit IDs and longer numbers are not rounded before comparison.
+
+// WithNormalizedNumbers rewrites numbers of the result in a normalized form: 42.0 is 42, 1.50 is 1.5, 1e3 is 1000,
+// -0 is 0. Numbers below 1e-6 and from 1e21 on (by absolute value) are written with an exponent (1e+21, 1.5e-7).
+// The value of a number is never changed: digits beyond float64 precision are kept (12345678901234567891 stays as is).
+func WithNormalizedNumbers() Option {
+	return func(ctx *tContext) {
+		ctx.output().numbers = true
+	}
+}
+
+// WithExactNumbers makes comparisons in filters (==, !=, <, <=, >, >=) of two numbers exact: 9007199254740993
+// is not equal to 9007199254740992 as it is in float64. Number literals of filters keep their digits as well.
+// Comparisons of other values and arithmetic are not affected (see also WithRFCComparison).
+func WithExactNumbers() Option {
+	return func(ctx *tContext) {
+		ctx.exact = true
+	}
+}
+
+// maxExactDigits is the number of significant digits of a number literal exactly representable as float64
+const maxExactDigits = 15
+
+// tDecimal is a number: 0.digits * 10^point
+type tDecimal struct {
+	neg    bool
+	digits []byte // no leading or trailing zeros, empty for zero
+	point  int
+}
+
+// parseDecimal parses a json number
+func parseDecimal(num []byte) (d tDecimal, ok bool) {
+	i := 0
+	if i < len(num) && num[i] == '-' {
+		d.neg = true
+		i++
+	}
+	s := i
+	for i < len(num) && num[i] >= '0' && num[i] <= '9' {
+		i++
+	}
+	d.digits = append(d.digits, num[s:i]...)
+	exp := 0
+	if i < len(num) && num[i] == '.' {
+		s = i + 1
+		for i = s; i < len(num) && num[i] >= '0' && num[i] <= '9'; i++ {
+		}
+		d.digits = append(d.digits, num[s:i]...)
+		exp = s - i
+	}
+	if len(d.digits) == 0 {
+		return d, false
+	}
+	if i < len(num) && (num[i] == 'e' || num[i] == 'E') {
+		e, err := strconv.Atoi(string(num[i+1:]))
+		if err != nil || e > 1<<30 || e < -1<<30 {
+			return d, false
+		}
+		exp += e
+		i = len(num)
+	}
+	if i < len(num) {
+		return d, false
+	}
+	for len(d.digits) > 0 && d.digits[0] == '0' {
+		d.digits = d.digits[1:]
+	}
+	for len(d.digits) > 0 && d.digits[len(d.digits)-1] == '0' {
+		d.digits = d.digits[:len(d.digits)-1]
+		exp++
+	}
+	if len(d.digits) == 0 {
+		return tDecimal{}, true
+	}
+	d.point = len(d.digits) + exp
+	return d, true
+}
+
+// sign returns -1, 0 or 1
+func (d tDecimal) sign() int {
+	switch {
+	case len(d.digits) == 0:
+		return 0
+	case d.neg:
+		return -1
+	}
+	return 1
+}
+
+// compareDecimals returns -1 if x < y, 0 if x == y, 1 if x > y
+func compareDecimals(x, y tDecimal) int {
+	sx, sy := x.sign(), y.sign()
+	switch {
+	case sx != sy:
+		if sx < sy {
+			return -1
+		}
+		return 1
+	case sx == 0:
+		return 0
+	}
+	c := bytes.Compare(x.digits, y.digits) // no trailing zeros: a prefix is less
+	if x.point != y.point {
+		c = 1
+		if x.point < y.point {
+			c = -1
+		}
+	}
+	return c * sx
+}
+
+// appendDecimal appends the number in the normalized form
+func appendDecimal(buf []byte, d tDecimal) []byte {
+	if len(d.digits) == 0 {
+		return append(buf, '0')
+	}
+	if d.neg {
+		buf = append(buf, '-')
+	}
+	n := len(d.digits)
+	switch {
+	case d.point < -5 || d.point > 21: // |d| < 1e-6 or |d| >= 1e21
+		buf = append(buf, d.digits[0])
+		if n > 1 {
+			buf = append(append(buf, '.'), d.digits[1:]...)
+		}
+		buf = append(buf, 'e')
+		if d.point > 0 {
+			buf = append(buf, '+')
+		}
+		return strconv.AppendInt(buf, int64(d.point-1), 10)
+	case d.point <= 0:
+		buf = append(buf, "0."...)
+		buf = append(buf, bytes.Repeat([]byte{'0'}, -d.point)...)
+		return append(buf, d.digits...)
+	case d.point >= n:
+		buf = append(buf, d.digits...)
+		return append(buf, bytes.Repeat([]byte{'0'}, d.point-n)...)
+	}
+	buf = append(buf, d.digits[:d.point]...)
+	buf = append(buf, '.')
+	return append(buf, d.digits[d.point:]...)
+}
+
+// normalizeNumbers appends the json text with every number normalized to buf
+func normalizeNumbers(buf, input []byte) []byte {
+	for i := 0; i < len(input); {
+		ch := input[i]
+		switch {
+		case ch == '"':
+			e, err := skipString(input, i)
+			if err != nil {
+				return append(buf, input[i:]...)
+			}
+			buf = append(buf, input[i:e]...)
+			i = e
+		case (ch >= '0' && ch <= '9') || ch == '-' || ch == '.':
+			e := skipNumber(input, i)
+			if e <= i {
+				e = i + 1
+			}
+			if d, ok := parseDecimal(input[i:e]); ok {
+				buf = appendDecimal(buf, d)
+			} else {
+				buf = append(buf, input[i:e]...)
+			}
+			i = e
+		default:
+			buf = append(buf, ch)
+			i++
+		}
+	}
+	return buf
+}
+
+// exactLiteral returns true if a number literal of a filter is to be kept as is rather than parsed
+// as float64: it has more significant digits than float64 holds
+func exactLiteral(num []byte) bool {
+	d, ok := parseDecimal(num)
+	return ok && len(d.digits) > maxExactDigits
+}
+
+var (
+	// operatorCategory is the token category of an operator
+	operatorCategory xpression.TokenCategory
+	// exactOperators are the comparison functions replacing the operators with WithExactNumbers:
+	// values other than numbers are compared as JavaScript does (false) or as RFC 9535 does (true)
+	exactOperators = map[bool]map[xpression.Operator]tComparison{}
+)
+
+func init() {
+	toks, _ := xpression.Parse([]byte("1 == 1"))
+	operatorCategory = toks[0].Category
+	for _, rfc := range []bool{false, true} {
+		exactOperators[rfc] = map[xpression.Operator]tComparison{}
+		for op, cmp := range rfcOperators {
+			exactOperators[rfc][op] = tComparison{cmp.name, exactCompare(op, rfc)}
+		}
+	}
+}
+
+// exactCompare makes a filter function comparing two numbers exactly and other values as the dialect does
+func exactCompare(op xpression.Operator, rfc bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		a, b := bytes.TrimSpace(args[0]), bytes.TrimSpace(args[1])
+		if c, ok := compareNumbers(a, b); ok {
+			switch op {
+			case opEqual, opStrictEqual:
+				return jsonBool(c == 0), nil
+			case opNotEqual, opStrictNotEqual:
+				return jsonBool(c != 0), nil
+			case opLess:
+				return jsonBool(c < 0), nil
+			case opLessEqual:
+				return jsonBool(c <= 0), nil
+			case opGreater:
+				return jsonBool(c > 0), nil
+			}
+			return jsonBool(c >= 0), nil
+		}
+		if rfc {
+			return rfcOperators[op].fn(ctx, args)
+		}
+		return jsonBool(legacyCompare(op, a, b)), nil
+	}
+}
+
+// compareNumbers compares two json numbers, ok is false if either of the values is not a number
+func compareNumbers(a, b []byte) (int, bool) {
+	if len(a) == 0 || len(b) == 0 || jsonType(a) != "number" || jsonType(b) != "number" {
+		return 0, false
+	}
+	x, okx := parseDecimal(a)
+	y, oky := parseDecimal(b)
+	if !okx || !oky {
+		return 0, false
+	}
+	return compareDecimals(x, y), true
+}
+
+// legacyCompare compares two json values (nil being a missing value) as the expression evaluator does
+func legacyCompare(op xpression.Operator, a, b []byte) bool {
+	var toks [4]xpression.Token // operator, its result, operands
+	toks[0].Category, toks[0].Operator = operatorCategory, op
+	for i, val := range [][]byte{a, b} {
+		tok := &toks[i+2]
+		xpression.SetLiteral(tok)
+		if len(val) == 0 || decodeValue(val, &tok.Operand) != nil {
+			tok.SetUndefined()
+		}
+	}
+	res, err := xpression.Evaluate([]*xpression.Token{&toks[0], &toks[1], &toks[2], &toks[3]}, nil)
+	return err == nil && xpression.ToBoolean(res)
+}
@@ -0,0 +1,105 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_NormalizedNumbers(t *testing.T) {
+
+	tests := []struct {
+		Input    string
+		Expected string
+	}{
+		{`42.0`, `42`},
+		{`[1.50, 1e3, 100e-2, -0, 0.0, -0.5e1]`, `[1.5, 1000, 1, 0, 0, -5]`},
+		{`{"a": 1E+21, "b": 1e20, "c": 0.000001, "d": 1e-7, "e": -12.5e-8}`, `{"a": 1e+21, "b": 100000000000000000000, "c": 0.000001, "d": 1e-7, "e": -1.25e-7}`},
+		{`[12345678901234567891, -9007199254740993.000, 1234567890123456789012.5]`, `[12345678901234567891, -9007199254740993, 1.2345678901234567890125e+21]`},
+		{`["1.0", "a\"2.0", 3.0]`, `["1.0", "a\"2.0", 3]`},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith([]byte(tst.Input), "$", WithNormalizedNumbers())
+		if err != nil {
+			t.Errorf(tst.Input + " : " + err.Error())
+		} else if string(res) != tst.Expected {
+			t.Errorf(tst.Input + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// aggregated results
+	doc := []byte(`[{"a": 1.0}, {"a": 2.50}, {"a": 3e0}]`)
+	res, err := GetWith(doc, `$[*].a`, WithNormalizedNumbers())
+	if err != nil || string(res) != `[1,2.5,3]` {
+		t.Errorf("aggregated: unexpected %s (%v)", res, err)
+	}
+	res, err = GetWith(doc, `$[1]`, WithNormalizedNumbers(), WithCompact())
+	if err != nil || string(res) != `{"a":2.5}` {
+		t.Errorf("with WithCompact: unexpected %s (%v)", res, err)
+	}
+	res, _ = Get(doc, `$[*].a`)
+	if string(res) != `[1.0,2.50,3e0]` {
+		t.Errorf("numbers are kept by default: unexpected %s", res)
+	}
+	p, err := CompileWith(`$[0].a`, WithNormalizedNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ = p.Get(doc)
+	if string(res) != `1` {
+		t.Errorf("CompileWith: unexpected %s", res)
+	}
+}
+
+func Test_ExactNumbers(t *testing.T) {
+
+	doc := []byte(`[{"id":9007199254740993},{"id":9007199254740992},{"id":"9007199254740993"},{"id":-12345678901234567891},{"id":4.20e1},{"x":1}]`)
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		// 64-bit integers
+		{`$[?(@.id == 9007199254740993)].id`, []byte(`[9007199254740993,"9007199254740993"]`)},
+		{`$[?(@.id != 9007199254740993)].id`, []byte(`[9007199254740992,-12345678901234567891,4.20e1]`)},
+		{`$[?(@.id > 9007199254740992)].id`, []byte(`[9007199254740993]`)},
+		{`$[?(@.id >= 9007199254740992)].id`, []byte(`[9007199254740993,9007199254740992,"9007199254740993"]`)},
+		{`$[?(@.id === 9007199254740993)].id`, []byte(`[9007199254740993]`)},
+		{`$[?(@.id == $[0].id)].id`, []byte(`[9007199254740993,"9007199254740993"]`)},
+		// negative and big literals
+		{`$[?(@.id == -12345678901234567891)].id`, []byte(`[-12345678901234567891]`)},
+		{`$[?(@.id < -12345678901234567890)].id`, []byte(`[-12345678901234567891]`)},
+		{`$[?(@.id > -12345678901234567891 && @.id < 0)].id`, []byte(`[]`)},
+		// equal values of different forms
+		{`$[?(@.id == 42)].id`, []byte(`[4.20e1]`)},
+		{`$[?(@.id <= 0.42e2)].id`, []byte(`[-12345678901234567891,4.20e1]`)},
+		// other values are compared as before
+		{`$[?(@.id === "9007199254740993")].id`, []byte(`["9007199254740993"]`)},
+		{`$[?(@.id == @.x)]`, []byte(`[]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(doc, tst.Query, WithExactNumbers())
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// float64 comparison is the default
+	res, _ := Get(doc, `$[?(@.id == 9007199254740993)].id`)
+	if string(res) != `[9007199254740993,9007199254740992,"9007199254740993"]` {
+		t.Errorf("float64 comparison: unexpected %s", res)
+	}
+	res, _ = GetWith(doc, `$[?(@.id == 9007199254740993)].id`, WithExactNumbers(), WithRFCComparison())
+	if string(res) != `[9007199254740993]` {
+		t.Errorf("with WithRFCComparison: unexpected %s", res)
+	}
+	p, err := CompileWith(`$[?(@.id < 9007199254740993)].id`, WithExactNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ = p.Get(doc)
+	if string(res) != `[9007199254740992,-12345678901234567891,4.20e1]` {
+		t.Errorf("CompileWith: unexpected %s", res)
+	}
+}
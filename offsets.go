@@ -0,0 +1,324 @@
+package jsonslice
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// SourceRef describes the origin of a single result value
+type SourceRef struct {
+	Path  string // normalized path of the value: $['store']['book'][0]['price']
+	Start int    // value start offset in the input
+	End   int    // value end offset in the input (excluded)
+}
+
+// WithOffsets makes GetWith store the source offsets [start,end) of every value in the result.
+// For an aggregated result offsets[k] corresponds to the k-th element of the output array.
+// Nested aggregations ($[:]['a','b']) produce nested arrays; offsets then list the leaf values in output order.
+// Function results ($.a.length()) have no source and produce errNotAddressable.
+func WithOffsets(offsets *[][2]int) Option {
+	return func(ctx *tContext) {
+		ctx.offsets = offsets
+	}
+}
+
+// WithSourceMap is the same as WithOffsets but also reports the normalized path of every value,
+// which allows to attribute the merged output of multi-key selectors ($[?(...)]['price','title'])
+// to the source records. refs[k] describes the k-th value of the result, as offsets[k] does.
+func WithSourceMap(refs *[]SourceRef) Option {
+	return func(ctx *tContext) {
+		ctx.sources = refs
+	}
+}
+
+// GetRange returns the bounds [start,end) of the (first) value in input matched by path.
+// Returns errFieldNotFound if nothing matches, errNotAddressable for a function result ($.a.length()).
+func GetRange(input []byte, path string) (start, end int, err error) {
+	ranges, err := GetRanges(input, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(ranges) == 0 {
+		return 0, 0, errFieldNotFound
+	}
+	return ranges[0][0], ranges[0][1], nil
+}
+
+// GetRanges returns the bounds [start,end) of every value in input matched by path, in output order
+func GetRanges(input []byte, path string) ([][2]int, error) {
+	refs, err := sourceRefs(input, path, false)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([][2]int, len(refs))
+	for i := range refs {
+		ranges[i] = [2]int{refs[i].Start, refs[i].End}
+	}
+	return ranges, nil
+}
+
+// GetWithPaths returns every value in input matched by path along with its normalized path
+// ($['store']['book'][2]['title']). Values are slices of the input and must not be modified.
+// Function results ($.a.length()) have no source and produce errNotAddressable.
+func GetWithPaths(input []byte, path string) ([]Match, error) {
+	refs, err := sourceRefs(input, path, true)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(refs))
+	for i, ref := range refs {
+		matches[i] = Match{Value: input[ref.Start:ref.End:ref.End], Path: ref.Path}
+	}
+	return matches, nil
+}
+
+// locateMatches fills offsets and/or source map of the context
+func (ctx *tContext) locateMatches() error {
+	refs, err := ctx.locator.sources(ctx.sources != nil)
+	if err != nil {
+		return err
+	}
+	if ctx.sources != nil {
+		*ctx.sources = refs
+	}
+	if ctx.offsets != nil {
+		*ctx.offsets = make([][2]int, len(refs))
+		for i := range refs {
+			(*ctx.offsets)[i] = [2]int{refs[i].Start, refs[i].End}
+		}
+	}
+	return nil
+}
+
+// sourceRefs returns the bounds (and normalized paths if locate is set) of every value in input matched by path
+func sourceRefs(input []byte, path string, locate bool) ([]SourceRef, error) {
+	ctx := &tContext{locator: &tLocator{input: input}}
+	if _, err := get(input, path, ctx); err != nil {
+		return nil, err
+	}
+	return ctx.locator.sources(locate)
+}
+
+// tLocator records the bounds of the values in the order they are put into the result by getValue.
+// The values are located by a node ending the node list (see locate), so the offsets come
+// from the same evaluation that builds the result.
+type tLocator struct {
+	input  []byte
+	refs   []SourceRef
+	copies []tCopy // values copied out of input (see objectByIndex)
+	lost   bool    // a value not taken from input
+}
+
+// tCopy is a value copied out of input
+type tCopy struct {
+	dst []byte // the copy
+	src []byte // the original
+}
+
+// locate appends a node recording the values to the node list.
+// Function results and member names have no source (see sources).
+func (ctx *tContext) locate(input []byte, node *tNode) {
+	if ctx == nil || ctx.locator == nil {
+		return
+	}
+	if node == nil {
+		ctx.locateRoot(input)
+		return
+	}
+	if hasNode(node, cFunction|cName) {
+		ctx.locator.lost = true
+		return
+	}
+	last := node
+	for last.Next != nil {
+		last = last.Next
+	}
+	loc := getEmptyNode()
+	loc.Type = cLocate
+	loc.ctx = ctx
+	last.Next = loc
+	for _, part := range last.Union {
+		part.Next = loc
+	}
+}
+
+// locateRoot records the input itself as the result of $
+func (ctx *tContext) locateRoot(input []byte) {
+	if ctx == nil || ctx.locator == nil {
+		return
+	}
+	i, _ := skipSpaces(input, 0)
+	if e, err := skipValue(input, i); err == nil && e > i {
+		ctx.locator.add(input[i:e])
+	}
+}
+
+// located makes sure the recorded values follow each other in the result
+func (ctx *tContext) located(result []byte) error {
+	if ctx == nil || ctx.locator == nil {
+		return nil
+	}
+	l := ctx.locator
+	if l.lost {
+		return nil // reported by sources
+	}
+	j := 0
+	for _, ref := range l.refs {
+		k := bytes.Index(result[j:], l.input[ref.Start:ref.End])
+		if k < 0 {
+			return errNotLocated
+		}
+		j += k + ref.End - ref.Start
+	}
+	return nil
+}
+
+// add records the bounds of a value which is a part of input or of a copy of input
+func (l *tLocator) add(val []byte) {
+	p := address(val)
+	base := address(l.input)
+	for i := len(l.copies) - 1; i >= 0; i-- { // copies of copies are registered later
+		if dst := address(l.copies[i].dst); p >= dst && p+len(val) <= dst+len(l.copies[i].dst) {
+			p += address(l.copies[i].src) - dst
+		}
+	}
+	if p < base || p+len(val) > base+len(l.input) {
+		l.lost = true
+		return
+	}
+	l.refs = append(l.refs, SourceRef{Start: p - base, End: p - base + len(val)})
+}
+
+// copied registers a copy of input bytes: the values found in the copy are located in the original
+func (ctx *tContext) copied(dst, src []byte) {
+	if ctx != nil && ctx.locator != nil {
+		ctx.locator.copies = append(ctx.locator.copies, tCopy{dst, src})
+	}
+}
+
+// copies returns the number of the registered copies
+func (ctx *tContext) copies() int {
+	if ctx == nil || ctx.locator == nil {
+		return 0
+	}
+	return len(ctx.locator.copies)
+}
+
+// released forgets the copies registered after the first n
+func (ctx *tContext) released(n int) {
+	if ctx != nil && ctx.locator != nil {
+		ctx.locator.copies = ctx.locator.copies[:n]
+	}
+}
+
+// address returns the address of the first byte of a non-empty slice
+func address(b []byte) int {
+	return int(reflect.ValueOf(b).Pointer())
+}
+
+// sources returns the recorded values, with their normalized paths if locate is set
+func (l *tLocator) sources(locate bool) ([]SourceRef, error) {
+	if l.lost {
+		return nil, errNotAddressable
+	}
+	refs := l.refs
+	if refs == nil {
+		refs = []SourceRef{}
+	}
+	if locate && len(refs) > 0 {
+		if err := locatePaths(l.input, refs); err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+// tPathScan finds the normalized paths of the values starting at given offsets in a single scan of input
+type tPathScan struct {
+	input []byte
+	refs  []SourceRef
+	order []int // refs sorted by the value start
+	k     int   // the next ref to find
+}
+
+// locatePaths fills the normalized paths of refs
+func locatePaths(input []byte, refs []SourceRef) error {
+	s := &tPathScan{input: input, refs: refs, order: make([]int, len(refs))}
+	for i := range s.order {
+		s.order[i] = i
+	}
+	sort.SliceStable(s.order, func(a, b int) bool { return refs[s.order[a]].Start < refs[s.order[b]].Start })
+	i, _ := skipSpaces(input, 0)
+	e, err := skipValue(input, i)
+	if err != nil {
+		return err
+	}
+	if err = s.scan(i, e, []byte{'$'}); err != nil {
+		return err
+	}
+	if s.k < len(s.order) {
+		return errNotLocated
+	}
+	return nil
+}
+
+// next returns the start of the next value to find, -1 if all are found
+func (s *tPathScan) next() int {
+	if s.k == len(s.order) {
+		return -1
+	}
+	return s.refs[s.order[s.k]].Start
+}
+
+// scan finds the values inside input[i:e]
+func (s *tPathScan) scan(i, e int, loc []byte) error {
+	for s.next() == i {
+		s.refs[s.order[s.k]].Path = string(loc)
+		s.k++
+	}
+	if n := s.next(); n < i || n >= e {
+		return nil
+	}
+	switch s.input[i] {
+	case '{':
+		members, err := appendMembers(nil, s.input, i)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			if n := s.next(); n >= m.start && n < m.end {
+				if err = s.scan(m.start, m.end, appendLocKey(loc, m.key)); err != nil {
+					return err
+				}
+			}
+		}
+	case '[':
+		elems, err := arrayElems(s.input, i)
+		if err != nil {
+			return err
+		}
+		for k, el := range elems {
+			if n := s.next(); n >= el.start && n < el.end {
+				sub := append(strconv.AppendInt(append(loc, '['), int64(k), 10), ']')
+				if err = s.scan(el.start, el.end, sub); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// appendLocKey appends a normalized path segment ['key'] for a raw (json-escaped) object key
+func appendLocKey(loc, key []byte) []byte {
+	loc = append(loc, '[', '\'')
+	for _, c := range key {
+		if c == '\'' {
+			loc = append(loc, '\\')
+		}
+		loc = append(loc, c)
+	}
+	return append(loc, '\'', ']')
+}
@@ -0,0 +1,194 @@
+package jsonslice
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_Offsets(t *testing.T) {
+
+	input := []byte(`{"a": [1, {"b": 2}, "x"], "c": {"b": 3}}`)
+	tests := []struct {
+		Query    string
+		Expected [][2]int
+	}{
+		{`$.a[0]`, [][2]int{{7, 8}}},
+		{`$.a[1].b`, [][2]int{{16, 17}}},
+		{`$.a[:]`, [][2]int{{7, 8}, {10, 18}, {20, 23}}},
+		{`$.a[2,0]`, [][2]int{{7, 8}, {20, 23}}},
+		{`$.a[0,0]`, [][2]int{{7, 8}}},
+		{`$..*`, [][2]int{{6, 24}, {7, 8}, {10, 18}, {16, 17}, {20, 23}, {31, 39}, {37, 38}}},
+		{`$..b`, [][2]int{{16, 17}, {37, 38}}},
+		{`$.a[?(@.b > 1)]`, [][2]int{{10, 18}}},
+		{`$.a[(@.length-1)]`, [][2]int{{20, 23}}},
+		{`$[?(@.b == 3)]`, [][2]int{{31, 39}}},
+		{`$.z`, [][2]int{}},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		_, err := GetWith(input, tst.Query, WithOffsets(&offsets))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if !reflect.DeepEqual(offsets, tst.Expected) {
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v", tst.Query, tst.Expected, offsets)
+		}
+	}
+
+	var offsets [][2]int
+	if _, err := GetWith(input, `$.a.length()`, WithOffsets(&offsets)); err != errNotAddressable {
+		t.Errorf("expected errNotAddressable, got %v", err)
+	}
+}
+
+func Test_OffsetsOfResult(t *testing.T) {
+
+	input := []byte(`{"book": [{"price": 8, "title": "A"}, {"title": "B", "price": 12}], "bicycle": {"price": 19}}`)
+	tests := []struct {
+		Query    string
+		Expected [][2]int
+	}{
+		{`$..['price','title']`, [][2]int{{20, 21}, {32, 35}, {48, 51}, {62, 64}, {89, 91}}},
+		{`$..[?(@.price > 10)]`, [][2]int{{38, 65}, {79, 92}}},
+		{`$..[?(@.price)].price`, [][2]int{{20, 21}, {62, 64}, {89, 91}}},
+		{`$.book[?(@.title == "A" || @.price > 10)]['title','price']`, [][2]int{{20, 21}, {32, 35}, {48, 51}, {62, 64}}},
+		{`$.book[?(length(@.title) == 1)].price`, [][2]int{{20, 21}, {62, 64}}},
+		{`$.book[0,'x',1:]`, [][2]int{{10, 36}, {38, 65}}},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		res, err := GetWith(input, tst.Query, WithOffsets(&offsets))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(offsets, tst.Expected) {
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v", tst.Query, tst.Expected, offsets)
+		}
+		// offsets[k] is the k-th element of the result
+		values := make([][]byte, len(offsets))
+		for i, o := range offsets {
+			values[i] = input[o[0]:o[1]]
+		}
+		if expected := "[" + string(bytes.Join(values, []byte(","))) + "]"; string(res) != expected {
+			t.Errorf("%s\n\texpected `%s`\n\tbut got  `%s`", tst.Query, expected, res)
+		}
+	}
+
+	for _, path := range []string{`$.book.length()`, `$..price.sum()`, `$.book[*].title.upper()`, `$..book[?(@.price > 10)].title.length()`} {
+		var offsets [][2]int
+		if _, err := GetWith(input, path, WithOffsets(&offsets)); err != errNotAddressable {
+			t.Errorf("%s: expected errNotAddressable, got %v", path, err)
+		}
+	}
+}
+
+func Test_SourceMap(t *testing.T) {
+
+	input := []byte(`[{"title": "A", "price": 5}, {"title": "B", "price": 15}, {"it's": 1}]`)
+	tests := []struct {
+		Query    string
+		Expected []SourceRef
+	}{
+		{`$[?(@.price > 1)]['price','title']`, []SourceRef{
+			{`$[0]['title']`, 11, 14}, {`$[0]['price']`, 25, 26},
+			{`$[1]['title']`, 39, 42}, {`$[1]['price']`, 53, 55},
+		}},
+		{`$[-2].price`, []SourceRef{{`$[1]['price']`, 53, 55}}},
+		{`$..['it\'s']`, []SourceRef{{`$[2]['it\'s']`, 67, 68}}},
+		{`$..['price','title']`, []SourceRef{
+			{`$[0]['title']`, 11, 14}, {`$[0]['price']`, 25, 26},
+			{`$[1]['title']`, 39, 42}, {`$[1]['price']`, 53, 55},
+		}},
+		{`$..[?(@.price > 10)]`, []SourceRef{{`$[1]`, 29, 56}}},
+		{`$[?(length(@.title) == 1)].price`, []SourceRef{{`$[0]['price']`, 25, 26}, {`$[1]['price']`, 53, 55}}},
+	}
+
+	for _, tst := range tests {
+		var refs []SourceRef
+		_, err := GetWith(input, tst.Query, WithSourceMap(&refs))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if !reflect.DeepEqual(refs, tst.Expected) {
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v", tst.Query, tst.Expected, refs)
+		}
+		// the path selects the value
+		for _, ref := range refs {
+			if val, err := Get(input, ref.Path); err != nil || string(val) != string(input[ref.Start:ref.End]) {
+				t.Errorf("%s: %s selects `%s` (%v)", tst.Query, ref.Path, val, err)
+			}
+		}
+	}
+
+	for _, path := range []string{`$[*].price.sum()`, `$[?(@.price > 10)].title.length()`} {
+		var refs []SourceRef
+		if _, err := GetWith(input, path, WithSourceMap(&refs)); err != errNotAddressable {
+			t.Errorf("%s: expected errNotAddressable, got %v", path, err)
+		}
+	}
+}
+
+func Test_GetWithPaths(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"title": "A", "price": 5}, {"title": "B", "price": 15}, {"title": "C", "price": 25}]}}`)
+	tests := []struct {
+		Query    string
+		Expected []Match
+	}{
+		{`$.store.book[?(@.price > 10)].title`, []Match{
+			{Value: []byte(`"B"`), Path: `$['store']['book'][1]['title']`},
+			{Value: []byte(`"C"`), Path: `$['store']['book'][2]['title']`},
+		}},
+		{`$.store.book[-1].price`, []Match{{Value: []byte(`25`), Path: `$['store']['book'][2]['price']`}}},
+		{`$`, []Match{{Value: input, Path: `$`}}},
+		{`$.none`, []Match{}},
+	}
+
+	for _, tst := range tests {
+		matches, err := GetWithPaths(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if !reflect.DeepEqual(matches, tst.Expected) {
+			t.Errorf("%s\n\texpected %q\n\tbut got  %q", tst.Query, tst.Expected, matches)
+		}
+	}
+
+	if _, err := GetWithPaths(input, `$.store.book.length()`); err != errNotAddressable {
+		t.Errorf("expected errNotAddressable, got %v", err)
+	}
+}
+
+func Test_GetRange(t *testing.T) {
+
+	input := []byte(`{"a": [1, 1, 1], "b": {"c": "1"}}`)
+	tests := []struct {
+		Query    string
+		Expected [][2]int
+	}{
+		{`$.a[1]`, [][2]int{{10, 11}}},
+		{`$.a[*]`, [][2]int{{7, 8}, {10, 11}, {13, 14}}},
+		{`$.b`, [][2]int{{22, 32}}},
+		{`$..c`, [][2]int{{28, 31}}},
+		{`$.z`, [][2]int{}},
+	}
+
+	for _, tst := range tests {
+		ranges, err := GetRanges(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(ranges, tst.Expected) {
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v", tst.Query, tst.Expected, ranges)
+		}
+		start, end, err := GetRange(input, tst.Query)
+		switch {
+		case len(tst.Expected) == 0 && err != errFieldNotFound:
+			t.Errorf("%s: expected errFieldNotFound, got %v", tst.Query, err)
+		case len(tst.Expected) > 0 && (err != nil || [2]int{start, end} != tst.Expected[0]):
+			t.Errorf("%s\n\texpected %v\n\tbut got  %v (%v)", tst.Query, tst.Expected[0], [2]int{start, end}, err)
+		}
+	}
+}
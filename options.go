@@ -0,0 +1,131 @@
+package jsonslice
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures evaluation performed by GetWith
+type Option func(*tContext)
+
+// tContext holds options and counters of a single evaluation
+type tContext struct {
+	stats       *Stats
+	collector   StatsCollector
+	aggregating bool // the path aggregates values
+	debug       func(event DebugEvent)
+	offsets     *[][2]int         // (optional) result value offsets
+	sources     *[]SourceRef      // (optional) result value source map
+	locator     *tLocator         // records result value sources, see WithOffsets
+	docs        map[string][]byte // named documents available in filters as $name
+	err         error             // option error
+	functions   map[string]bool   // enabled opt-in functions
+	maxDepth    int               // deepscan depth limit, 0 means no limit
+	depth       int               // current deepscan depth
+	strict      bool              // strictly valid json result, see WithStrictJSON
+	pairs       bool              // key/value pairs result, see WithKeyValues
+	workers     int               // goroutines evaluating filters, see WithWorkers
+	policy      *Policy           // path restrictions, see WithPolicy
+	cancel      context.Context   // evaluation is stopped when done, see WithContext
+	done        <-chan struct{}   // cancel.Done()
+	keyMatch    KeyMatch          // object key comparison, see WithKeyMatch
+	objIndexes  bool              // object members selected by position, see WithObjectIndexes
+	disabled    Extension         // disabled syntax extensions, see WithoutExtensions
+	rfcCompare  bool              // RFC 9535 comparisons in filters, see WithRFCComparison
+	exact       bool              // exact number comparisons in filters, see WithExactNumbers
+	duplicates  DuplicateKeys     // members selected by a single key, see WithDuplicateKeys
+	root        []byte            // the document of a nested filter (see evalRootRefs)
+	format      *tFormat          // result reformatting, see WithCompact and WithIndent
+	jsonc       bool              // comments and trailing commas in the input, see WithJSONC
+	decoder     Decoder           // input format, see WithDecoder
+}
+
+// WithMaxDepth limits deepscan (..) to the values at most n levels below the node it starts at:
+// with n = 2 $..name finds the children and the grandchildren only. Deepscan references in filters
+// ([?(@..name)]) are limited the same way, counting from @. n <= 0 means no limit.
+func WithMaxDepth(n int) Option {
+	return func(ctx *tContext) {
+		ctx.maxDepth = n
+	}
+}
+
+// GetWith is the same as Get but accepts evaluation options.
+func GetWith(input []byte, path string, opts ...Option) ([]byte, error) {
+	ctx := &tContext{}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	if ctx.err != nil {
+		return nil, ctx.err
+	}
+	return ctx.run(input, path, func(input []byte) ([]byte, error) {
+		return get(input, path, ctx)
+	})
+}
+
+// run applies the options of the context to the evaluation of path on input (eval): decodes the input,
+// checks for cancellation, post-processes the result and reports the counters
+func (ctx *tContext) run(input []byte, path string, eval func(input []byte) ([]byte, error)) (result []byte, err error) {
+	if ctx.decoder != nil {
+		if input, err = ctx.decoder.Decode(input); err != nil {
+			return nil, err
+		}
+	}
+	if ctx.jsonc && len(input) > 0 {
+		input = stripJSONC(input)
+	}
+	if ctx.done != nil {
+		if err = ctx.cancelled(); err != nil {
+			return nil, err
+		}
+		defer func() {
+			// some nested evaluation errors are ignored: make sure a partial result is not returned
+			if err == nil {
+				if err = ctx.cancelled(); err != nil {
+					result = nil
+				}
+			}
+		}()
+	}
+	if ctx.format != nil {
+		defer func() {
+			if err == nil {
+				result, err = ctx.format.apply(result)
+			}
+		}()
+	}
+	if ctx.offsets != nil || ctx.sources != nil || ctx.strict {
+		ctx.locator = &tLocator{input: input}
+	}
+	if ctx.offsets != nil || ctx.sources != nil {
+		defer func() {
+			if err == nil {
+				err = ctx.locateMatches()
+			}
+		}()
+	}
+	if ctx.pairs {
+		defer func() {
+			if err == nil {
+				result, err = ctx.keyValues(input, path, result)
+			}
+		}()
+	} else if ctx.strict {
+		defer func() {
+			if err == nil {
+				result, err = ctx.strictJSON(input, result)
+			}
+		}()
+	}
+	if ctx.collector == nil {
+		return eval(input)
+	}
+
+	ctx.stats = &Stats{Path: path, InputSize: len(input)}
+	start := time.Now()
+	result, err = eval(input)
+	ctx.stats.Duration = time.Since(start)
+	ctx.stats.collect(result, ctx.aggregating, err)
+	ctx.collector.Collect(ctx.stats)
+	return result, err
+}
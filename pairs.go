@@ -0,0 +1,65 @@
+package jsonslice
+
+import (
+	"strconv"
+)
+
+// WithKeyValues makes an aggregating path return an object of the matched values keyed by their member names
+// instead of an array: $.store.* gives {"book": [...], "bicycle": {...}}. Array elements are keyed by their
+// indexes ("0", "1", ...). Keys may repeat if the values come from different objects ($..price).
+// A singular path returns the value itself. Combined with WithStrictJSON the values are validated and compacted.
+func WithKeyValues() Option {
+	return func(ctx *tContext) {
+		ctx.pairs = true
+	}
+}
+
+// keyValues rebuilds the result of an aggregating path as an object of key/value pairs
+func (ctx *tContext) keyValues(input []byte, path string, result []byte) ([]byte, error) {
+	if len(result) == 0 || !ctx.aggregating {
+		if ctx.strict {
+			return ctx.strictJSON(input, result)
+		}
+		return result, nil
+	}
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	if err = ctx.restrict(node); err != nil {
+		return nil, err
+	}
+	setRefContext(node, ctx)
+	evalRootRefs(input, node)
+
+	buf := append(make([]byte, 0, len(result)*2), '{')
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			if len(buf) > 1 {
+				buf = append(buf, ',')
+			}
+			if m.key != nil {
+				buf = jsonQuote(buf, m.key)
+			} else {
+				buf = append(strconv.AppendInt(append(buf, '"'), int64(m.index), 10), '"')
+			}
+			buf = append(buf, ':')
+			if !ctx.strict {
+				buf = append(buf, input[m.start:m.end]...)
+				return true, nil
+			}
+			var err error
+			buf, err = compactJSON(buf, input[m.start:m.end])
+			return err == nil, err
+		},
+	}
+	w.options(ctx)
+	if _, err = walkInput(input, node, w); err != nil {
+		if err == errNotAddressable { // function result
+			return result, nil
+		}
+		return nil, err
+	}
+	return append(buf, '}'), nil
+}
@@ -0,0 +1,43 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_KeyValues(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"title": "A", "price": 8}, {"title": "B", "price": 12}], "bicycle": {"color": "red", "price": 19}, "it\"s": 1}}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.*`, []byte(`{"book":[{"title": "A", "price": 8}, {"title": "B", "price": 12}],"bicycle":{"color": "red", "price": 19},"it\"s":1}`)},
+		{`$.store.bicycle['color','price']`, []byte(`{"color":"red","price":19}`)},
+		{`$..price`, []byte(`{"price":8,"price":12,"price":19}`)},
+		{`$.store.book[*].title`, []byte(`{"title":"A","title":"B"}`)},
+		{`$.store.book[:]`, []byte(`{"0":{"title": "A", "price": 8},"1":{"title": "B", "price": 12}}`)},
+		{`$.store[?(@.color)]`, []byte(`{"bicycle":{"color": "red", "price": 19}}`)},
+		{`$.store.book[?(@.price > 100)]`, []byte(`{}`)},
+		{`$.store.bicycle.color`, []byte(`"red"`)},
+		{`$.store.book.length()`, []byte(`2`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithKeyValues())
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	res, err := GetWith(input, `$.store.book[0]`, WithKeyValues(), WithStrictJSON())
+	if err != nil || string(res) != `{"title":"A","price":8}` {
+		t.Errorf("unexpected %s (%v)", res, err)
+	}
+	res, err = GetWith(input, `$.store.book[*]`, WithKeyValues(), WithStrictJSON())
+	if err != nil || string(res) != `{"0":{"title":"A","price":8},"1":{"title":"B","price":12}}` {
+		t.Errorf("unexpected %s (%v)", res, err)
+	}
+}
@@ -0,0 +1,114 @@
+package jsonslice
+
+import (
+	"sync"
+
+	"github.com/bhmj/xpression"
+)
+
+// parallelChunk is the minimum number of array elements per worker
+const parallelChunk = 256
+
+// WithWorkers evaluates filters ([?(...)]) over large arrays using up to n goroutines.
+// The results are merged in the order of the elements, so the result is the same as without the option.
+// Functions registered with RegisterFunction must be safe for concurrent use.
+func WithWorkers(n int) Option {
+	return func(ctx *tContext) {
+		ctx.workers = n
+	}
+}
+
+// parallel returns the number of workers for evaluating a filter over n array elements, 1 means sequential
+func (ctx *tContext) parallel(n int) int {
+	w := ctx.workers
+	if n/parallelChunk < w {
+		w = n / parallelChunk
+	}
+	if w < 1 {
+		return 1
+	}
+	return w
+}
+
+// arrayElemByFilterParallel is arrayElemByFilter evaluating the filter on several goroutines
+func arrayElemByFilterParallel(input []byte, nod *tNode, elems []tElem, workers int, inside bool) (result []byte, err error) {
+	matches := make([]bool, len(elems))
+	errs := make([]error, workers)
+	size := (len(elems) + workers - 1) / workers
+	nodes := make([]*tNode, workers)
+	nodes[0] = nod
+	for w := 1; w < workers; w++ {
+		nodes[w] = cloneFilter(nod) // xpression keeps intermediate results in the tokens
+	}
+	var wg sync.WaitGroup
+	for w, fnod := range nodes {
+		from, to := w*size, (w+1)*size
+		if to > len(elems) {
+			to = len(elems)
+		}
+		wg.Add(1)
+		go func(w, from, to int, fnod *tNode) {
+			defer wg.Done()
+			for k := from; k < to && errs[w] == nil; k++ {
+				matches[k], errs[w] = filterMatch(input[elems[k].start:elems[k].end], fnod, k)
+			}
+		}(w, from, to, fnod)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	var sub []byte
+	for k, el := range elems {
+		nod.ctx.emit(DebugFilter, nod, input[el.start:el.end], matches[k])
+		if matches[k] {
+			sub, err = getValue(input[el.start:el.end], nod.Next, inside) // recurse
+			if len(sub) > 0 {
+				result = plus(result, sub)
+			}
+		} else {
+			nod.ctx.skipped(nod, input[el.start:el.end])
+		}
+	}
+	return result, err
+}
+
+// cloneFilter returns a copy of the filter node which can be evaluated concurrently with the original
+func cloneFilter(nod *tNode) *tNode {
+	c := &tNode{Type: nod.Type, Filter: cloneTokens(nod.Filter), ctx: nod.ctx, root: nod.root, refs: nod.refs}
+	c.Calls = cloneCalls(nod.Calls)
+	return c
+}
+
+// cloneCalls returns a copy of the calls of a node along with the expression tokens of their arguments
+func cloneCalls(calls []*tCall) []*tCall {
+	if calls == nil {
+		return nil
+	}
+	res := make([]*tCall, len(calls))
+	for i, call := range calls {
+		cc := *call
+		cc.args = make([]*tArg, len(call.args))
+		for j, arg := range call.args {
+			ca := *arg
+			ca.toks = cloneTokens(arg.toks)
+			cc.args[j] = &ca
+		}
+		res[i] = &cc
+	}
+	return res
+}
+
+func cloneTokens(toks []*xpression.Token) []*xpression.Token {
+	if toks == nil {
+		return nil
+	}
+	res := make([]*xpression.Token, len(toks))
+	for i, tok := range toks {
+		t := *tok
+		res[i] = &t
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package jsonslice
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Workers(t *testing.T) {
+
+	var sb strings.Builder
+	sb.WriteString(`{"max": 11, "items": [`)
+	for i := 0; i < 5000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`{"id": ` + strconv.Itoa(i) + `, "name": "item` + strconv.Itoa(i) + `", "tags": ["t` + strconv.Itoa(i%7) + `"], "books": [{"price": ` + strconv.Itoa(i%13) + `}]}`)
+	}
+	sb.WriteString(`], "min": 4990}`)
+	input := []byte(sb.String())
+
+	tests := []string{
+		`$.items[?(@.id % 1000 == 7)].name`,
+		`$.items[?(@.name =~ /item49[0-9]7$/)].id`,
+		`$.items[?(@.id > $.min)]`,
+		`$.items[?(@.tags.length() == 1 && upper(@.tags[0]) == "T3" && @.id < 30)].id`,
+		`$.items[?(@.tags[0] in ["t1", "t2"] && @.id > 4980)].id`,
+		`$.items[?(@.nope)]`,
+		`$.items[?(index() % 1000 == 999)].id`,
+		`$.items[?(@.books[?(@.price > $.max)])].id`,
+	}
+
+	for _, path := range tests {
+		expected, err := Get(input, path)
+		if err != nil {
+			t.Errorf(path + " : " + err.Error())
+			continue
+		}
+		for _, workers := range []int{0, 1, 2, 8, 100} {
+			res, err := GetWith(input, path, WithWorkers(workers))
+			if err != nil {
+				t.Errorf(path + " : " + err.Error())
+			} else if compareSlices(res, expected) != 0 {
+				t.Errorf("%s (%d workers)\n\texpected `%s`\n\tbut got  `%s`", path, workers, expected, res)
+			}
+		}
+	}
+
+	// errors are reported as without workers
+	if _, err := GetWith(input, `$.items[?(@.id % 0 == 1)]`, WithWorkers(4)); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Benchmark_Jsonslice_Workers_10Mb(b *testing.B) {
+	b.StopTimer()
+	largeData := GenerateLargeData()
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = GetWith(largeData, `$.store.book[?(@.title =~ /^Title [0-9]*7$/)].price`, WithWorkers(8))
+	}
+}
@@ -0,0 +1,80 @@
+package jsonslice
+
+// A parent selector (.^ or .parent()) selects the container of the current value:
+// $..book[?(@.isbn)].^ is the array holding the books with isbn.
+// Parents are resolved by walk which keeps track of the containers of the current value.
+// Every value is selected once even if it is the parent of several matches.
+
+// tParent is a container of the current value during walk
+type tParent struct {
+	start int    // container start
+	key   []byte // member key of the container
+	index int    // array index of the container
+}
+
+// hasNode returns true if the node list contains a node of any of the types (cParent|cName)
+func hasNode(node *tNode, types int) bool {
+	for n := node; n != nil; n = n.Next {
+		if n.Type&types > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// walkParent continues the walk from the container of the current value.
+// The root has no parent and selects nothing.
+func walkParent(input []byte, nod *tNode, w *tWalker) (bool, error) {
+	n := len(w.parents)
+	if n == 0 {
+		return true, nil
+	}
+	p := w.parents[n-1]
+	if w.trace != nil {
+		e, err := skipValue(input, p.start)
+		if err != nil {
+			return false, err
+		}
+		w.trace.examine(nod, 1)
+		w.trace.matched(nod, p.start, e)
+	}
+	key, index := w.key, w.index
+	w.parents = w.parents[:n-1]
+	w.key, w.index = p.key, p.index
+	ok, err := walk(input, p.start, nod.Next, w)
+	w.parents = append(w.parents, p)
+	w.key, w.index = key, index
+	return ok, err
+}
+
+// getParents evaluates a node list containing parent selectors. The path up to the last parent
+// selector is walked, the rest of it ($..book[?(@.isbn)].^.length()) is evaluated on every value found.
+func getParents(input []byte, node *tNode) ([]byte, error) {
+	var last *tNode
+	for n := node; n != nil; n = n.Next {
+		if n.Type&cParent > 0 {
+			last = n
+		}
+	}
+	agg := aggregates(node)
+	rest := last.Next
+	last.Next = nil
+	defer func() { last.Next = rest }()
+
+	var res []byte
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			val, err := getValue(input[m.start:m.end:m.end], rest, true)
+			res = plus(res, val)
+			return err == nil, err
+		},
+	}
+	w.options(node.ctx)
+	if _, err := walkInput(input, node, w); err != nil {
+		return nil, err
+	}
+	if agg {
+		return append(append([]byte{'['}, res...), ']'), nil
+	}
+	return res, nil
+}
@@ -0,0 +1,49 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_Parent(t *testing.T) {
+
+	doc := []byte(`{"store":{"book":[{"t":"a","price":8},{"t":"b","price":12,"isbn":"x"},{"t":"c","price":22,"isbn":"y"}],"bicycle":{"color":"red","price":19}}}`)
+	books := `[{"t":"a","price":8},{"t":"b","price":12,"isbn":"x"},{"t":"c","price":22,"isbn":"y"}]`
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$..book[?(@.isbn)].^`, []byte(`[` + books + `]`)}, // the parent of several matches is selected once
+		{`$.store.book[?(@.price>10)].parent()`, []byte(`[` + books + `]`)},
+		{`$..[?(@.price>15)].^`, []byte(`[` + books + `,{"book":` + books + `,"bicycle":{"color":"red","price":19}}]`)},
+		{`$.store.book[0].^.^.bicycle.color`, []byte(`"red"`)},
+		{`$.store.bicycle.^.book[-1].t`, []byte(`"c"`)},
+		{`$..price.^.^.^`, []byte(`[{"book":` + books + `,"bicycle":{"color":"red","price":19}},{"store":{"book":` + books + `,"bicycle":{"color":"red","price":19}}}]`)},
+		{`$.store.book[?(@.price>10)].^.length()`, []byte(`[3]`)},
+		{`$.store.book[*].^.^.keys()`, []byte(`[["book","bicycle"]]`)},
+		{`$.store.book[?(@.t == 'b')].^[?(@.price < 10)].t`, []byte(`["a"]`)},
+		{`$.^`, []byte(``)}, // the root has no parent
+		{`$.store.none.^`, []byte(``)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(doc, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// walk-based evaluation
+	refs, _ := GetWithPaths(doc, `$.store.book[?(@.isbn == 'y')].^.^.bicycle`)
+	if len(refs) != 1 || refs[0].Path != `$['store']['bicycle']` {
+		t.Errorf("GetWithPaths: unexpected %v", refs)
+	}
+	res, _ := Delete(doc, `$..[?(@.isbn)].^`)
+	if string(res) != `{"store":{"bicycle":{"color":"red","price":19}}}` {
+		t.Errorf("Delete $..[?(@.isbn)].^\n\tbut got  `%s`", res)
+	}
+	if _, err := Get(doc, `$..^`); err == nil {
+		t.Errorf("$..^ : error expected")
+	}
+}
@@ -0,0 +1,80 @@
+package jsonslice
+
+// QueryPlan describes how a path is going to be evaluated
+type QueryPlan struct {
+	Path        string     // the path
+	Steps       []PlanStep // one step per path node
+	Aggregating bool       // the result is an array of values
+}
+
+// PlanStep describes evaluation of a single path node
+type PlanStep struct {
+	Node       string // node as written in the path (spaces removed), e.g. `.book` or `[-1]`
+	Kind       string // key, keys, pattern, index, indexes, slice, wildcard, filter, union, parent, name, function
+	Deep       bool   // deepscan (..): every nested value is visited
+	Seek       bool   // scanning stops as soon as the target is found
+	FullScan   bool   // the whole object or array has to be scanned
+	Tail       bool   // only the last elements of the array are scanned, from the end (negative indexes)
+	Aggregates bool   // the node may select more than one value
+}
+
+// Plan parses path and describes how it is going to be evaluated:
+// which nodes can stop early (seek), which scan an array from the end (negative indexes),
+// which require a full scan (deepscan, wildcards, filters) and which aggregate values.
+// Plan does not touch any data.
+func Plan(path string) (*QueryPlan, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+
+	plan := &QueryPlan{Path: path}
+	for n := node; n != nil; n = n.Next {
+		step := PlanStep{
+			Node:       string(n.Src),
+			Kind:       nodeKind(n),
+			Deep:       n.Type&cDeep > 0,
+			FullScan:   n.Type&(cFullScan|cWild|cDeep|cFilter|cGlob|cUnion) > 0,
+			Aggregates: n.Type&(cAgg|cSlice|cDeep|cWild|cFilter|cUnion) > 0,
+		}
+		if tailCount(n) > 0 {
+			step.FullScan, step.Tail = false, true
+		}
+		step.Seek = !step.FullScan && !step.Tail && n.Type&(cFunction|cParent|cName) == 0
+		plan.Aggregating = plan.Aggregating || step.Aggregates
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+// nodeKind returns a selector kind of the node
+func nodeKind(n *tNode) string {
+	switch {
+	case n.Type&cParent > 0:
+		return "parent"
+	case n.Type&cName > 0:
+		return "name"
+	case n.Type&cUnion > 0:
+		return "union"
+	case n.Type&cFunction > 0:
+		return "function"
+	case n.Type&cFilter > 0:
+		return "filter"
+	case n.Type&cScript > 0:
+		return "script"
+	case n.Type&cWild > 0:
+		return "wildcard"
+	case n.Type&cGlob > 0:
+		return "pattern"
+	case n.Type&cSlice > 0:
+		return "slice"
+	case n.Type&cAgg > 0 && len(n.Elems) == len(n.Keys):
+		return "indexes"
+	case n.Type&cAgg > 0:
+		return "keys"
+	case n.Slice[0] != cNAN && n.Slice[0] != cEmpty:
+		return "index"
+	}
+	return "key"
+}
@@ -0,0 +1,86 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_Plan(t *testing.T) {
+
+	tests := []struct {
+		Query       string
+		Expected    []PlanStep
+		Aggregating bool
+	}{
+		{`$.store.book[3].title`, []PlanStep{
+			{Node: ".store", Kind: "key", Seek: true},
+			{Node: ".book", Kind: "key", Seek: true},
+			{Node: "[3]", Kind: "index", Seek: true},
+			{Node: ".title", Kind: "key", Seek: true},
+		}, false},
+		{`$.book[-1]`, []PlanStep{
+			{Node: ".book", Kind: "key", Seek: true},
+			{Node: "[-1]", Kind: "index", Tail: true},
+		}, false},
+		{`$.book[1,-1]`, []PlanStep{
+			{Node: ".book", Kind: "key", Seek: true},
+			{Node: "[1,-1]", Kind: "indexes", FullScan: true, Aggregates: true},
+		}, true},
+		{`$[-3:]`, []PlanStep{
+			{Node: "[-3:]", Kind: "slice", Tail: true, Aggregates: true},
+		}, true},
+		{`$..book[1:3]['a','b']`, []PlanStep{
+			{Node: "..book", Kind: "key", Deep: true, FullScan: true, Aggregates: true},
+			{Node: "[1:3]", Kind: "slice", Seek: true, Aggregates: true},
+			{Node: "['a','b']", Kind: "keys", Seek: true, Aggregates: true},
+		}, true},
+		{`$[0,2].*`, []PlanStep{
+			{Node: "[0,2]", Kind: "indexes", Seek: true, Aggregates: true},
+			{Node: ".*", Kind: "wildcard", FullScan: true, Aggregates: true},
+		}, true},
+		{`$.store.b*`, []PlanStep{
+			{Node: ".store", Kind: "key", Seek: true},
+			{Node: ".b*", Kind: "pattern", FullScan: true, Aggregates: true},
+		}, true},
+		{`$.store.book[?(@.isbn)].^`, []PlanStep{
+			{Node: ".store", Kind: "key", Seek: true},
+			{Node: ".book", Kind: "key", Seek: true},
+			{Node: "[?(@.isbn)]", Kind: "filter", FullScan: true, Aggregates: true},
+			{Node: ".^", Kind: "parent"},
+		}, true},
+		{`$.store.*~`, []PlanStep{
+			{Node: ".store", Kind: "key", Seek: true},
+			{Node: ".*", Kind: "wildcard", FullScan: true, Aggregates: true},
+			{Node: "~", Kind: "name"},
+		}, true},
+		{`$.book[?(@.price > 10)].title.length()`, []PlanStep{
+			{Node: ".book", Kind: "key", Seek: true},
+			{Node: "[?(@.price>10)]", Kind: "filter", FullScan: true, Aggregates: true},
+			{Node: ".title", Kind: "key", Seek: true},
+			{Node: ".length()", Kind: "function"},
+		}, true},
+	}
+
+	for _, tst := range tests {
+		plan, err := Plan(tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if plan.Aggregating != tst.Aggregating {
+			t.Errorf("%s: aggregating expected %v", tst.Query, tst.Aggregating)
+		}
+		if len(plan.Steps) != len(tst.Expected) {
+			t.Errorf("%s: expected %d steps, got %+v", tst.Query, len(tst.Expected), plan.Steps)
+			continue
+		}
+		for i, step := range plan.Steps {
+			if step != tst.Expected[i] {
+				t.Errorf("%s: step %d\n\texpected %+v\n\tbut got  %+v", tst.Query, i, tst.Expected[i], step)
+			}
+		}
+	}
+
+	if _, err := Plan(`$.store(`); err == nil {
+		t.Errorf("error expected")
+	}
+}
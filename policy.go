@@ -0,0 +1,136 @@
+package jsonslice
+
+import (
+	"fmt"
+
+	"github.com/bhmj/xpression"
+)
+
+// Policy restricts the features a path may use, e.g. for paths supplied by untrusted users.
+// Zero values mean no restriction. References inside filters (@..name) are checked as well.
+type Policy struct {
+	NoDeepScan      bool // deny deepscan (..)
+	NoRegexp        bool // deny regular expressions (=~ /.../, match(), search())
+	MaxNodes        int  // maximum number of nodes, including the nodes of references in filters
+	MaxFilterTokens int  // maximum number of tokens in a filter expression
+}
+
+// WithPolicy makes GetWith check the path against the policy before evaluation.
+// A violation is reported as an error matching ErrPolicyViolation (errors.Is).
+func WithPolicy(p Policy) Option {
+	return func(ctx *tContext) {
+		ctx.policy = &p
+	}
+}
+
+// Check parses path and checks it against the policy without evaluating it
+func (p Policy) Check(path string) error {
+	node, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	defer repool(node)
+	nodes := 0
+	return p.check(node, &nodes)
+}
+
+// check checks the node list against the policy. nodes counts the nodes checked so far.
+func (p *Policy) check(node *tNode, nodes *int) error {
+	for n := node; n != nil; n = n.Next {
+		if err := p.checkNode(n, nodes); err != nil {
+			return err
+		}
+		for _, part := range n.Union { // selectors of a union count as nodes
+			if err := p.checkNode(part, nodes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkNode checks a single node
+func (p *Policy) checkNode(n *tNode, nodes *int) error {
+	*nodes++
+	if p.MaxNodes > 0 && *nodes > p.MaxNodes {
+		return fmt.Errorf("%w: more than %d nodes", ErrPolicyViolation, p.MaxNodes)
+	}
+	if p.NoDeepScan && n.Type&cDeep > 0 {
+		return fmt.Errorf("%w: deepscan is not allowed: %s", ErrPolicyViolation, n.Src)
+	}
+	tokens := countTokens(n.Filter)
+	if err := p.checkTokens(n.Filter, nodes); err != nil {
+		return err
+	}
+	for _, call := range n.Calls {
+		if p.NoRegexp && regexpFunctions[call.name] {
+			return fmt.Errorf("%w: regular expressions are not allowed: %s()", ErrPolicyViolation, call.name)
+		}
+		for _, arg := range call.args {
+			tokens += countTokens(arg.toks)
+			if err := p.checkTokens(arg.toks, nodes); err != nil {
+				return err
+			}
+			if err := p.checkRef(arg.ref, nodes); err != nil {
+				return err
+			}
+		}
+	}
+	if p.MaxFilterTokens > 0 && tokens > p.MaxFilterTokens {
+		return fmt.Errorf("%w: more than %d tokens in %s", ErrPolicyViolation, p.MaxFilterTokens, n.Src)
+	}
+	return nil
+}
+
+// checkTokens checks regular expressions and references of a filter expression
+func (p *Policy) checkTokens(toks []*xpression.Token, nodes *int) error {
+	for _, tok := range toks {
+		switch tok.Type {
+		case xpression.RegexpOperand:
+			if p.NoRegexp && tok.Regexp != nil {
+				return fmt.Errorf("%w: regular expressions are not allowed: /%s/", ErrPolicyViolation, tok.Regexp)
+			}
+		case xpression.VariableOperand:
+			if err := p.checkRef(tok.Str, nodes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// countTokens returns the number of operators and operands of an expression
+// (intermediate result placeholders are not counted)
+func countTokens(toks []*xpression.Token) int {
+	n := 0
+	for _, tok := range toks {
+		if tok.Category != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// checkRef checks a reference (@.a, $.a, $doc.a) used in a filter
+func (p *Policy) checkRef(ref []byte, nodes *int) error {
+	node := parseRef(ref)
+	if node == nil {
+		return nil
+	}
+	defer repool(node)
+	return p.check(node, nodes)
+}
+
+// parseRef parses a reference (@.a, $.a, $doc.a) used in a filter as a path.
+// Returns nil if ref is not a path or has errors (reported on evaluation).
+func parseRef(ref []byte) *tNode {
+	if len(ref) == 0 || (ref[0] != '@' && ref[0] != '$') {
+		return nil
+	}
+	_, path := docRef(ref) // $doc.a: skip the document name
+	node, err := parsePath("$" + string(path))
+	if err != nil {
+		return nil
+	}
+	return node
+}
@@ -0,0 +1,51 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Policy(t *testing.T) {
+
+	strict := Policy{NoDeepScan: true, NoRegexp: true, MaxNodes: 5, MaxFilterTokens: 7}
+	tests := []struct {
+		Query    string
+		Violates bool
+	}{
+		{`$.store.book[0].title`, false},
+		{`$.store.book[?(@.price > 10)].title`, false},
+		{`$..price`, true},
+		{`$..*..*..*`, true},
+		{`$.store.book[?(@..price)]`, true},                      // deepscan in a reference
+		{`$.store.book[?(@.author =~ /Tolk/)]`, true},            // regexp
+		{`$.store.book[?(@.author =~ /Tolk/)].length()`, true},   // regexp
+		{`$.store.book[?match(@.author, "Tolk.*")]`, true},       // regexp function
+		{`$.store.book[?(search(@.author, "Tolk"))]`, true},      // regexp function
+		{`$.a.b.c.d.e.f`, true},                                  // nodes
+		{`$.a.b[?(@.c.d.e.f)]`, true},                            // nodes in a reference
+		{`$.store.book[?(@.a > 1 && @.b > 2 && @.c > 3)]`, true}, // filter tokens
+		{`$.store.book[?(@.a > 1 && @.b > 2)]`, false},
+		{`$.store.book[?(@.price > $limits.max)]`, false},
+		{`$.store.book[?(@.price > $limits..max)]`, true},  // deepscan in a document reference
+		{`$.store.book[?(@.price > $limits2..max)]`, true}, // document name with a digit
+		{`$.store.book[?(@.price > $limits[0]..max)]`, true},
+		{`$.a[?(@.price > $limits.b.c.d.e)]`, true}, // nodes in a document reference
+	}
+
+	for _, tst := range tests {
+		err := strict.Check(tst.Query)
+		if tst.Violates != errors.Is(err, ErrPolicyViolation) {
+			t.Errorf(tst.Query+" : unexpected result %v", err)
+		}
+		_, err = GetWith(data, tst.Query, WithPolicy(strict))
+		if tst.Violates != errors.Is(err, ErrPolicyViolation) {
+			t.Errorf(tst.Query+" : unexpected result %v", err)
+		}
+	}
+
+	// zero policy allows everything
+	res, err := GetWith(data, `$..price`, WithPolicy(Policy{}))
+	if err != nil || compareSlices(res, []byte(`[8.95,12.99,8.99,22.99,19.95]`)) != 0 {
+		t.Errorf("$..price : unexpected result `%s` %v", res, err)
+	}
+}
@@ -0,0 +1,104 @@
+package jsonslice
+
+// WithObjectIndexes makes integer indexes and slices select object members by position (in document order),
+// as if the object were an array of its values: $[0], $[-1], $[1:3], $.a.first() on {"a":1,"b":2,"c":3}.
+// Only unquoted indexes in brackets are positional: $['2'] and $.2 still look up the key "2".
+func WithObjectIndexes() Option {
+	return func(ctx *tContext) {
+		ctx.objIndexes = true
+	}
+}
+
+// objectIndexes returns true if object members are selected by position
+func (ctx *tContext) objectIndexes() bool {
+	return ctx != nil && ctx.objIndexes
+}
+
+// positional returns true if the node selects by position: [0], [-1], [0,2], [1:3], [:]
+func positional(nod *tNode) bool {
+	return nod.Index || nod.Type&cSlice > 0
+}
+
+// tMember is a member of an object
+type tMember struct {
+	key   []byte // unescaped key
+	start int    // value start
+	end   int    // value end (excluded)
+}
+
+// appendMembers appends all the members of an object starting at input[i]
+func appendMembers(members []tMember, input []byte, i int) ([]tMember, error) {
+	l := len(input)
+	i++ // skip '{'
+	for i < l && input[i] != '}' {
+		key, j, err := readObjectKey(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			return members, nil
+		}
+		s, e, j, err := valuate(input, j)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, tMember{key, s, e})
+		i = j
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	return members, nil
+}
+
+// objectByIndex selects object members by position: the values are collected into an array
+// which the node is applied to
+func objectByIndex(input []byte, nod *tNode, inside bool) ([]byte, error) {
+	members, err := appendMembers(nil, input, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer nod.ctx.released(nod.ctx.copies())
+	arr := make([]byte, 0, len(input))
+	arr = append(arr, '[')
+	for k, m := range members {
+		if k > 0 {
+			arr = append(arr, ',')
+		}
+		arr = append(arr, input[m.start:m.end]...)
+		nod.ctx.copied(arr[len(arr)-(m.end-m.start):], input[m.start:m.end]) // the values are located in input (see WithOffsets)
+	}
+	arr = append(arr, ']')
+	if nod.Type&cSlice > 0 {
+		return arraySlice(arr, nod)
+	}
+	return arrayElemByIndex(arr, nod, inside)
+}
+
+// walkObjectByIndex visits object members selected by position (and deeper if deepscan)
+func walkObjectByIndex(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	members, err := appendMembers(nil, input, i)
+	if err != nil {
+		return false, err
+	}
+	n := len(members)
+	w.trace.examine(nod, n)
+	ok, err := visitIndexes(nod, n, func(k int) (bool, error) {
+		w.trace.matched(nod, members[k].start, members[k].end)
+		return walkMember(input, members[k], nod.Next, w)
+	})
+	if nod.Type&cDeep > 0 && w.descend() {
+		w.depth++
+		for k := 0; ok && err == nil && k < n; k++ {
+			ok, err = walkMember(input, members[k], nod, w)
+		}
+		w.depth--
+	}
+	return ok, err
+}
+
+// walkMember walks an object member
+func walkMember(input []byte, m tMember, nod *tNode, w *tWalker) (bool, error) {
+	w.key, w.index = m.key, -1
+	return walk(input, m.start, nod, w)
+}
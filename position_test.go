@@ -0,0 +1,49 @@
+package jsonslice
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ObjectIndexes(t *testing.T) {
+
+	doc := []byte(`{"a":"first","2":"second","b":{"x":1,"y":[{"p":1},{"q":2}]}}`)
+	b := `{"x":1,"y":[{"p":1},{"q":2}]}`
+	tests := []struct {
+		Query    string
+		Expected []byte
+		Offsets  [][2]int
+	}{
+		{`$[0]`, []byte(`"first"`), [][2]int{{5, 12}}},
+		{`$[2]`, []byte(b), [][2]int{{30, 59}}},
+		{`$[-1].x`, []byte(`1`), [][2]int{{35, 36}}},
+		{`$[0,1]`, []byte(`["first","second"]`), [][2]int{{5, 12}, {17, 25}}},
+		{`$[1:]`, []byte(`["second",` + b + `]`), [][2]int{{17, 25}, {30, 59}}},
+		{`$[::-2]`, []byte(`[` + b + `,"first"]`), [][2]int{{30, 59}, {5, 12}}},
+		{`$.b.first()`, []byte(`1`), [][2]int{{35, 36}}},
+		{`$.b.last()[1].q`, []byte(`2`), [][2]int{{55, 56}}},
+		{`$..[0]`, []byte(`["first",1,{"p":1},1,2]`), [][2]int{{5, 12}, {35, 36}, {42, 49}, {47, 48}, {55, 56}}},
+		{`$[?(@[0] == 1)]`, []byte(`[` + b + `]`), [][2]int{{30, 59}}},
+		// keys
+		{`$.2`, []byte(`"second"`), [][2]int{{17, 25}}},
+		{`$['2']`, []byte(`"second"`), [][2]int{{17, 25}}},
+	}
+
+	for _, tst := range tests {
+		var offsets [][2]int
+		res, err := GetWith(doc, tst.Query, WithObjectIndexes(), WithOffsets(&offsets))
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		} else if !reflect.DeepEqual(offsets, tst.Offsets) {
+			t.Errorf(tst.Query+"\n\texpected offsets %v\n\tbut got  %v", tst.Offsets, offsets)
+		}
+	}
+
+	// without the option an index is a key
+	res, _ := Get(doc, `$[2]`)
+	if string(res) != `"second"` {
+		t.Errorf("$[2]\n\texpected `\"second\"`\n\tbut got  `%s`", res)
+	}
+}
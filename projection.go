@@ -0,0 +1,82 @@
+package jsonslice
+
+import "bytes"
+
+// A projection builds an object of every value selected: $.store.book[*]{"name": @.title, "cost": @.price}.
+// Member values are filter expressions evaluated on the value, a member with an undefined value is omitted.
+// Keys are quoted strings or words: {name: @.title}. The rest of the path is applied to the objects.
+
+// readProjection reads a projection at path[i] ('{') into a function node
+func readProjection(path []byte, i int) (*tNode, int, error) {
+	e, err := closingBracket(path, i, len(path), '{', '}')
+	if err != nil {
+		return nil, i, err
+	}
+	r := &tRewriter{expr: path}
+	var keys [][]byte
+	var args []*tArg
+	for _, member := range splitArgs(path, i+1, e-1) {
+		key, j, err := readProjectionKey(path, member[0], member[1])
+		if err != nil {
+			return nil, j, err
+		}
+		if j == member[1] || path[j] != ':' {
+			return nil, j, errPathInvalidChar
+		}
+		out, err := r.rewrite(j+1, member[1])
+		if err != nil {
+			return nil, j, err
+		}
+		toks, err := parseExpression(out)
+		if err != nil {
+			return nil, j, err
+		}
+		keys = append(keys, key)
+		args = append(args, tokenArg(toks))
+	}
+	nod := getEmptyNode()
+	nod.Type = cFunction
+	nod.Calls = append(r.calls, &tCall{fn: fnProjection(keys), args: args})
+	nod.Src = path[i:e]
+	nod.Ext = ExtProjection
+	nod.Next, e, err = readRef(path, e, nod.Type)
+	return nod, e, err
+}
+
+// readProjectionKey reads a key of a projection member: a quoted string or a word
+func readProjectionKey(path []byte, i, e int) ([]byte, int, error) {
+	for i < e && (path[i] == ' ' || path[i] == '\t') {
+		i++
+	}
+	if i < e && (path[i] == '"' || path[i] == '\'') {
+		return readQuotedKey(path[:e], i)
+	}
+	s := i
+	for i < e && (isLetter(path[i]) || (path[i] >= '0' && path[i] <= '9') || path[i] == '_') {
+		i++
+	}
+	if i == s {
+		return nil, i, errPathInvalidChar
+	}
+	return path[s:i], i, nil
+}
+
+// fnProjection returns a function making an object of the keys and the values (arguments)
+func fnProjection(keys [][]byte) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		res := []byte{'{'}
+		n := 0
+		for k, val := range args {
+			val = finiteValue(bytes.TrimSpace(val))
+			if len(val) == 0 {
+				continue
+			}
+			if n > 0 {
+				res = append(res, ',')
+			}
+			res = append(append(jsonQuote(res, keys[k]), ':'), val...)
+			n++
+		}
+		return append(res, '}'), nil
+	}
+}
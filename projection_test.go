@@ -0,0 +1,37 @@
+package jsonslice
+
+import "testing"
+
+func Test_Projection(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"title": "A", "price": 8, "tags": ["x"]}, {"title": "B", "price": 12.5}, {"title": "C"}]}, "rate": 2}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[:]{ "name": @.title, "cost": @.price }`, []byte(`[{"name":"A","cost":8},{"name":"B","cost":12.5},{"name":"C"}]`)},
+		{`$.store.book[*]{name: @.title, total: @.price * $.rate, tags: @.tags}`, []byte(`[{"name":"A","total":16,"tags":["x"]},{"name":"B","total":25},{"name":"C"}]`)},
+		{`$.store.book[0]{n: @.title, big: @.price > 10, x: lower(@.title)}`, []byte(`{"n":"A","big":false,"x":"a"}`)},
+		{`$.store.book[?(@.price)]{"t": @.title, "in": "x" in @.tags}`, []byte(`[{"t":"A","in":true},{"t":"B","in":false}]`)},
+		{`$.store.book[*]{'a b': @.title, "c\"d": 1}`, []byte(`[{"a b":"A","c\"d":1},{"a b":"B","c\"d":1},{"a b":"C","c\"d":1}]`)},
+		{`$.store.book[*]{}`, []byte(`[{},{},{}]`)},
+		{`$.store.book[*]{n: @.title}.n`, []byte(`["A","B","C"]`)},
+		{`$.store.book[*]{n: @.title, p: @.price}.sortDesc(@.p).limit(1)`, []byte(`[{"n":"B","p":12.5}]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	for _, path := range []string{`$.store.book[*]{n @.title}`, `$.store.book[*]{n: @.title`, `$.store.book[*]{: 1}`} {
+		if _, err := Get(input, path); err == nil {
+			t.Errorf(path + " : error expected")
+		}
+	}
+}
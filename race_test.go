@@ -0,0 +1,6 @@
+//go:build race
+
+package jsonslice
+
+// raceEnabled is true if the race detector is on: it drops pooled values at random, which adds allocations
+const raceEnabled = true
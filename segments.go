@@ -0,0 +1,191 @@
+package jsonslice
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Segment describes a single parsed node of a path
+type Segment struct {
+	Source   string    // node as written in the path (spaces removed), e.g. `.book` or `[?(@.price>10)]`
+	Kind     string    // key, keys, pattern, index, indexes, slice, wildcard, filter, script, union, parent, name, function (see PlanStep)
+	Deep     bool      // deepscan (..): the node applies at any depth
+	Keys     []string  // member keys (key, keys) or key patterns (pattern)
+	Indexes  []int     // element indexes (index, indexes), negative ones count from the end
+	Start    *int      // slice start, nil if empty
+	End      *int      // slice end (excluded), nil if empty
+	Step     int       // slice step
+	Filter   string    // filter or script expression (filter, script)
+	Function string    // function name (function)
+	Union    []Segment // selectors (union)
+}
+
+// ParsePath parses and validates jsonpath without evaluating it. It is the same as Compile:
+// a syntax error is returned as *PathError, the parsed path can be inspected with Segments and Describe.
+func ParsePath(path string) (*Path, error) {
+	return Compile(path)
+}
+
+// Segments returns the parsed nodes of the path, one per selector. The root ($) is not included.
+func (p *Path) Segments() []Segment {
+	var segs []Segment
+	for n := p.parsed; n != nil; n = n.Next {
+		segs = append(segs, newSegment(n))
+	}
+	return segs
+}
+
+// newSegment describes a single node
+func newSegment(n *tNode) Segment {
+	seg := Segment{
+		Source: string(n.Src),
+		Kind:   nodeKind(n),
+		Deep:   n.Type&cDeep > 0,
+	}
+	switch seg.Kind {
+	case "key", "keys", "pattern":
+		for _, k := range n.Keys {
+			seg.Keys = append(seg.Keys, string(k))
+		}
+	case "index":
+		seg.Indexes = []int{n.Slice[0]}
+	case "indexes":
+		seg.Indexes = append(seg.Indexes, n.Elems...)
+	case "slice":
+		if n.Slice[0] != cEmpty {
+			start := n.Slice[0]
+			seg.Start = &start
+		}
+		if n.Slice[1] != cEmpty {
+			end := n.Slice[1]
+			seg.End = &end
+		}
+		seg.Step = n.Slice[2]
+		if seg.Step == cEmpty || seg.Step == 0 {
+			seg.Step = 1
+		}
+	case "filter":
+		seg.Filter = strings.TrimPrefix(strings.TrimPrefix(seg.Source, "["), "?(") // [?(...)] or ?(...) in a union
+		seg.Filter = strings.TrimSuffix(strings.TrimSuffix(seg.Filter, "]"), ")")
+	case "script":
+		seg.Filter = strings.TrimSuffix(strings.TrimPrefix(seg.Source, "["), "]")
+	case "function":
+		if len(n.Keys) > 0 { // a path expression has none
+			seg.Function = string(n.Keys[0])
+		}
+	case "union":
+		for _, part := range n.Union {
+			seg.Union = append(seg.Union, newSegment(part))
+		}
+	}
+	return seg
+}
+
+// Describe returns a human-readable explanation of what the path selects, one line per node:
+//
+//	$.store.book[?(@.price>10)].title
+//	  .store            member "store"
+//	  [?(@.price>10)]   members or elements matching @.price>10
+//	  ...
+func (p *Path) Describe() string {
+	segs := p.Segments()
+	width := 0
+	for _, seg := range segs {
+		if len(seg.Source) > width {
+			width = len(seg.Source)
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(p.path)
+	for _, seg := range segs {
+		sb.WriteString("\n  ")
+		sb.WriteString(seg.Source)
+		sb.WriteString(strings.Repeat(" ", width-len(seg.Source)+3))
+		sb.WriteString(seg.describe())
+	}
+	return sb.String()
+}
+
+// describe explains the segment in words
+func (seg *Segment) describe() string {
+	var s string
+	switch seg.Kind {
+	case "key":
+		s = "member " + strconv.Quote(seg.Keys[0])
+	case "keys":
+		s = "members " + quoteKeys(seg.Keys)
+	case "pattern":
+		s = "members matching " + quoteKeys(seg.Keys)
+	case "index":
+		s = "element " + describeIndex(seg.Indexes[0])
+	case "indexes":
+		list := make([]string, len(seg.Indexes))
+		for i, k := range seg.Indexes {
+			list[i] = describeIndex(k)
+		}
+		s = "elements " + strings.Join(list, ", ")
+	case "slice":
+		from, to := "the first", "the last"
+		if seg.Step < 0 {
+			from, to = to, from
+		}
+		if seg.Start != nil {
+			from = describeIndex(*seg.Start)
+		}
+		if seg.End != nil {
+			to = describeIndex(*seg.End) + " (excluded)"
+		}
+		s = "elements from " + from + " to " + to
+		if seg.Step != 1 {
+			s += ", step " + strconv.Itoa(seg.Step)
+		}
+	case "wildcard":
+		s = "all members or elements"
+	case "filter":
+		s = "members or elements matching " + seg.Filter
+	case "script":
+		s = "element at index " + seg.Filter
+	case "union":
+		parts := make([]string, len(seg.Union))
+		for i := range seg.Union {
+			parts[i] = seg.Union[i].describe()
+		}
+		s = "union of: " + strings.Join(parts, "; ")
+	case "parent":
+		s = "the container of the value"
+	case "name":
+		s = "the member name (or element index) of the value"
+	case "function":
+		s = "function " + seg.Function + "() of the value"
+		if aggregateFunctions[seg.Function] {
+			s = "function " + seg.Function + "() of all the values"
+		}
+		if seg.Function == "" {
+			s = "expression evaluated on the value"
+			if strings.HasPrefix(seg.Source, "{") {
+				s = "object of the expressions evaluated on the value"
+			}
+		}
+	}
+	if seg.Deep {
+		s += ", at any depth"
+	}
+	return s
+}
+
+// quoteKeys returns a comma separated list of quoted keys
+func quoteKeys(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = strconv.Quote(k)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// describeIndex explains an array index: 2, -1 (from the end)
+func describeIndex(k int) string {
+	if k < 0 {
+		return strconv.Itoa(k) + " (from the end)"
+	}
+	return strconv.Itoa(k)
+}
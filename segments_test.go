@@ -0,0 +1,96 @@
+package jsonslice
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Segments(t *testing.T) {
+
+	one, three := 1, 3
+	tests := []struct {
+		Query    string
+		Expected []Segment
+	}{
+		{`$`, nil},
+		{`$.store['a','b']`, []Segment{
+			{Source: `.store`, Kind: "key", Keys: []string{"store"}},
+			{Source: `['a','b']`, Kind: "keys", Keys: []string{"a", "b"}},
+		}},
+		{`$.b*['cpu_*','mem']`, []Segment{
+			{Source: `.b*`, Kind: "pattern", Keys: []string{"b*"}},
+			{Source: `['cpu_*','mem']`, Kind: "pattern", Keys: []string{"cpu_*", "mem"}},
+		}},
+		{`$.a.^.parent()`, []Segment{
+			{Source: `.a`, Kind: "key", Keys: []string{"a"}},
+			{Source: `.^`, Kind: "parent"},
+			{Source: `.parent()`, Kind: "parent"},
+		}},
+		{`$[0]~`, []Segment{
+			{Source: `[0]`, Kind: "index", Indexes: []int{0}},
+			{Source: `~`, Kind: "name"},
+		}},
+		{`$..book[-1][1,-2]`, []Segment{
+			{Source: `..book`, Kind: "key", Deep: true, Keys: []string{"book"}},
+			{Source: `[-1]`, Kind: "index", Indexes: []int{-1}},
+			{Source: `[1,-2]`, Kind: "indexes", Indexes: []int{1, -2}},
+		}},
+		{`$[1:3][::-1][*]`, []Segment{
+			{Source: `[1:3]`, Kind: "slice", Start: &one, End: &three, Step: 1},
+			{Source: `[::-1]`, Kind: "slice", Step: -1},
+			{Source: `[*]`, Kind: "wildcard"},
+		}},
+		{`$[?(@.price > 10)][(@.length-1)].length()`, []Segment{
+			{Source: `[?(@.price>10)]`, Kind: "filter", Filter: `@.price>10`},
+			{Source: `[(@.length-1)]`, Kind: "script", Filter: `(@.length-1)`},
+			{Source: `.length()`, Kind: "function", Function: "length"},
+		}},
+	}
+
+	for _, tst := range tests {
+		p, err := ParsePath(tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+			continue
+		}
+		if segs := p.Segments(); !reflect.DeepEqual(segs, tst.Expected) {
+			t.Errorf("%s\n\texpected %+v\n\tbut got  %+v", tst.Query, tst.Expected, segs)
+		}
+	}
+
+	if _, err := ParsePath(`$.store(foo`); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+func Test_Describe(t *testing.T) {
+
+	tests := []struct {
+		Query    string
+		Expected string
+	}{
+		{`$.store.book[?(@.price > 10)].title`, "$.store.book[?(@.price > 10)].title\n" +
+			"  .store            member \"store\"\n" +
+			"  .book             member \"book\"\n" +
+			"  [?(@.price>10)]   members or elements matching @.price>10\n" +
+			"  .title            member \"title\""},
+		{`$..*`, "$..*\n  ..*   all members or elements, at any depth"},
+		{`$[-3:].sum()`, "$[-3:].sum()\n" +
+			"  [-3:]    elements from -3 (from the end) to the last\n" +
+			"  .sum()   function sum() of all the values"},
+		{`$[::2][0,1]`, "$[::2][0,1]\n" +
+			"  [::2]   elements from the first to the last, step 2\n" +
+			"  [0,1]   elements 0, 1"},
+		{`$.a + 1`, "$.a + 1\n  $.a + 1   expression evaluated on the value"},
+		{`$[*]{n: @.name}`, "$[*]{n: @.name}\n" +
+			"  [*]          all members or elements\n" +
+			"  {n:@.name}   object of the expressions evaluated on the value"},
+	}
+
+	for _, tst := range tests {
+		res := MustCompile(tst.Query).Describe()
+		if res != tst.Expected {
+			t.Errorf(tst.Query + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + res + "`")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package jsonslice
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/bhmj/xpression"
+)
+
+// sort() and sortDesc() applied to an aggregated result sort the values: $.store.book[?(@.price)].sort(@.price)[0]
+// is the cheapest book. The arguments are sort keys evaluated on every value, the value itself if there are none.
+// Applied to a single array ($.tags.sort()) or used in filters (sort(@.tags)) they sort the elements.
+//
+// Values of different types are ordered as null < false < true < numbers < strings < arrays, objects;
+// values without a key (@.price is missing) follow the rest. The order of equal values is preserved.
+
+// sortArray sorts the elements of an array (the first argument of a call) by the keys (the rest of them)
+func sortArray(input []byte, nod *tNode, call *tCall) ([]byte, error) {
+	if len(call.args) == 0 {
+		return nil, errPathInvalidExpression
+	}
+	val, err := evalArg(input, nod, call.args[0])
+	if err != nil {
+		return nil, err
+	}
+	elems, ok := argArray(val)
+	if !ok {
+		return nil, nil
+	}
+	keys := make([][][]byte, len(elems))
+	for k, el := range elems {
+		if len(call.args) == 1 {
+			keys[k] = [][]byte{bytes.TrimSpace(el)}
+			continue
+		}
+		keys[k] = make([][]byte, len(call.args)-1)
+		for i, arg := range call.args[1:] {
+			if keys[k][i], err = evalArg(el, nod, arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	desc := call.name == "sortDesc"
+	order := make([]int, len(elems))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		x, y := keys[order[a]], keys[order[b]]
+		for i := range x {
+			if c := compareKeys(x[i], y[i], desc); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	res := []byte{'['}
+	for k, i := range order {
+		if k > 0 {
+			res = append(res, ',')
+		}
+		res = append(res, elems[i]...)
+	}
+	return append(res, ']'), nil
+}
+
+// compareKeys compares two sort keys: missing keys follow the rest in both orders
+func compareKeys(a, b []byte, desc bool) int {
+	a, b = bytes.TrimSpace(a), bytes.TrimSpace(b)
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return len(b) - len(a)
+	case desc:
+		return compareValues(b, a)
+	}
+	return compareValues(a, b)
+}
+
+// compareValues compares two json values of the same type, values of different types by their type rank
+func compareValues(a, b []byte) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	var x, y xpression.Operand
+	switch jsonType(a) {
+	case "number":
+		if decodeValue(a, &x) != nil || decodeValue(b, &y) != nil || x.Number == y.Number {
+			return 0
+		}
+		if x.Number < y.Number {
+			return -1
+		}
+		return 1
+	case "string":
+		if decodeValue(a, &x) != nil || decodeValue(b, &y) != nil {
+			return 0
+		}
+		return bytes.Compare(unescape(x.Str), unescape(y.Str))
+	}
+	return 0
+}
+
+// typeRank returns the position of the type of a value in the sort order
+func typeRank(val []byte) int {
+	switch jsonType(val) {
+	case "null":
+		return 0
+	case "boolean":
+		if val[0] == 'f' {
+			return 1
+		}
+		return 2
+	case "number":
+		return 3
+	case "string":
+		return 4
+	}
+	return 5
+}
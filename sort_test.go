@@ -0,0 +1,41 @@
+package jsonslice
+
+import "testing"
+
+func Test_Sort(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"t": "a", "isbn": "1", "price": 8.95}, {"t": "b", "price": 12.99}, {"t": "c", "isbn": "2", "price": 8.99}, {"t": "d", "isbn": "3", "price": 22.99}, {"t": "e"}], "bicycle": {"price": 19.95}}, "tags": ["z", "x", 3, null, true, "y", false, 1.5]}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.store.book[?(@.price)].sort(@.price)[0]`, []byte(`{"t": "a", "isbn": "1", "price": 8.95}`)},
+		{`$.store.book[*].sort(@.price)[*].t`, []byte(`["a","c","b","d","e"]`)},
+		{`$.store.book[*].sortDesc(@.price)[*].t`, []byte(`["d","b","c","a","e"]`)},
+		{`$.store.book[*].sortDesc(@.price)[0:2].t`, []byte(`["d","b"]`)},
+		{`$.store.book[*].sort(-@.price)[*].t`, []byte(`["d","b","c","a","e"]`)},
+		{`$.store.book[*].sort(@.isbn, @.price)[*].t`, []byte(`["a","c","d","b","e"]`)},
+		{`$.store.book.sort(@.t)[-1].t`, []byte(`"e"`)},
+		{`$..price.sort()`, []byte(`[8.95,8.99,12.99,19.95,22.99]`)},
+		{`$..price.sortDesc().limit(2)`, []byte(`[22.99,19.95]`)},
+		{`$..price.sortDesc().limit(2).min()`, []byte(`19.95`)},
+		{`$..book[*].sortDesc(@.price).limit(1)[0].t`, []byte(`"d"`)},
+		{`$..book[*].sort(@.price).offset(1).limit(1)[0].t`, []byte(`"c"`)},
+		{`$.store.book[*].t.sortDesc()`, []byte(`["e","d","c","b","a"]`)},
+		{`$.tags.sort()`, []byte(`[null,false,true,1.5,3,"x","y","z"]`)},
+		{`$.tags.sortDesc()`, []byte(`["z","y","x",3,1.5,true,false,null]`)},
+		{`$.store.book[?(@.t in limit(sortDesc(["c","a","b"]), 2))].t`, []byte(`["b","c"]`)},
+		{`$.store.book[?(@.t == "x")].sort(@.price)[0]`, nil},
+		{`$.store.sort()`, nil},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(input, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+}
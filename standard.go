@@ -0,0 +1,174 @@
+package jsonslice
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// The function extensions of RFC 9535 available in filters:
+//
+//	length(val)          -- number of characters of a string, elements of an array or members of an object
+//	count(query)         -- number of values selected by a query: count(@.authors[*])
+//	match(str, pattern)  -- true if the whole string matches the regular expression (I-Regexp)
+//	search(str, pattern) -- true if a substring matches the regular expression
+//	value(query)         -- the value selected by a query if there is exactly one
+//
+// count and value take node lists: a singular reference (@.a) is a list of one value (or none).
+
+// nodeListFunctions take node lists as arguments (see tArg.wrap)
+var nodeListFunctions = map[string]bool{"count": true, "value": true}
+
+// regexpFunctions take regular expressions (see Policy.NoRegexp)
+var regexpFunctions = map[string]bool{"match": true, "search": true}
+
+// fnLength returns the length of a string (in characters), an array or an object
+func fnLength(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	n := -1
+	switch jsonType(args[0]) {
+	case "string":
+		str, _ := argString(args[0])
+		n = utf8.RuneCount(str)
+	case "array":
+		elems, ok := argArray(args[0])
+		if ok {
+			n = len(elems)
+		}
+	case "object":
+		members, err := appendMembers(nil, bytes.TrimSpace(args[0]), 0)
+		if err == nil {
+			n = len(members)
+		}
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	return strconv.AppendInt(nil, int64(n), 10), nil
+}
+
+// fnCount returns the number of values of a node list
+func fnCount(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	if len(args[0]) == 0 {
+		return []byte("0"), nil
+	}
+	elems, ok := argArray(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return strconv.AppendInt(nil, int64(len(elems)), 10), nil
+}
+
+// fnValue returns the value of a node list of a single value
+func fnValue(ctx *tContext, args [][]byte) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errPathInvalidExpression
+	}
+	elems, ok := argArray(args[0])
+	if !ok || len(elems) != 1 {
+		return nil, nil
+	}
+	return elems[0], nil
+}
+
+// fnIndex is index(): the index of the array element (or the position of the object member) being filtered,
+// $.items[?(index() % 2 == 0)]. It is evaluated by evalCall, undefined outside of a filter.
+func fnIndex(ctx *tContext, args [][]byte) ([]byte, error) {
+	return nil, nil
+}
+
+// nodeIndex returns the index of the value being filtered by the node
+func nodeIndex(nod *tNode) []byte {
+	if nod.pos < 0 {
+		return nil
+	}
+	return strconv.AppendInt(nil, int64(nod.pos), 10)
+}
+
+// fnRegexp returns a function matching a string against a regular expression: as a whole or a substring of it.
+// A value which is not a string or an invalid pattern does not match.
+func fnRegexp(whole bool) tFilterFunc {
+	return func(ctx *tContext, args [][]byte) ([]byte, error) {
+		if len(args) != 2 {
+			return nil, errPathInvalidExpression
+		}
+		str, ok := argString(args[0])
+		pattern, okp := argString(args[1])
+		if !ok || !okp {
+			return jsonFalse, nil
+		}
+		re := compileIRegexp(string(pattern), whole)
+		return jsonBool(re != nil && re.Match(str)), nil
+	}
+}
+
+// iregexps caches compiled patterns: the pattern is usually the same for every element being filtered
+var iregexps struct {
+	sync.Mutex
+	cache map[string]*regexp.Regexp
+}
+
+// maxIRegexps limits the number of cached patterns
+const maxIRegexps = 256
+
+// compileIRegexp compiles an I-Regexp (RFC 9485) pattern, nil if the pattern is invalid
+func compileIRegexp(pattern string, whole bool) *regexp.Regexp {
+	key := pattern
+	if whole {
+		key = "\x00" + pattern
+	}
+	iregexps.Lock()
+	defer iregexps.Unlock()
+	if re, ok := iregexps.cache[key]; ok {
+		return re
+	}
+	expr := translateIRegexp(pattern)
+	if whole {
+		expr = `^(?:` + expr + `)$`
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		re = nil
+	}
+	if iregexps.cache == nil || len(iregexps.cache) >= maxIRegexps {
+		iregexps.cache = make(map[string]*regexp.Regexp)
+	}
+	iregexps.cache[key] = re
+	return re
+}
+
+// translateIRegexp converts an I-Regexp into Go syntax: '.' outside of a character class
+// matches any character except line breaks \n and \r
+func translateIRegexp(pattern string) string {
+	if !strings.Contains(pattern, ".") {
+		return pattern
+	}
+	var sb strings.Builder
+	class := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			sb.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[':
+			class = true
+		case c == ']':
+			class = false
+		case c == '.' && !class:
+			sb.WriteString(`[^\n\r]`)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
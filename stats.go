@@ -0,0 +1,75 @@
+package jsonslice
+
+import (
+	"time"
+)
+
+// Stats holds counters of a single GetWith call
+type Stats struct {
+	Path          string        // the path
+	InputSize     int           // input size in bytes
+	BytesScanned  int           // total size of the values skipped or returned
+	ValuesSkipped int           // keys and array elements skipped without descending into them
+	Matches       int           // number of values in the result
+	Allocs        int           // estimated number of allocations made to build the result
+	Duration      time.Duration // evaluation time
+	Err           error         // evaluation error if any
+}
+
+// StatsCollector receives counters of every GetWith call made with WithStats option.
+// Collect is called synchronously after evaluation so it should be cheap
+// (e.g. update Prometheus counters and histograms).
+type StatsCollector interface {
+	Collect(stats *Stats)
+}
+
+// WithStats sets a collector receiving evaluation counters.
+// Several WithStats options add collectors receiving the same counters.
+func WithStats(collector StatsCollector) Option {
+	return func(ctx *tContext) {
+		if ctx.collector != nil {
+			ctx.collector = statsCollectors{ctx.collector, collector}
+			return
+		}
+		ctx.collector = collector
+	}
+}
+
+// statsCollectors passes the counters to several collectors
+type statsCollectors []StatsCollector
+
+func (c statsCollectors) Collect(stats *Stats) {
+	for _, collector := range c {
+		collector.Collect(stats)
+	}
+}
+
+// skipped counts a value skipped during evaluation
+func (ctx *tContext) skipped(nod *tNode, value []byte) {
+	if ctx == nil {
+		return
+	}
+	ctx.emit(DebugSkip, nod, value, false)
+	if ctx.stats == nil {
+		return
+	}
+	ctx.stats.ValuesSkipped++
+	ctx.stats.BytesScanned += len(value)
+}
+
+// collect counts result values
+func (s *Stats) collect(result []byte, aggregating bool, err error) {
+	s.Err = err
+	s.BytesScanned += len(result)
+	if err != nil || len(result) == 0 {
+		return
+	}
+	if !aggregating {
+		s.Matches = 1
+		return
+	}
+	// count top-level elements of the aggregated result
+	elems, _ := arrayElems(result, 0)
+	s.Matches = len(elems)
+	s.Allocs = s.Matches + 1 // every value appended + final brackets
+}
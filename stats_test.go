@@ -0,0 +1,90 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+type testCollector struct {
+	stats []Stats
+}
+
+func (c *testCollector) Collect(s *Stats) {
+	c.stats = append(c.stats, *s)
+}
+
+func Test_Stats(t *testing.T) {
+	input := []byte(`{"skip": [1, 2, 3], "book": [{"price": 8}, {"price": 22}, {"title": "x"}]}`)
+
+	tests := []struct {
+		Query   string
+		Skipped int
+		Scanned int
+		Matches int
+		Allocs  int
+	}{
+		// single value: no allocations
+		{`$.book[1].price`, 2, 23, 1, 0},
+		// filter: rejected elements are skipped
+		{`$.book[?(@.price > 10)]`, 3, 50, 1, 2},
+		// aggregated
+		{`$.book[:].price`, 2, 18, 2, 3},
+		// not found
+		{`$.none`, 2, 54, 0, 0},
+	}
+
+	for _, tst := range tests {
+		c := &testCollector{}
+		res, err := GetWith(input, tst.Query, WithStats(c))
+		expected, _ := Get(input, tst.Query)
+		if err != nil || compareSlices(res, expected) != 0 {
+			t.Errorf("%s: unexpected result `%s` (%v)", tst.Query, res, err)
+		}
+		if len(c.stats) != 1 {
+			t.Errorf("%s: expected a single Collect call", tst.Query)
+			continue
+		}
+		s := c.stats[0]
+		if s.Path != tst.Query || s.InputSize != len(input) || s.ValuesSkipped != tst.Skipped ||
+			s.BytesScanned != tst.Scanned || s.Matches != tst.Matches || s.Allocs != tst.Allocs {
+			t.Errorf("%s: unexpected stats %+v", tst.Query, s)
+		}
+
+		// compiled path: same counters
+		p := MustCompile(tst.Query)
+		pc := &testCollector{}
+		res, err = p.GetWith(input, WithStats(pc))
+		if err != nil || compareSlices(res, expected) != 0 {
+			t.Errorf("%s: unexpected compiled result `%s` (%v)", tst.Query, res, err)
+		}
+		if len(pc.stats) != 1 {
+			t.Errorf("%s: expected a single Collect call", tst.Query)
+			continue
+		}
+		pc.stats[0].Duration = s.Duration
+		if pc.stats[0] != s {
+			t.Errorf("%s: compiled path stats %+v differ from %+v", tst.Query, pc.stats[0], s)
+		}
+		// calls without a collector report nothing
+		_, _ = p.Get(input)
+		_, _ = p.GetWith(input)
+		if len(pc.stats) != 1 {
+			t.Errorf("%s: unexpected Collect call", tst.Query)
+		}
+	}
+
+	// several collectors receive the same counters
+	c1, c2 := &testCollector{}, &testCollector{}
+	if _, err := GetWith(input, `$.book[:].price`, WithStats(c1), WithStats(c2)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(c1.stats) != 1 || len(c2.stats) != 1 || c1.stats[0].Matches != 2 || c2.stats[0].Matches != 2 {
+		t.Errorf("both collectors expected to be called: %+v %+v", c1.stats, c2.stats)
+	}
+
+	// error is reported
+	c := &testCollector{}
+	_, err := GetWith(input, `$.`, WithStats(c))
+	if err == nil || len(c.stats) != 1 || c.stats[0].Err != err {
+		t.Errorf("error expected in stats")
+	}
+}
@@ -0,0 +1,177 @@
+package jsonslice
+
+import (
+	"bufio"
+	"io"
+)
+
+// ArrayIterator yields the elements of a top-level json array read from a stream one by one:
+//
+//	it := jsonslice.IterateArray(file, "$.id")
+//	for it.Next() {
+//		fmt.Println(it.Index(), string(it.Value()))
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Only a single element is kept in memory at a time, so arbitrarily large arrays can be processed.
+type ArrayIterator struct {
+	rd      *bufio.Reader
+	path    string
+	sub     *Path  // (optional) path applied to every element
+	elem    []byte // current element (reused)
+	value   []byte
+	index   int
+	err     error
+	started bool
+	done    bool
+}
+
+// IterateArray returns an iterator over the elements of a json array read from r.
+// If path is not empty (or "$") it is applied to every element and the elements it does not match are skipped:
+// "$.user.id" yields the user ids of the elements. The array is read lazily on calls to Next.
+func IterateArray(r io.Reader, path string) *ArrayIterator {
+	return &ArrayIterator{rd: bufio.NewReader(r), path: path, index: -1}
+}
+
+// Next advances the iterator to the next element. Returns false when there are no more elements or an error occurred.
+func (it *ArrayIterator) Next() bool {
+	it.value = nil
+	if it.err != nil || it.done {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if len(it.path) > 0 && it.path != "$" {
+			if it.sub, it.err = Compile(it.path); it.err != nil {
+				return false
+			}
+		}
+		ch, err := it.skipSpaces()
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if ch != '[' {
+			it.err = errArrayExpected
+			return false
+		}
+	}
+	for {
+		ok, err := it.readElem()
+		if !ok || err != nil {
+			it.err = err
+			it.done = true
+			return false
+		}
+		it.index++
+		if it.sub == nil {
+			it.value = it.elem
+			return true
+		}
+		if it.value, it.err = it.sub.Get(it.elem); it.err != nil {
+			return false
+		}
+		if len(it.value) > 0 {
+			return true
+		}
+	}
+}
+
+// Value returns the current element (or the result of the path applied to it).
+// The value is only valid until the next call to Next.
+func (it *ArrayIterator) Value() []byte {
+	return it.value
+}
+
+// Index returns the index of the current element in the array
+func (it *ArrayIterator) Index() int {
+	return it.index
+}
+
+// Err returns the error occurred during iteration, if any
+func (it *ArrayIterator) Err() error {
+	return it.err
+}
+
+// skipSpaces returns the next non-space character (commas are skipped too)
+func (it *ArrayIterator) skipSpaces() (byte, error) {
+	for {
+		ch, err := it.rd.ReadByte()
+		if err == io.EOF {
+			return 0, errUnexpectedEnd
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !bytein(ch, []byte{' ', '\t', '\r', '\n', ','}) {
+			return ch, nil
+		}
+	}
+}
+
+// readElem reads the next array element into it.elem. Returns false at the end of the array.
+func (it *ArrayIterator) readElem() (bool, error) {
+	ch, err := it.skipSpaces()
+	if err != nil || ch == ']' {
+		return false, err
+	}
+	it.elem = append(it.elem[:0], ch)
+	depth := 0
+	switch ch {
+	case '"':
+		return true, it.readString()
+	case '{', '[':
+		depth = 1
+	}
+	for {
+		ch, err = it.rd.ReadByte()
+		if err == io.EOF {
+			if depth == 0 {
+				return true, nil // a scalar at the end of (truncated) input
+			}
+			return false, errUnexpectedEnd
+		}
+		if err != nil {
+			return false, err
+		}
+		if depth == 0 && bytein(ch, []byte{' ', '\t', '\r', '\n', ',', ']'}) {
+			return true, it.rd.UnreadByte() // end of scalar
+		}
+		it.elem = append(it.elem, ch)
+		switch ch {
+		case '"':
+			if err = it.readString(); err != nil {
+				return false, err
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth--; depth == 0 {
+				return true, nil
+			}
+		}
+	}
+}
+
+// readString reads the rest of a string (the opening quote has already been read) into it.elem
+func (it *ArrayIterator) readString() error {
+	escaped := false
+	for {
+		ch, err := it.rd.ReadByte()
+		if err == io.EOF {
+			return errUnexpectedStringEnd
+		}
+		if err != nil {
+			return err
+		}
+		it.elem = append(it.elem, ch)
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '"':
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package jsonslice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_IterateArray(t *testing.T) {
+
+	input := ` [ {"id": 1, "tags": ["a", "]"]}, "x\"]", 42 ,true, [1, [2]], {"id": 2}, null,-1.5e3 ] `
+
+	tests := []struct {
+		Path     string
+		Expected []string
+		Indexes  []int
+	}{
+		{``, []string{`{"id": 1, "tags": ["a", "]"]}`, `"x\"]"`, `42`, `true`, `[1, [2]]`, `{"id": 2}`, `null`, `-1.5e3`}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{`$`, []string{`{"id": 1, "tags": ["a", "]"]}`, `"x\"]"`, `42`, `true`, `[1, [2]]`, `{"id": 2}`, `null`, `-1.5e3`}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{`$.id`, []string{`1`, `2`}, []int{0, 5}},
+		{`$.tags[-1]`, []string{`"]"`}, []int{0}},
+		{`$[1]`, []string{`[2]`}, []int{4}},
+	}
+
+	for _, tst := range tests {
+		var res []string
+		var idx []int
+		it := IterateArray(iotest.OneByteReader(strings.NewReader(input)), tst.Path)
+		for it.Next() {
+			res = append(res, string(it.Value()))
+			idx = append(idx, it.Index())
+		}
+		if it.Err() != nil {
+			t.Errorf(tst.Path + " : " + it.Err().Error())
+			continue
+		}
+		if !reflect.DeepEqual(res, tst.Expected) || !reflect.DeepEqual(idx, tst.Indexes) {
+			t.Errorf("%s\n\texpected %q %v\n\tbut got  %q %v", tst.Path, tst.Expected, tst.Indexes, res, idx)
+		}
+	}
+
+	errors := []struct {
+		Input string
+		Path  string
+		Err   error
+	}{
+		{`{"a": 1}`, ``, errArrayExpected},
+		{``, ``, errUnexpectedEnd},
+		{`[1, {"a": [2]`, ``, errUnexpectedEnd},
+		{`[1, "abc`, ``, errUnexpectedStringEnd},
+		{`[1, 2]`, `$.`, errPathUnexpectedEnd},
+	}
+	for _, tst := range errors {
+		it := IterateArray(strings.NewReader(tst.Input), tst.Path)
+		for it.Next() {
+		}
+		if it.Err() == nil || !strings.HasPrefix(it.Err().Error(), tst.Err.Error()) {
+			t.Errorf("%s: expected %v, got %v", tst.Input, tst.Err, it.Err())
+		}
+	}
+}
+
+func Benchmark_Jsonslice_IterateArray_10Mb(b *testing.B) {
+	b.StopTimer()
+	largeData := GenerateLargeData()
+	arr, _ := Get(largeData, "$.store.book")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		it := IterateArray(strings.NewReader(string(arr)), "$.title")
+		for it.Next() {
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package jsonslice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// WithStrictJSON makes the result strictly valid compact json of the same shape regardless of the path:
+// an aggregating path ($[:]['a','b'], $..book[?(@.isbn)]) returns a flat array of the matched values
+// in document order, a singular path returns the value itself. Returns an error if a value is not valid json.
+func WithStrictJSON() Option {
+	return func(ctx *tContext) {
+		ctx.strict = true
+	}
+}
+
+// strictJSON rebuilds the result of path as a flat array of the matched values (if the path aggregates)
+// and validates the values
+func (ctx *tContext) strictJSON(input []byte, result []byte) ([]byte, error) {
+	if len(result) == 0 {
+		return result, nil
+	}
+	refs, err := ctx.locator.sources(false)
+	if err == errNotAddressable {
+		// function result
+		return compactJSON(nil, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ctx.aggregating {
+		if len(refs) == 0 {
+			return nil, nil
+		}
+		return compactJSON(nil, input[refs[0].Start:refs[0].End])
+	}
+	buf := append(make([]byte, 0, len(result)), '[')
+	for i, ref := range refs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if buf, err = compactJSON(buf, input[ref.Start:ref.End]); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, ']'), nil
+}
+
+// compactJSON appends a validated json value with insignificant whitespace removed to buf
+func compactJSON(buf []byte, val []byte) ([]byte, error) {
+	out := bytes.NewBuffer(buf)
+	if err := json.Compact(out, val); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidValue, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,42 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_StrictJSON(t *testing.T) {
+
+	input := []byte(`{"items": [{"a": 1, "b": "x"}, {"a": 2, "b": "y"}, {"a": 3}], "o": {"a": 1, "b": { "c" : [ 5 ] }}}`)
+
+	tests := []struct {
+		Query    string
+		Expected []byte
+	}{
+		{`$.items[:]['a','b']`, []byte(`[1,"x",2,"y",3]`)},
+		{`$.items[?(@.a > 1)]['a','b']`, []byte(`[2,"y",3]`)},
+		{`$..items[?(@.a)].a`, []byte(`[1,2,3]`)},
+		{`$.items[?(@.a > 5)]`, []byte(`[]`)},
+		{`$.items[0,0]`, []byte(`[{"a":1,"b":"x"}]`)},
+		{`$.items[2,0].a`, []byte(`[1,3]`)},
+		{`$.o..*`, []byte(`[1,{"c":[5]},[5],5]`)},
+		{`$.o.b`, []byte(`{"c":[5]}`)},
+		{`$.o`, []byte(`{"a":1,"b":{"c":[5]}}`)},
+		{`$.o.z`, []byte(``)},
+		{`$.items.length()`, []byte(`3`)},
+	}
+
+	for _, tst := range tests {
+		res, err := GetWith(input, tst.Query, WithStrictJSON())
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// single-quoted strings are accepted by Get but are not valid json
+	if _, err := GetWith([]byte(`{"a": ['x']}`), `$.a`, WithStrictJSON()); !errors.Is(err, errInvalidValue) {
+		t.Errorf("expected errInvalidValue, got %v", err)
+	}
+}
@@ -1,7 +1,14 @@
 package jsonslice
 
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
 // readQuotedKey reads quoted key. Allocates memory if necessasry.
 // Consumes right bound.
+// Follows RFC 9535 string literal rules: control characters must be escaped,
+// the opposite quote must not be escaped (see readEscape).
 // Returns key []byte -- sliced or allocated (without quotes), i after last quote
 func readQuotedKey(path []byte, i int) ([]byte, int, error) {
 	l := len(path)
@@ -14,9 +21,15 @@ func readQuotedKey(path []byte, i int) ([]byte, int, error) {
 	copying := false
 
 	for i < l && path[i] != bound {
+		if path[i] < 0x20 {
+			return nil, i, errPathInvalidChar
+		}
 		if path[i] == '\\' {
 			// get escaped value
 			before := i
+			if i+1 < l && (path[i+1] == '\'' || path[i+1] == '"') && path[i+1] != bound {
+				return nil, i, errPathUnknownEscape // \' in "..." or \" in '...'
+			}
 			esc, i, err = readEscape(path, i)
 			if err != nil {
 				return nil, i, err
@@ -43,7 +56,7 @@ func readQuotedKey(path []byte, i int) ([]byte, int, error) {
 }
 
 // readEscape reads escape sequence. path[i] must be '\' symbol.
-// Supports \n, \r, \t, \0, \', \", \\, \x, \u.
+// Supports \b, \f, \n, \r, \t, \/, \\, \', \" and \uXXXX (surrogate pairs are combined), see RFC 9535.
 // Returns UTF-8 rune as []byte, next character pointer or error.
 func readEscape(path []byte, i int) ([]byte, int, error) {
 	l := len(path)
@@ -52,118 +65,67 @@ func readEscape(path []byte, i int) ([]byte, int, error) {
 		return nil, i, errPathUnexpectedEnd
 	}
 	switch path[i] {
+	case 'b':
+		return []byte{'\b'}, i + 1, nil
+	case 'f':
+		return []byte{'\f'}, i + 1, nil
 	case 'n':
-		return []byte{'\x0A'}, i + 1, nil
+		return []byte{'\n'}, i + 1, nil
 	case 'r':
-		return []byte{'\x0D'}, i + 1, nil
+		return []byte{'\r'}, i + 1, nil
 	case 't':
-		return []byte{'\x09'}, i + 1, nil
-	case '0':
-		return []byte{'\x00'}, i + 1, nil
-	case '\'':
-		return []byte{'\''}, i + 1, nil
-	case '"':
-		return []byte{'"'}, i + 1, nil
-	case '\\':
-		return []byte{'\\'}, i + 1, nil
-	case 'x':
-		return readHexByte(path, i+1)
+		return []byte{'\t'}, i + 1, nil
+	case '/', '\'', '"', '\\':
+		return []byte{path[i]}, i + 1, nil
 	case 'u':
-		return readUnicodeSequence(4, path, i+1)
-	case 'U':
-		return readUnicodeSequence(8, path, i+1)
+		return readUnicodeEscape(path, i+1)
 	}
 	return nil, i, errPathUnknownEscape
 }
 
-func readHexByte(path []byte, i int) ([]byte, int, error) {
-	value := uint32(0)
-	var err error
-
-	l := len(path)
-	for num := 0; i < l && num < 2; i++ {
-		value, err = readNextHexNum(value, path[i])
+// readUnicodeEscape reads XXXX of \uXXXX (and the low surrogate \uXXXX following a high one).
+// Unpaired surrogates are rejected.
+func readUnicodeEscape(path []byte, i int) ([]byte, int, error) {
+	r, i, err := readHex4(path, i)
+	if err != nil {
+		return nil, i, err
+	}
+	if utf16.IsSurrogate(r) {
+		if r >= 0xDC00 || i+1 >= len(path) || path[i] != '\\' || path[i+1] != 'u' {
+			return nil, i, errPathInvalidSurrogate
+		}
+		var low rune
+		low, i, err = readHex4(path, i+2)
 		if err != nil {
 			return nil, i, err
 		}
-		num++
+		if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+			return nil, i, errPathInvalidSurrogate
+		}
 	}
-	return []byte{byte(value)}, i, nil
+	return utf8.AppendRune(nil, r), i, nil
 }
 
-func readNextHexNum(value uint32, ch byte) (uint32, error) {
-	if ch >= '0' && ch <= '9' {
-		value = (value << 4) + uint32(ch-'0')
-	} else if ch >= 'A' && ch <= 'F' {
-		value = (value << 4) + uint32(ch-'A'+10)
-	} else if ch >= 'a' && ch <= 'f' {
-		value = (value << 4) + uint32(ch-'a'+10)
-	} else {
-		return value, errPathUnknownEscape
+// readHex4 reads 4 hex digits
+func readHex4(path []byte, i int) (rune, int, error) {
+	if i+4 > len(path) {
+		return 0, len(path), errPathUnexpectedEnd
 	}
-	return value, nil
-}
-
-func readUnicodeSequence(length int, path []byte, i int) ([]byte, int, error) {
-	var err error
-	codepoint := uint32(0)
-	l := len(path)
-	num := 0
-	for ; i < l && num < length; i++ {
-		codepoint, err = readNextHexNum(codepoint, path[i])
-		if err != nil {
-			return nil, i, err
+	r := rune(0)
+	for k := 0; k < 4; k, i = k+1, i+1 {
+		ch := path[i]
+		switch {
+		case ch >= '0' && ch <= '9':
+			r = r<<4 + rune(ch-'0')
+		case ch >= 'A' && ch <= 'F':
+			r = r<<4 + rune(ch-'A'+10)
+		case ch >= 'a' && ch <= 'f':
+			r = r<<4 + rune(ch-'a'+10)
+		default:
+			return 0, i, errPathUnknownEscape
 		}
-		num++
-	}
-	if i == l && num < length {
-		return nil, i, errPathUnexpectedEnd
-	}
-	res, err := codepointToUTF8(codepoint)
-	return res, i, err
-}
-
-func codepointToUTF8(codepoint uint32) ([]byte, error) {
-	if codepoint <= 0x7F {
-		return []byte{byte(codepoint)}, nil
-	} else if codepoint <= 0x7FF {
-		return []byte{
-			0xC0 + byte((codepoint>>6)&0x3F),
-			0x80 + byte(codepoint&0x3F),
-		}, nil
-	} else if codepoint <= 0xFFFF {
-		return []byte{
-			0xE0 + byte((codepoint>>12)&0x3F),
-			0x80 + byte((codepoint>>6)&0x3F),
-			0x80 + byte(codepoint&0x3F),
-		}, nil
-	} else if codepoint <= 0x1FFFFF {
-		return []byte{
-			0xF0 + byte((codepoint>>18)&0x3F),
-			0x80 + byte((codepoint>>12)&0x3F),
-			0x80 + byte((codepoint>>6)&0x3F),
-			0x80 + byte(codepoint&0x3F),
-		}, nil
-	} else if codepoint <= 0x3FFFFFF {
-		return []byte{
-			0xF8 + byte((codepoint>>24)&0x3F),
-			0x80 + byte((codepoint>>18)&0x3F),
-			0x80 + byte((codepoint>>12)&0x3F),
-			0x80 + byte((codepoint>>6)&0x3F),
-			0x80 + byte(codepoint&0x3F),
-		}, nil
-	} else if codepoint <= 0x7FFFFFFF {
-		return []byte{
-			0xFC + byte((codepoint>>30)&0x3F),
-			0x80 + byte((codepoint>>24)&0x3F),
-			0x80 + byte((codepoint>>18)&0x3F),
-			0x80 + byte((codepoint>>12)&0x3F),
-			0x80 + byte((codepoint>>6)&0x3F),
-			0x80 + byte(codepoint&0x3F),
-		}, nil
-	} else {
-		return nil, errPathUnknownEscape
 	}
+	return r, i, nil
 }
 
 func toInt(buf []byte) int {
@@ -206,3 +168,26 @@ func readTerminatorBounded(path []byte, i int, terminators []byte) ([]byte, int,
 
 	return path[s:i], i, nil
 }
+
+// jsonQuote appends str to buf as a quoted json string
+func jsonQuote(buf []byte, str []byte) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for _, ch := range str {
+		switch {
+		case ch == '"' || ch == '\\':
+			buf = append(buf, '\\', ch)
+		case ch == '\n':
+			buf = append(buf, '\\', 'n')
+		case ch == '\r':
+			buf = append(buf, '\\', 'r')
+		case ch == '\t':
+			buf = append(buf, '\\', 't')
+		case ch < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[ch>>4], hex[ch&0xF])
+		default:
+			buf = append(buf, ch)
+		}
+	}
+	return append(buf, '"')
+}
@@ -0,0 +1,178 @@
+package jsonslice
+
+import (
+	"sync"
+)
+
+// Document is an input with a structural index: bounds of object members and array elements
+// are recorded the first time a container is visited and reused by every following query.
+// Key and index steps of a path ($.a.b[3]) are resolved from the index; the rest of the path
+// (wildcards, slices, filters, functions) is evaluated on the value reached by these steps.
+//
+//	doc := jsonslice.ParseStructure(input)
+//	author, _ := doc.Get("$.store.book[0].author")
+//	price, _ := doc.Get("$.store.bicycle.price")
+//
+// A Document is safe for concurrent use. The input must not be modified while the Document is in use.
+type Document struct {
+	input      []byte
+	mu         sync.Mutex
+	containers map[int]*tContainer // container start offset -> its members or elements
+}
+
+// tContainer holds the bounds of object members (by raw key) or array elements
+type tContainer struct {
+	members map[string]tElem
+	elems   []tElem
+}
+
+// ParseStructure returns a Document for input. Containers are indexed lazily, so
+// malformed input is reported by the query which reaches it.
+func ParseStructure(input []byte) *Document {
+	return &Document{input: input, containers: make(map[int]*tContainer)}
+}
+
+// Get returns a part of the document matching jsonpath. The result is the same as of jsonslice.Get.
+func (d *Document) Get(path string) ([]byte, error) {
+	if len(path) == 1 && path[0] == '$' {
+		return d.input, nil
+	}
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := d.evaluate(node)
+	repool(node)
+	return result, err
+}
+
+// GetPath returns a part of the document matching compiled path
+func (d *Document) GetPath(p *Path) ([]byte, error) {
+	if len(p.path) == 1 && p.path[0] == '$' {
+		return p.reformat(d.input, nil)
+	}
+	node := p.take()
+	result, err := d.evaluate(node)
+	p.release(node)
+	return p.reformat(result, err)
+}
+
+// evaluate resolves leading key and index steps via the index and evaluates the rest of the node list
+func (d *Document) evaluate(node *tNode) ([]byte, error) {
+	if err := checkFunctions(node, nil); err != nil {
+		return nil, err
+	}
+	evalRootRefs(d.input, node)
+
+	start, err := skipSpaces(d.input, 0)
+	if err != nil {
+		return nil, inputError(d.input, err)
+	}
+	end := len(d.input)
+	walked := hasNode(node, cParent|cName) // containers and member names are only known to walk
+	for ; node != nil && !walked && indexable(node); node = node.Next {
+		c, err := d.container(start)
+		if err != nil {
+			return nil, inputError(d.input, err)
+		}
+		var (
+			elem tElem
+			ok   bool
+		)
+		switch {
+		case c == nil: // scalar
+		case c.members != nil:
+			elem, ok = c.members[string(node.Keys[0])]
+		case node.Slice[0] != cNAN:
+			k := node.Slice[0]
+			if k < 0 {
+				k += len(c.elems)
+			}
+			if k >= 0 && k < len(c.elems) {
+				elem, ok = c.elems[k], true
+			}
+		}
+		if !ok {
+			return nil, nil
+		}
+		start, end = elem.start, elem.end
+	}
+	if node == nil {
+		if end == len(d.input) { // root
+			return d.input, nil
+		}
+		return d.input[start:end:end], nil
+	}
+	result, err := getResult(d.input[start:end:end], node)
+	if err != nil {
+		return nil, inputError(d.input, err)
+	}
+	return result, nil
+}
+
+// indexable returns true if nod is a single key or index step which can be resolved via the index
+func indexable(nod *tNode) bool {
+	return nod.Type&^(cDot|cFullScan) == 0 && len(nod.Keys) == 1 && len(nod.Calls) == 0
+}
+
+// container returns the index of an object or array starting at input[i], building it on first use.
+// Returns nil for scalars.
+func (d *Document) container(i int) (*tContainer, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if c, ok := d.containers[i]; ok {
+		return c, nil
+	}
+	var (
+		c   *tContainer
+		err error
+	)
+	switch d.input[i] {
+	case '{':
+		c, err = indexObject(d.input, i)
+	case '[':
+		var elems []tElem
+		elems, err = arrayElems(d.input, i)
+		c = &tContainer{elems: elems}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.containers[i] = c
+	return c, nil
+}
+
+// indexObject records the bounds of the members of an object starting at input[i].
+// The first of duplicate keys wins.
+func indexObject(input []byte, i int) (*tContainer, error) {
+	var (
+		key  []byte
+		s, e int
+		err  error
+	)
+	c := &tContainer{members: make(map[string]tElem)}
+	l := len(input)
+	i++ // skip '{'
+	for i < l && input[i] != '}' {
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := c.members[string(key)]; !ok {
+			c.members[string(key)] = tElem{s, e}
+		}
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	return c, nil
+}
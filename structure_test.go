@@ -0,0 +1,85 @@
+package jsonslice
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_Document(t *testing.T) {
+
+	tests := []string{
+		`$`,
+		`$.store`,
+		`$.store.book[0].author`,
+		`$.store.book[-1].title`,
+		`$['store']['bicycle']['color']`,
+		`$.store.book[5].title`,
+		`$.store.nothing.title`,
+		`$.store.book[*].price`,
+		`$.store.book[?(@.price > 10)].title`,
+		`$.store.book[?(@.price > $.expensive)].title`,
+		`$.store.book.length()`,
+		`$.store.book[1:3].author`,
+		`$.store..price`,
+		`$.store.book[0].title.length()`,
+	}
+
+	doc := ParseStructure(data)
+	for pass := 0; pass < 2; pass++ { // the second pass is answered from the index
+		for _, path := range tests {
+			expected, err := Get(data, path)
+			if err != nil {
+				t.Errorf(path + " : " + err.Error())
+				continue
+			}
+			res, err := doc.Get(path)
+			if err != nil {
+				t.Errorf(path + " : " + err.Error())
+				continue
+			}
+			if compareSlices(res, expected) != 0 {
+				t.Errorf(path + "\n\texpected `" + string(expected) + "`\n\tbut got  `" + string(res) + "`")
+			}
+		}
+	}
+
+	p := MustCompile(`$.store.bicycle.price`)
+	res, err := doc.GetPath(p)
+	if err != nil || string(res) != `19.95` {
+		t.Errorf("GetPath: expected `19.95` but got `%s` (%v)", res, err)
+	}
+
+	doc = ParseStructure([]byte(`{"a": [1, 2`))
+	if _, err := doc.Get(`$.a[0]`); err == nil {
+		t.Errorf("error expected for malformed input")
+	}
+}
+
+func Test_DocumentConcurrent(t *testing.T) {
+	doc := ParseStructure(data)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				res, err := doc.Get(`$.store.book[2].isbn`)
+				if err != nil || string(res) != `"0-553-21311-3"` {
+					t.Errorf("expected `\"0-553-21311-3\"` but got `%s` (%v)", res, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Benchmark_Jsonslice_Document_10Mb_Last(b *testing.B) {
+	b.StopTimer()
+	doc := ParseStructure(GenerateLargeData())
+	_, _ = doc.Get("$.store.book[100000].title")
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = doc.Get("$.store.book[100000].title")
+	}
+}
@@ -0,0 +1,106 @@
+package jsonslice
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Match is a value matched by the path: in a record of an NDJSON stream (Subscribe) or in a document (GetWithPaths)
+type Match struct {
+	Line   int    // line number of the record (1-based), Subscribe only
+	Record []byte // the record itself, Subscribe only
+	Value  []byte // the value matched by the path
+	Path   string // normalized path of the value: $['store']['book'][2]['title'], GetWithPaths only
+}
+
+// Subscribe reads newline-delimited json records from r until EOF and calls handler for every record
+// in which path matches a value. Empty lines and records failing to evaluate (i.e. malformed json) are skipped.
+// Returns a path parsing error or a read error.
+func Subscribe(r io.Reader, path string, handler func(Match)) error {
+	return SubscribeWhere(r, "", path, handler)
+}
+
+// SubscribeWhere is the same as Subscribe but handler is only called for the records satisfying filter.
+// filter is an expression as in ?(...) where @ (and $) refers to the record: `@.level == "error" && @.code >= 500`.
+// Empty filter matches every record.
+func SubscribeWhere(r io.Reader, filter, path string, handler func(Match)) error {
+	node, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	defer repool(node)
+
+	pred := getEmptyNode()
+	defer repool(pred)
+	if len(filter) > 0 {
+		if err = parseFilter(unspace([]byte(filter)), pred); err != nil {
+			return err
+		}
+	}
+
+	rd := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		rec, err := rd.ReadBytes('\n')
+		if len(bytes.TrimSpace(rec)) > 0 {
+			if m, ok := matchRecord(rec, pred, node); ok {
+				m.Line = line
+				handler(m)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// GetLines reads newline-delimited json (JSON Lines) from r until EOF and calls fn with the result of path
+// for every non-empty line (nil if nothing matches). line is 1-based.
+// Stops on the first error: a path parsing error, an evaluation error (i.e. malformed json) along with
+// the line number, a read error or an error returned by fn.
+func GetLines(r io.Reader, path string, fn func(line int, result []byte) error) error {
+	p, err := Compile(path)
+	if err != nil {
+		return err
+	}
+	rd := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		rec, err := rd.ReadBytes('\n')
+		if len(bytes.TrimSpace(rec)) > 0 {
+			val, err := p.Get(rec)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			if err = fn(line, val); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// matchRecord applies predicate and parsed path to a single record
+func matchRecord(rec []byte, pred *tNode, node *tNode) (Match, bool) {
+	if pred.Filter != nil {
+		evalRootRefs(rec, pred)
+		ok, err := filterMatch(rec, pred, -1)
+		if !ok || err != nil {
+			return Match{}, false
+		}
+	}
+	ctx := &tContext{}
+	val, err := evaluate(rec, node, ctx)
+	if err != nil || len(val) == 0 || (ctx.aggregating && bytes.Equal(val, []byte("[]"))) {
+		return Match{}, false
+	}
+	return Match{Record: rec, Value: val}, true
+}
@@ -0,0 +1,107 @@
+package jsonslice
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Subscribe(t *testing.T) {
+
+	stream := `{"level": "info", "msg": "started", "tags": []}
+{"level": "error", "msg": "failed", "code": 502, "tags": ["db"]}
+
+not a json
+{"level": "error", "msg": "retry", "code": 404}
+{"level": "warn", "msg": "slow", "tags": ["db", "api"]}`
+
+	tests := []struct {
+		Filter   string
+		Path     string
+		Expected string // line:value pairs
+	}{
+		{``, `$.msg`, `1:"started" 2:"failed" 5:"retry" 6:"slow" `},
+		{``, `$.code`, `2:502 5:404 `},
+		{``, `$.tags[*]`, `2:["db"] 6:["db","api"] `},
+		{`@.level == "error"`, `$.msg`, `2:"failed" 5:"retry" `},
+		{`@.level == 'error' && @.code >= 500`, `$`, `2:{"level": "error", "msg": "failed", "code": 502, "tags": ["db"]} `},
+		{`@.code > $.tags.length()`, `$.code`, `2:502 `},
+	}
+
+	for _, tst := range tests {
+		var res strings.Builder
+		err := SubscribeWhere(strings.NewReader(stream), tst.Filter, tst.Path, func(m Match) {
+			res.WriteString(strconv.Itoa(m.Line) + ":" + strings.TrimSpace(string(m.Value)) + " ")
+		})
+		if err != nil {
+			t.Errorf(tst.Path + " : " + err.Error())
+		} else if res.String() != tst.Expected {
+			t.Errorf(tst.Filter + " " + tst.Path + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + res.String() + "`")
+		}
+	}
+
+	// root references are evaluated against every record, not the first one
+	limits := `{"limit": 1, "code": 5, "items": [5]}
+{"code": 7, "items": [7]}`
+	var lines []int
+	err := SubscribeWhere(strings.NewReader(limits), `@.code > $.limit`, `$.items[?(@ > $.limit)]`, func(m Match) {
+		lines = append(lines, m.Line)
+	})
+	if err != nil || len(lines) != 1 || lines[0] != 1 {
+		t.Errorf("expected a match on line 1 only, got %v %v", lines, err)
+	}
+
+	if err := Subscribe(strings.NewReader(stream), `$.`, func(Match) {}); err == nil {
+		t.Errorf("path error expected")
+	}
+	if err := SubscribeWhere(strings.NewReader(stream), `@.a[`, `$`, func(Match) {}); err == nil {
+		t.Errorf("filter error expected")
+	}
+}
+
+func Test_GetLines(t *testing.T) {
+
+	stream := `{"level": "info", "msg": "started"}
+{"level": "error", "msg": "failed", "code": 502}
+
+{"level": "warn", "tags": ["db", "api"]}
+`
+
+	tests := []struct {
+		Path     string
+		Expected string // line:value pairs
+	}{
+		{`$.msg`, `1:"started" 2:"failed" 4: `},
+		{`$.tags[*]`, `1: 2: 4:["db","api"] `},
+		{`$.level`, `1:"info" 2:"error" 4:"warn" `},
+	}
+
+	for _, tst := range tests {
+		var res strings.Builder
+		err := GetLines(strings.NewReader(stream), tst.Path, func(line int, result []byte) error {
+			res.WriteString(strconv.Itoa(line) + ":" + string(result) + " ")
+			return nil
+		})
+		if err != nil {
+			t.Errorf(tst.Path + " : " + err.Error())
+		} else if res.String() != tst.Expected {
+			t.Errorf(tst.Path + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + res.String() + "`")
+		}
+	}
+
+	// stop on handler error
+	n := 0
+	stop := errors.New("stop")
+	if err := GetLines(strings.NewReader(stream), `$.msg`, func(int, []byte) error { n++; return stop }); err != stop || n != 1 {
+		t.Errorf("expected a single call and the handler error, got %d, %v", n, err)
+	}
+	// malformed line
+	err := GetLines(strings.NewReader("{\"a\": 1}\n{\"a\": [1, 2\n"), `$.a[1]`, func(int, []byte) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected an error at line 2, got %v", err)
+	}
+	if err := GetLines(strings.NewReader(stream), `$.`, func(int, []byte) error { return nil }); err == nil {
+		t.Errorf("path error expected")
+	}
+}
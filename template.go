@@ -0,0 +1,63 @@
+package jsonslice
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Expand replaces ${path} placeholders of a template with the values the paths select in input:
+//
+//	{"id": ${$.order.id}, "skus": ${$.items[*].sku}, "text": "Order ${$.order.id} for ${$.user.name}"}
+//
+// A value is inserted as json text. Inside a string of the template a string value is inserted as its contents
+// and other values as json text, both escaped. A path selecting nothing inserts null (nothing inside a string).
+// A path error (*PathError) points to the offending character of the template.
+func Expand(template []byte, input []byte) ([]byte, error) {
+	res := make([]byte, 0, len(template))
+	str := false // inside a string of the template
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '\\' && str && i+1 < len(template):
+			res = append(res, c, template[i+1])
+			i++
+			continue
+		case c == '"':
+			str = !str
+		case c == '$' && i+1 < len(template) && template[i+1] == '{':
+			e, err := closingBracket(template, i+1, len(template), '{', '}')
+			if err != nil {
+				return nil, errTemplateUnclosed
+			}
+			val, err := Get(input, string(template[i+2:e-1]))
+			if err != nil {
+				var pe *PathError
+				if errors.As(err, &pe) {
+					pe.Pos += i + 2
+				}
+				return nil, err
+			}
+			res = expandValue(res, bytes.TrimSpace(val), str)
+			i = e - 1
+			continue
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// expandValue appends a value to the expanded template: as json text or inside a string
+func expandValue(res, val []byte, str bool) []byte {
+	switch {
+	case !str && len(val) == 0:
+		return append(res, "null"...)
+	case !str:
+		return append(res, val...)
+	case len(val) == 0:
+		return res
+	case val[0] == '"':
+		return append(res, val[1:len(val)-1]...)
+	}
+	quoted := jsonQuote(nil, val)
+	return append(res, quoted[1:len(quoted)-1]...)
+}
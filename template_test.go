@@ -0,0 +1,42 @@
+package jsonslice
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_Expand(t *testing.T) {
+
+	input := []byte(`{"order": {"id": 42, "note": "a \"b\""}, "user": {"name": "John"}, "items": [{"sku": "x1"}, {"sku": "y2"}]}`)
+
+	tests := []struct {
+		Template string
+		Expected string
+	}{
+		{`{"id": ${$.order.id}, "skus": ${$.items[*].sku}, "text": "Order ${$.order.id} for ${$.user.name}"}`,
+			`{"id": 42, "skus": ["x1","y2"], "text": "Order 42 for John"}`},
+		{`{"note": "${$.order.note}", "raw": ${$.order.note}, "skus": "${$.items[*].sku}"}`,
+			`{"note": "a \"b\"", "raw": "a \"b\"", "skus": "[\"x1\",\"y2\"]"}`},
+		{`{"m": ${$.missing}, "s": "<${$.missing}>"}`, `{"m": null, "s": "<>"}`},
+		{`{"x": "\"${$.user.name}\""}`, `{"x": "\"John\""}`},
+		{`{"p": ${$.items[*]{s: @.sku}}, "n": ${$.items.length() * 2}}`, `{"p": [{"s":"x1"},{"s":"y2"}], "n": 4}`},
+		{`no placeholders $ {} $`, `no placeholders $ {} $`},
+	}
+
+	for _, tst := range tests {
+		res, err := Expand([]byte(tst.Template), input)
+		if err != nil {
+			t.Errorf(tst.Template + " : " + err.Error())
+		} else if string(res) != tst.Expected {
+			t.Errorf(tst.Template + "\n\texpected `" + tst.Expected + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	if _, err := Expand([]byte(`{"a": ${$.user.name`), input); err == nil {
+		t.Errorf("unclosed placeholder: error expected")
+	}
+	var pe *PathError
+	if _, err := Expand([]byte(`{"a": ${$.user.[}}`), input); !errors.As(err, &pe) || pe.Pos != 16 {
+		t.Errorf("path error at 16 expected, got %v", err)
+	}
+}
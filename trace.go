@@ -0,0 +1,81 @@
+package jsonslice
+
+// Trace describes how a path has been evaluated against the input
+type Trace struct {
+	Path    string      // the path
+	Steps   []TraceStep // one step per path node
+	Matches int         // number of values matched by the whole path
+}
+
+// TraceStep holds evaluation counters of a single path node
+type TraceStep struct {
+	Node     string   // node as written in the path (spaces removed), e.g. `.book` or `[?(@.price>10)]`
+	Examined int      // object keys or array elements examined
+	Matched  int      // keys or elements selected by the node
+	Skipped  int      // keys or elements rejected by the node
+	Offsets  [][2]int // [start,end) bounds of the selected values in the input
+}
+
+// tTracer collects node counters during walk
+type tTracer struct {
+	steps map[*tNode]*TraceStep
+}
+
+func (t *tTracer) examine(nod *tNode, n int) {
+	if t == nil {
+		return
+	}
+	t.steps[nod].Examined += n
+}
+
+func (t *tTracer) matched(nod *tNode, s, e int) {
+	if t == nil {
+		return
+	}
+	step := t.steps[nod]
+	step.Matched++
+	step.Offsets = append(step.Offsets, [2]int{s, e})
+}
+
+// Explain evaluates path against input and returns the trace of evaluation:
+// per node counters of examined, selected and rejected keys or elements
+// along with byte offsets of the selected values.
+// Useful for finding out why a filter excluded an element.
+// A function ($.n.length()) or a name selector (~) is recorded with the offsets of the value
+// it is applied to; the nodes following it are not evaluated.
+func Explain(input []byte, path string) (*Trace, error) {
+	node, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer repool(node)
+	evalRootRefs(input, node)
+
+	trace := &Trace{Path: path}
+	tracer := &tTracer{steps: make(map[*tNode]*TraceStep)}
+	for n := node; n != nil; n = n.Next {
+		trace.Steps = append(trace.Steps, TraceStep{Node: string(n.Src)})
+	}
+	i := 0
+	for n := node; n != nil; n = n.Next {
+		tracer.steps[n] = &trace.Steps[i]
+		for _, part := range n.Union {
+			tracer.steps[part] = &trace.Steps[i]
+		}
+		i++
+	}
+	w := &tWalker{
+		match: func(m *tMatch) (bool, error) {
+			trace.Matches++
+			return true, nil
+		},
+		trace: tracer,
+	}
+	if _, err = walkInput(input, node, w); err != nil {
+		return trace, err
+	}
+	for i := range trace.Steps {
+		trace.Steps[i].Skipped = trace.Steps[i].Examined - trace.Steps[i].Matched
+	}
+	return trace, nil
+}
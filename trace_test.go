@@ -0,0 +1,56 @@
+package jsonslice
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_Explain(t *testing.T) {
+	input := []byte(`{"book": [{"price": 8}, {"price": 22}, {"title": "x"}]}`)
+
+	trace, err := Explain(input, `$.book[?(@.price > 10)].price`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TraceStep{
+		{Node: ".book", Examined: 1, Matched: 1, Skipped: 0, Offsets: [][2]int{{9, 54}}},
+		{Node: "[?(@.price>10)]", Examined: 3, Matched: 1, Skipped: 2, Offsets: [][2]int{{24, 37}}},
+		{Node: ".price", Examined: 1, Matched: 1, Skipped: 0, Offsets: [][2]int{{34, 36}}},
+	}
+	if trace.Matches != 1 {
+		t.Errorf("expected 1 match, got %d", trace.Matches)
+	}
+	if fmt.Sprint(trace.Steps) != fmt.Sprint(expected) {
+		t.Errorf("\n\texpected %v\n\tbut got  %v", expected, trace.Steps)
+	}
+	if string(input[34:36]) != "22" {
+		t.Errorf("wrong offsets")
+	}
+
+	// deepscan counts every key and element on every level
+	trace, err = Explain(input, `$..price`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Matches != 2 || trace.Steps[0].Examined != 7 || trace.Steps[0].Skipped != 5 {
+		t.Errorf("deepscan: unexpected trace %+v", trace)
+	}
+
+	// function: the value it is applied to is recorded
+	trace, err = Explain(input, `$.book.length()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []TraceStep{
+		{Node: ".book", Examined: 1, Matched: 1, Skipped: 0, Offsets: [][2]int{{9, 54}}},
+		{Node: ".length()", Examined: 1, Matched: 1, Skipped: 0, Offsets: [][2]int{{9, 54}}},
+	}
+	if trace.Matches != 1 || fmt.Sprint(trace.Steps) != fmt.Sprint(expected) {
+		t.Errorf("\n\texpected %v\n\tbut got  %v (%d matches)", expected, trace.Steps, trace.Matches)
+	}
+
+	// invalid path
+	if _, err = Explain(input, `$.`); err == nil {
+		t.Errorf("error expected")
+	}
+}
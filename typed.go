@@ -0,0 +1,89 @@
+package jsonslice
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/bhmj/xpression"
+)
+
+// getOperand returns the value matched by path decoded into an operand of the expected type.
+// Returns errFieldNotFound if nothing matches, errTypeMismatch if the value is of another type.
+func getOperand(input []byte, path string, typ xpression.OperandType) ([]byte, *xpression.Operand, error) {
+	val, err := Get(input, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(val) == 0 {
+		return nil, nil, errFieldNotFound
+	}
+	op := &xpression.Operand{}
+	i, _ := skipSpaces(val, 0)
+	if err = decodeValue(val, op); err != nil {
+		return nil, nil, err
+	}
+	if op.Type != typ || (typ == xpression.StringOperand && val[i] != '"') {
+		return nil, nil, errTypeMismatch
+	}
+	return val[i:], op, nil
+}
+
+// GetString returns the string matched by path with the escape sequences decoded.
+// Returns an error if nothing matches or the value is not a string.
+func GetString(input []byte, path string) (string, error) {
+	_, op, err := getOperand(input, path, xpression.StringOperand)
+	if err != nil {
+		return "", err
+	}
+	return string(unescape(op.Str)), nil
+}
+
+// GetInt returns the integer number matched by path.
+// Returns an error if nothing matches or the value is not an integer.
+func GetInt(input []byte, path string) (int, error) {
+	val, op, err := getOperand(input, path, xpression.NumberOperand)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := strconv.ParseInt(string(val[:skipNumber(val, 0)]), 10, strconv.IntSize); err == nil {
+		return int(n), nil
+	}
+	if op.Number != math.Trunc(op.Number) || math.Abs(op.Number) > 1<<53 {
+		return 0, errTypeMismatch
+	}
+	return int(op.Number), nil
+}
+
+// GetFloat returns the number matched by path.
+// Returns an error if nothing matches or the value is not a number.
+func GetFloat(input []byte, path string) (float64, error) {
+	_, op, err := getOperand(input, path, xpression.NumberOperand)
+	if err != nil {
+		return 0, err
+	}
+	return op.Number, nil
+}
+
+// GetBool returns the boolean matched by path.
+// Returns an error if nothing matches or the value is not a boolean.
+func GetBool(input []byte, path string) (bool, error) {
+	_, op, err := getOperand(input, path, xpression.BooleanOperand)
+	if err != nil {
+		return false, err
+	}
+	return op.Bool, nil
+}
+
+// GetInto decodes the value matched by path into v using encoding/json.
+// Returns errFieldNotFound if nothing matches.
+func GetInto(input []byte, path string, v interface{}) error {
+	val, err := Get(input, path)
+	if err != nil {
+		return err
+	}
+	if len(val) == 0 {
+		return errFieldNotFound
+	}
+	return json.Unmarshal(val, v)
+}
@@ -0,0 +1,83 @@
+package jsonslice
+
+import "testing"
+
+func Test_TypedAccessors(t *testing.T) {
+
+	input := []byte(`{"name": "Jo\"hn А", "age": 42, "big": 9007199254740993, "exp": 1e3, "price": 8.95, "ok": true, "none": null, "tags": ["a", "b"]}`)
+
+	if s, err := GetString(input, `$.name`); err != nil || s != `Jo"hn А` {
+		t.Errorf("GetString: unexpected %q (%v)", s, err)
+	}
+	if s, err := GetString(input, `$.tags[1]`); err != nil || s != `b` {
+		t.Errorf("GetString: unexpected %q (%v)", s, err)
+	}
+	if n, err := GetInt(input, `$.age`); err != nil || n != 42 {
+		t.Errorf("GetInt: unexpected %d (%v)", n, err)
+	}
+	if n, err := GetInt(input, `$.big`); err != nil || n != 9007199254740993 {
+		t.Errorf("GetInt: unexpected %d (%v)", n, err)
+	}
+	if n, err := GetInt(input, `$.exp`); err != nil || n != 1000 {
+		t.Errorf("GetInt: unexpected %d (%v)", n, err)
+	}
+	if n, err := GetInt(input, `$.tags.length()`); err != nil || n != 2 {
+		t.Errorf("GetInt: unexpected %d (%v)", n, err)
+	}
+	if f, err := GetFloat(input, `$.price`); err != nil || f != 8.95 {
+		t.Errorf("GetFloat: unexpected %v (%v)", f, err)
+	}
+	if b, err := GetBool(input, `$.ok`); err != nil || !b {
+		t.Errorf("GetBool: unexpected %v (%v)", b, err)
+	}
+
+	errors := []struct {
+		Name string
+		Fn   func() error
+		Err  error
+	}{
+		{"GetString(age)", func() error { _, err := GetString(input, `$.age`); return err }, errTypeMismatch},
+		{"GetString(tags)", func() error { _, err := GetString(input, `$.tags`); return err }, errTypeMismatch},
+		{"GetString(nope)", func() error { _, err := GetString(input, `$.nope`); return err }, errFieldNotFound},
+		{"GetInt(price)", func() error { _, err := GetInt(input, `$.price`); return err }, errTypeMismatch},
+		{"GetInt(name)", func() error { _, err := GetInt(input, `$.name`); return err }, errTypeMismatch},
+		{"GetFloat(none)", func() error { _, err := GetFloat(input, `$.none`); return err }, errTypeMismatch},
+		{"GetBool(none)", func() error { _, err := GetBool(input, `$.none`); return err }, errTypeMismatch},
+	}
+	for _, tst := range errors {
+		if err := tst.Fn(); err != tst.Err {
+			t.Errorf("%s: expected %v, got %v", tst.Name, tst.Err, err)
+		}
+	}
+}
+
+func Test_GetInto(t *testing.T) {
+
+	input := []byte(`{"store": {"book": [{"title": "Moby Dick", "price": 8.99, "tags": ["sea"]}, {"title": "Sword", "price": 12.99}]}}`)
+
+	type book struct {
+		Title string   `json:"title"`
+		Price float64  `json:"price"`
+		Tags  []string `json:"tags"`
+	}
+
+	var b book
+	if err := GetInto(input, `$.store.book[0]`, &b); err != nil || b.Title != "Moby Dick" || b.Price != 8.99 || len(b.Tags) != 1 {
+		t.Errorf("unexpected %+v (%v)", b, err)
+	}
+	var books []book
+	if err := GetInto(input, `$.store.book[?(@.price > 10)]`, &books); err != nil || len(books) != 1 || books[0].Title != "Sword" {
+		t.Errorf("unexpected %+v (%v)", books, err)
+	}
+	var titles []string
+	if err := GetInto(input, `$..title`, &titles); err != nil || len(titles) != 2 || titles[1] != "Sword" {
+		t.Errorf("unexpected %v (%v)", titles, err)
+	}
+	if err := GetInto(input, `$.store.bicycle`, &b); err != errFieldNotFound {
+		t.Errorf("expected errFieldNotFound, got %v", err)
+	}
+	var n int
+	if err := GetInto(input, `$.store.book[0].title`, &n); err == nil {
+		t.Errorf("expected unmarshal error")
+	}
+}
@@ -0,0 +1,115 @@
+package jsonslice
+
+// A union is a bracket of selectors of different kinds: $[0,'a',2:4,?(@.x)], $.a[*,0].
+// Every selector is parsed into a separate node (tNode.Union) followed by the rest of the path,
+// the values selected by every selector are concatenated in the order of the selectors.
+// Plain lists of keys or indexes ($['a','b'], $[1,2]) are not unions: they select values
+// in the order of the document.
+
+// isUnion returns true if the bracket starting at path[i] (after '[') is a union
+func isUnion(path []byte, i int) bool {
+	items, union := 0, false
+	for i < len(path) {
+		e, err := unionItemEnd(path, i)
+		if err != nil {
+			return false // reported by readBrackets
+		}
+		items++
+		union = union || unionSelector(path[i:e])
+		if path[e] == ']' {
+			break
+		}
+		i = e + 1
+	}
+	return items > 1 && union
+}
+
+// unionSelector returns true if the selector can not be a part of a plain list: filter, script, wildcard or slice
+func unionSelector(sel []byte) bool {
+	if len(sel) == 0 {
+		return false
+	}
+	if sel[0] == '?' || sel[0] == '(' || len(sel) == 1 && sel[0] == '*' {
+		return true
+	}
+	for i := 0; i < len(sel); i++ {
+		switch sel[i] {
+		case '\'', '"':
+			return false // a quoted key
+		case ':':
+			return true
+		}
+	}
+	return false
+}
+
+// unionItemEnd returns the position of ',' or ']' ending the selector starting at path[i]
+func unionItemEnd(path []byte, i int) (int, error) {
+	var err error
+	for i < len(path) {
+		switch path[i] {
+		case '\'', '"':
+			if i, err = skipString(path, i); err != nil {
+				return i, errPathUnexpectedEnd
+			}
+			continue
+		case '(':
+			if i, err = findClosingBracket(path, i+1); err != nil {
+				return i, errPathUnexpectedEnd
+			}
+		case '[':
+			if i, err = closingBracket(path, i, len(path), '[', ']'); err != nil {
+				return i, errPathUnexpectedEnd
+			}
+			continue
+		case ',', ']':
+			return i, nil
+		}
+		i++
+	}
+	return i, errPathUnexpectedEnd
+}
+
+// readUnion reads the selectors of a union starting at path[i] (after '[').
+// Consumes final ']'
+func readUnion(nod *tNode, path []byte, i int) (int, error) {
+	nod.Type = nod.Type&cDeep | cUnion
+	for {
+		e, err := unionItemEnd(path, i)
+		if err != nil {
+			return e, err
+		}
+		if e == i {
+			return i, errPathInvalidChar // empty selector
+		}
+		part := getEmptyNode()
+		part.Type = nod.Type & cDeep
+		nod.Union = append(nod.Union, part)
+		// the selector is read as a bracket of its own: path up to the selector end + ']'
+		j, err := readBrackets(part, append(path[:e:e], ']'), i)
+		part.Src = path[i:e]
+		if err != nil {
+			return j, err
+		}
+		if j != e+1 {
+			return j, errPathInvalidChar
+		}
+		i = e + 1
+		if path[e] == ']' {
+			return i, nil
+		}
+	}
+}
+
+// getValueUnion returns the values selected by every selector of a union in turn
+func getValueUnion(input []byte, nod *tNode) ([]byte, error) {
+	var res []byte
+	for _, part := range nod.Union {
+		sub, err := getValue(input, part, true)
+		if err != nil {
+			return nil, err
+		}
+		res = plus(res, sub)
+	}
+	return res, nil
+}
@@ -0,0 +1,55 @@
+package jsonslice
+
+import (
+	"testing"
+)
+
+func Test_Union(t *testing.T) {
+
+	arr := []byte(`[{"x":1,"key":"k"},{"y":2},{"x":3},{"x":4},5]`)
+	obj := []byte(`{"key":"v","a":1,"b":{"x":1}}`)
+	tests := []struct {
+		Data     []byte
+		Query    string
+		Expected []byte
+	}{
+		{arr, `$[0, 'key', 2:4, ?(@.x)]`, []byte(`[{"x":1,"key":"k"},{"x":3},{"x":4},{"x":1,"key":"k"},{"x":3},{"x":4}]`)},
+		{arr, `$[4, 0:2]`, []byte(`[5,{"x":1,"key":"k"},{"y":2}]`)}, // in the order of the selectors
+		{arr, `$[?(@.x > 2), 0].x`, []byte(`[3,4,1]`)},
+		{arr, `$[*, -1]`, []byte(`[{"x":1,"key":"k"},{"y":2},{"x":3},{"x":4},5,5]`)},
+		{arr, `$[0, (@.length-1)]`, []byte(`[{"x":1,"key":"k"},5]`)},
+		{arr, `$[::2, 1].x`, []byte(`[1,3]`)},
+		{obj, `$['key', *]`, []byte(`["v","v",1,{"x":1}]`)},
+		{obj, `$[?(@.x), 'a']`, []byte(`[{"x":1},1]`)},
+		{obj, `$['a', 0:1]`, []byte(`[1]`)},
+		{obj, `$..[?(@ == 1), 'key']`, []byte(`[1,1,"v"]`)}, // deepscan of every selector in turn
+		// plain lists keep the document order
+		{obj, `$['a', 'key']`, []byte(`["v",1]`)},
+	}
+
+	for _, tst := range tests {
+		res, err := Get(tst.Data, tst.Query)
+		if err != nil {
+			t.Errorf(tst.Query + " : " + err.Error())
+		} else if compareSlices(res, tst.Expected) != 0 {
+			t.Errorf(tst.Query + "\n\texpected `" + string(tst.Expected) + "`\n\tbut got  `" + string(res) + "`")
+		}
+	}
+
+	// walk-based evaluation
+	res, _ := Delete(arr, `$[4, 0:2]`)
+	if string(res) != `[{"x":3},{"x":4}]` {
+		t.Errorf("Delete $[4, 0:2]\n\tbut got  `%s`", res)
+	}
+	var offsets [][2]int
+	_, _ = GetWith(arr, `$[3, ?(@.y)]`, WithOffsets(&offsets))
+	if len(offsets) != 2 || offsets[0] != [2]int{35, 42} || offsets[1] != [2]int{19, 26} {
+		t.Errorf("$[3, ?(@.y)] offsets\n\tbut got  %v", offsets)
+	}
+
+	for _, path := range []string{`$[0, 1:2`, `$[0, ?(@.x), ]`, `$[1:2, 'a'b]`} {
+		if _, err := Get(arr, path); err == nil {
+			t.Errorf(path + " : error expected")
+		}
+	}
+}
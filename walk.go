@@ -0,0 +1,403 @@
+package jsonslice
+
+import (
+	"sync"
+)
+
+// tMatch describes a single value matched by the path during walk
+type tMatch struct {
+	start int    // value start
+	end   int    // value end (excluded)
+	key   []byte // member key of the value (unescaped), nil for an array element
+	index int    // array index of the value, -1 for an object member
+}
+
+// tWalker holds walk callbacks.
+//
+//	match   -- called for every value matched by the path; returning false stops the walk;
+//	           m is reused for the next match and must not be retained
+//	missing -- (optional) called when a singular key (or the next array index) is absent;
+//	           at is the insertion point, comma tells whether a separator is needed,
+//	           array is true for an array container, nod is the first absent node
+type tWalker struct {
+	match         func(m *tMatch) (bool, error)
+	missing       func(at int, comma, array bool, nod *tNode) error
+	trace         *tTracer      // (optional) per node counters
+	maxDepth      int           // (optional) deepscan depth limit, see WithMaxDepth
+	depth         int           // current deepscan depth
+	keyMatch      KeyMatch      // object key comparison, see WithKeyMatch
+	duplicates    DuplicateKeys // members selected by a single key, see WithDuplicateKeys
+	objectIndexes bool          // object members selected by position, see WithObjectIndexes
+	key           []byte        // member key of the current value
+	index         int           // array index of the current value
+	m             tMatch        // the current match
+	matches       int           // number of matches reported
+	parents       []tParent     // containers of the current value (if the path has parent selectors)
+	seen          map[int]bool  // starts of the values matched so far (if the path has parent selectors)
+	ctx           *tContext     // (optional) the walk is stopped when the context is done, see WithContext
+}
+
+// options applies the evaluation options relevant to walk
+func (w *tWalker) options(ctx *tContext) {
+	if ctx != nil {
+		w.maxDepth, w.keyMatch, w.objectIndexes = ctx.maxDepth, ctx.keyMatch, ctx.objIndexes
+		w.duplicates = ctx.duplicates
+		w.ctx = ctx
+	}
+}
+
+// descend reports whether deepscan may go one level deeper
+func (w *tWalker) descend() bool {
+	return w.maxDepth <= 0 || w.depth+1 < w.maxDepth
+}
+
+// walk visits every value in input (starting at i) matched by the node list.
+// Unlike getValue it does not collect results but reports absolute value bounds,
+// which makes it suitable for in-place modifications of the input.
+// Returns false if the walk has been stopped by the callback.
+func walk(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	var err error
+	i, err = skipSpaces(input, i)
+	if err != nil {
+		return false, err
+	}
+	if nod == nil {
+		e, err := skipValue(input, i)
+		if err != nil {
+			return false, err
+		}
+		if w.seen != nil {
+			if w.seen[i] { // the parent of several matches
+				return true, nil
+			}
+			w.seen[i] = true
+		}
+		w.matches++
+		m := &w.m
+		*m = tMatch{start: i, end: e, key: w.key, index: w.index}
+		return w.match(m)
+	}
+	if nod.Type&cParent > 0 {
+		return walkParent(input, nod, w)
+	}
+	if nod.Type&(cFunction|cName) > 0 {
+		if w.trace != nil {
+			return traceFunction(input, i, nod, w)
+		}
+		return false, errNotAddressable
+	}
+	if nod.Type&cScript > 0 {
+		tmp, err := scriptNode(input[i:], nod)
+		if tmp == nil || err != nil {
+			return true, err
+		}
+		defer releaseScriptNode(tmp)
+		nod = tmp
+	}
+	if nod.Type&cUnion > 0 {
+		return walkUnion(input, i, nod, w)
+	}
+	if w.seen != nil && (input[i] == '{' || input[i] == '[') {
+		w.parents = append(w.parents, tParent{i, w.key, w.index})
+		defer func() { w.parents = w.parents[:len(w.parents)-1] }()
+	}
+	switch input[i] {
+	case '{':
+		if w.objectIndexes && positional(nod) {
+			return walkObjectByIndex(input, i, nod, w)
+		}
+		return walkObject(input, i, nod, w)
+	case '[':
+		return walkArray(input, i, nod, w)
+	}
+	return true, nil
+}
+
+// traceFunction records the value a function (or a name selector) is applied to: the result is not
+// a part of the input, so the walk does not go any further and the value counts as a match
+func traceFunction(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	e, err := skipValue(input, i)
+	if err != nil {
+		return false, err
+	}
+	w.trace.examine(nod, 1)
+	w.trace.matched(nod, i, e)
+	w.matches++
+	w.m = tMatch{start: i, end: e, key: w.key, index: w.index}
+	return w.match(&w.m)
+}
+
+// walkInput walks the whole input reporting syntax errors as *JSONError
+func walkInput(input []byte, node *tNode, w *tWalker) (bool, error) {
+	if hasNode(node, cParent) {
+		w.seen = make(map[int]bool)
+	}
+	ok, err := walk(input, 0, node, w)
+	if err != nil {
+		err = inputError(input, err)
+	}
+	return ok, err
+}
+
+// walkUnion visits the values selected by every selector of a union in turn
+func walkUnion(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	for _, part := range nod.Union {
+		if ok, err := walk(input, i, part, w); !ok || err != nil {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+// walkObject visits matching members of an object (and deeper if deepscan).
+func walkObject(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	var (
+		err  error
+		key  []byte
+		s, e int
+	)
+	l := len(input)
+	last := i + 1 // end of the last member value (insertion point)
+	members := 0
+	found := false
+	taken := false // a single key has been resolved
+	lastStart := -1
+	if w.duplicates == DuplicateLast && singular(nod) {
+		if lastStart, err = w.lastMember(input, i, nod); err != nil {
+			return false, err
+		}
+	}
+
+	i++ // skip '{'
+	for i < l && input[i] != '}' {
+		if err = w.ctx.cancelled(); err != nil {
+			return false, err
+		}
+		key, i, err = readObjectKey(input, i)
+		if err != nil {
+			return false, err
+		}
+		if key == nil { // '}' reached
+			break
+		}
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return false, err
+		}
+		members++
+		last = e
+		w.trace.examine(nod, 1)
+		if !taken && nod.Type&(cDot|cDeep|cWild) > 0 && (nod.Type&cWild > 0 || w.keyIn(key, nod)) &&
+			(lastStart < 0 || s == lastStart) {
+			found = true
+			w.trace.matched(nod, s, e)
+			w.key, w.index = key, -1
+			matches := w.matches
+			if ok, err := walk(input, s, nod.Next, w); !ok || err != nil {
+				return ok, err
+			}
+			// as in getValue, a single key selects the first of the matching members (duplicate keys,
+			// see WithKeyMatch and WithDuplicateKeys) having a result
+			taken = singular(nod) && w.duplicates == DuplicateFirst && w.matches > matches
+		}
+		if nod.Type&cFilter > 0 {
+			b, err := filterMatch(input[s:e], nod, members-1)
+			if err != nil {
+				return false, err
+			}
+			if b {
+				found = true
+				w.trace.matched(nod, s, e)
+				w.key, w.index = key, -1
+				if ok, err := walk(input, s, nod.Next, w); !ok || err != nil {
+					return ok, err
+				}
+			}
+		}
+		if nod.Type&cDeep > 0 && w.descend() {
+			w.depth++
+			w.key, w.index = key, -1
+			ok, err := walk(input, s, nod, w)
+			w.depth--
+			if !ok || err != nil {
+				return ok, err
+			}
+		}
+	}
+	if i >= l {
+		return false, errUnexpectedEnd
+	}
+	if !found && w.missing != nil && singular(nod) {
+		return true, w.missing(last, members > 0, false, nod)
+	}
+	return true, nil
+}
+
+// walkArray visits matching elements of an array (and deeper if deepscan).
+func walkArray(input []byte, i int, nod *tNode, w *tWalker) (bool, error) {
+	buf := elemsPool.Get().(*[]tElem)
+	defer elemsPool.Put(buf)
+	elems, err := appendArrayElems((*buf)[:0], input, i)
+	*buf = elems
+	if err != nil {
+		return false, err
+	}
+	n := len(elems)
+	w.trace.examine(nod, n)
+	visit := func(k int) (bool, error) {
+		w.trace.matched(nod, elems[k].start, elems[k].end)
+		return walkElem(input, elems[k].start, k, nod.Next, w)
+	}
+	ok := true
+	deepWild := nod.Type&(cDeep|cWild) == cDeep|cWild
+	switch {
+	case nod.Type&cFilter > 0:
+		for k := 0; ok && err == nil && k < n; k++ {
+			var b bool
+			b, err = filterMatch(input[elems[k].start:elems[k].end], nod, k)
+			if b && err == nil {
+				ok, err = visit(k)
+			}
+		}
+	case deepWild:
+		// as in getValue, every element is followed by the values found inside it: $..*
+		for k := 0; ok && err == nil && k < n; k++ {
+			if ok, err = visit(k); ok && err == nil && w.descend() {
+				w.depth++
+				ok, err = walkElem(input, elems[k].start, k, nod, w)
+				w.depth--
+			}
+		}
+	default:
+		ok, err = visitIndexes(nod, n, visit)
+	}
+	if ok && err == nil && nod.Slice[0] == n && len(nod.Elems) == 0 && w.missing != nil && singular(nod) {
+		last := i + 1
+		if n > 0 {
+			last = elems[n-1].end
+		}
+		err = w.missing(last, n > 0, true, nod)
+	}
+	if nod.Type&cDeep > 0 && !deepWild && w.descend() {
+		w.depth++
+		for k := 0; ok && err == nil && k < n; k++ {
+			ok, err = walkElem(input, elems[k].start, k, nod, w)
+		}
+		w.depth--
+	}
+	return ok, err
+}
+
+// visitIndexes visits the elements out of n selected by a wildcard, a slice or indexes.
+// Negative indexes count from the end, indexes out of range are skipped. As in getValue, a list of
+// non-negative indexes ($[2,0,2]) selects the elements in document order, each one once.
+func visitIndexes(nod *tNode, n int, visit func(k int) (bool, error)) (bool, error) {
+	ok, err := true, error(nil)
+	index := func(k int) (bool, error) {
+		if k < 0 {
+			k += n
+		}
+		if k < 0 || k >= n {
+			return true, nil
+		}
+		return visit(k)
+	}
+	switch {
+	case nod.Type&cWild > 0:
+		for k := 0; ok && err == nil && k < n; k++ {
+			ok, err = visit(k)
+		}
+	case nod.Type&cSlice > 0:
+		a, b, step, _ := adjustBounds(nod.Slice[0], nod.Slice[1], nod.Slice[2], n)
+		if step > 0 && nod.Slice[0] != cEmpty && nod.Slice[0] >= n { // starts beyond the end
+			break
+		}
+		for ; ok && err == nil && ((a > b && step < 0) || (a < b && step > 0)); a += step {
+			ok, err = index(a)
+		}
+	case len(nod.Elems) > 0 && nod.Type&cFullScan == 0:
+		for k := 0; ok && err == nil && k < n; k++ {
+			if hasIndex(nod.Elems, k) {
+				ok, err = visit(k)
+			}
+		}
+	case len(nod.Elems) > 0:
+		for k := 0; ok && err == nil && k < len(nod.Elems); k++ {
+			ok, err = index(nod.Elems[k])
+		}
+	case nod.Slice[0] != cNAN && nod.Slice[0] != cEmpty:
+		ok, err = index(nod.Slice[0])
+	}
+	return ok, err
+}
+
+// hasIndex returns true if k is one of the indexes
+func hasIndex(indexes []int, k int) bool {
+	for _, i := range indexes {
+		if i == k {
+			return true
+		}
+	}
+	return false
+}
+
+// walkElem walks k-th array element
+func walkElem(input []byte, i, k int, nod *tNode, w *tWalker) (bool, error) {
+	if err := w.ctx.cancelled(); err != nil {
+		return false, err
+	}
+	w.key, w.index = nil, k
+	return walk(input, i, nod, w)
+}
+
+// elemsPool keeps element bounds buffers for walkArray
+var elemsPool = sync.Pool{
+	New: func() interface{} {
+		elems := make([]tElem, 0, 8)
+		return &elems
+	},
+}
+
+// arrayElems returns absolute bounds of all the elements of an array starting at input[i]
+func arrayElems(input []byte, i int) ([]tElem, error) {
+	return appendArrayElems(make([]tElem, 0, 8), input, i)
+}
+
+// appendArrayElems appends absolute bounds of all the elements of an array starting at input[i] to elems
+func appendArrayElems(elems []tElem, input []byte, i int) ([]tElem, error) {
+	var (
+		s, e int
+		err  error
+	)
+	l := len(input)
+	i, err = skipSpaces(input, i+1) // skip '['
+	if err != nil {
+		return nil, err
+	}
+	for i < l && input[i] != ']' {
+		s, e, i, err = valuate(input, i)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, tElem{s, e})
+	}
+	if i >= l {
+		return nil, errUnexpectedEnd
+	}
+	return elems, nil
+}
+
+// keyIn returns true if key matches one of the node keys (or key patterns)
+func (w *tWalker) keyIn(key []byte, nod *tNode) bool {
+	for _, k := range nod.Keys {
+		if w.keyMatch.equal(key, k, nod.Type&cGlob > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// singular returns true if nod addresses exactly one key or index: $.a, $['a'], $[0]
+func singular(nod *tNode) bool {
+	return nod.Type&^cDot == 0 && len(nod.Keys) == 1
+}